@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/form3tech/k8s-promoter/internal/git"
+	"github.com/form3tech/k8s-promoter/internal/promoter"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -64,10 +65,24 @@ func Test_arguments_are_set(t *testing.T) {
 
 	assert.Equal(t, "path/to/config.yaml", args.ConfigPath)
 	assert.Equal(t, "config-repository", args.ConfigRepository)
+	assert.Equal(t, promoter.ConfigSourceGitHub, args.ConfigSource)
+	assert.Equal(t, "master", args.ConfigRef)
 
 	assert.Equal(t, []string{"some-bot-1", "some-bot-2"}, args.NoIssueUsers)
 }
 
+func Test_multiple_targets(t *testing.T) {
+	cliArgs := getDefaultArgs()
+	cliArgs["-target"] = "prod-eu, prod-us"
+
+	setArgs(cliArgs)
+	setAuth(t, "username", "token")
+
+	args, err := parseArgs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod-eu", "prod-us"}, args.TargetEnvs())
+}
+
 func Test_empty_branch_default(t *testing.T) {
 	cliArgs := getDefaultArgs()
 	delete(cliArgs, "-branch")
@@ -167,3 +182,137 @@ func Test_empty_required_field(t *testing.T) {
 		})
 	}
 }
+
+func Test_freeze_override(t *testing.T) {
+	cliArgs := getDefaultArgs()
+	cliArgs["-freeze-override"] = "INC-123 hotfix"
+
+	setArgs(cliArgs)
+	setAuth(t, "username", "token")
+
+	args, err := parseArgs()
+	require.NoError(t, err)
+	assert.Equal(t, "INC-123 hotfix", args.FreezeOverride)
+}
+
+func Test_config_source(t *testing.T) {
+	cliArgs := getDefaultArgs()
+	cliArgs["-config-source"] = "git"
+	cliArgs["-config-ref"] = "v1.2.0"
+
+	setArgs(cliArgs)
+	setAuth(t, "username", "token")
+
+	args, err := parseArgs()
+	require.NoError(t, err)
+	assert.Equal(t, promoter.ConfigSourceGit, args.ConfigSource)
+	assert.Equal(t, "v1.2.0", args.ConfigRef)
+}
+
+func Test_previous_config_ref(t *testing.T) {
+	cliArgs := getDefaultArgs()
+	cliArgs["-previous-config-ref"] = "v1.1.0"
+
+	setArgs(cliArgs)
+	setAuth(t, "username", "token")
+
+	args, err := parseArgs()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", args.PreviousConfigRef)
+}
+
+func Test_local_config_source_does_not_require_config_repository(t *testing.T) {
+	cliArgs := getDefaultArgs()
+	cliArgs["-config-source"] = "local"
+	delete(cliArgs, "-config-repository")
+
+	setArgs(cliArgs)
+	setAuth(t, "username", "token")
+
+	args, err := parseArgs()
+	require.NoError(t, err)
+	assert.Equal(t, promoter.ConfigSourceLocal, args.ConfigSource)
+}
+
+func Test_unknown_config_source(t *testing.T) {
+	cliArgs := getDefaultArgs()
+	cliArgs["-config-source"] = "s3"
+
+	setArgs(cliArgs)
+	setAuth(t, "username", "token")
+
+	_, err := parseArgs()
+	require.EqualError(t, err, "invalid CLI argument: -config-source must be one of github, git, manifest-repo, local")
+}
+
+func Test_decommission(t *testing.T) {
+	setArgs(getDefaultArgs())
+	os.Args = append(os.Args, "-decommission")
+	setAuth(t, "username", "token")
+
+	args, err := parseArgs()
+	require.NoError(t, err)
+	assert.True(t, args.Decommission)
+}
+
+func Test_decommission_default(t *testing.T) {
+	setArgs(getDefaultArgs())
+	setAuth(t, "username", "token")
+
+	args, err := parseArgs()
+	require.NoError(t, err)
+	assert.False(t, args.Decommission)
+}
+
+func Test_validate_does_not_require_target(t *testing.T) {
+	cliArgs := getDefaultArgs()
+	delete(cliArgs, "-target")
+
+	setArgs(cliArgs)
+	os.Args = append(os.Args, "-validate")
+	setAuth(t, "username", "token")
+
+	args, err := parseArgs()
+	require.NoError(t, err)
+	assert.True(t, args.Validate)
+}
+
+func Test_semantic_diff(t *testing.T) {
+	setArgs(getDefaultArgs())
+	os.Args = append(os.Args, "-semantic-diff")
+	setAuth(t, "username", "token")
+
+	args, err := parseArgs()
+	require.NoError(t, err)
+	assert.True(t, args.SemanticDiff)
+}
+
+func Test_manual(t *testing.T) {
+	setArgs(getDefaultArgs())
+	os.Args = append(os.Args, "-manual")
+	setAuth(t, "username", "token")
+
+	args, err := parseArgs()
+	require.NoError(t, err)
+	assert.True(t, args.Manual)
+}
+
+func Test_drift_with_resync(t *testing.T) {
+	setArgs(getDefaultArgs())
+	os.Args = append(os.Args, "-drift", "-resync")
+	setAuth(t, "username", "token")
+
+	args, err := parseArgs()
+	require.NoError(t, err)
+	assert.True(t, args.Drift)
+	assert.True(t, args.Resync)
+}
+
+func Test_resync_requires_drift(t *testing.T) {
+	setArgs(getDefaultArgs())
+	os.Args = append(os.Args, "-resync")
+	setAuth(t, "username", "token")
+
+	_, err := parseArgs()
+	require.EqualError(t, err, "invalid CLI argument: -resync requires -drift")
+}
@@ -21,12 +21,22 @@ import (
 var (
 	ErrMissingArg = errors.New("missing CLI argument")
 	ErrMissingEnv = errors.New("missing Env variable")
+	ErrInvalidArg = errors.New("invalid CLI argument")
 )
 
 type userList []string
 
 const (
 	Timeout = 10 * time.Minute
+
+	// ExitCodeChangeFreeze is the exit status when a target environment wasn't promoted due to a change freeze.
+	ExitCodeChangeFreeze = 3
+
+	// ExitCodeExpiredExclusions is the exit status when validating finds expired workload exclusions.
+	ExitCodeExpiredExclusions = 4
+
+	// ExitCodeDrift is the exit status when drift detection finds drifted workloads which aren't re-synced.
+	ExitCodeDrift = 5
 )
 
 func main() {
@@ -48,9 +58,81 @@ func main() {
 	if err != nil {
 		log.Fatalf("manifest.New: %v", err)
 	}
-	err = prom.Promote(ctx, args.TargetEnv)
+
+	if args.Validate {
+		validate(prom, log)
+		return
+	}
+
+	if err := prom.ValidateTargets(args.TargetEnvs()); err != nil {
+		log.Fatalf("promoter.ValidateTargets: %v", err)
+	}
+
+	if args.Drift {
+		drift(ctx, prom, args, log)
+		return
+	}
+
+	frozen := false
+	for _, target := range args.TargetEnvs() {
+		summary, err := prom.Promote(ctx, target)
+		if errors.Is(err, promoter.ErrChangeFreeze) {
+			log.Warnf("promoter.Promote: %s: %v", target, err)
+			frozen = true
+			continue
+		}
+		if err != nil {
+			log.Fatalf("promoter.Promote: %s: %v", target, err)
+		}
+		summary.Log(log)
+	}
+
+	if frozen {
+		os.Exit(ExitCodeChangeFreeze)
+	}
+}
+
+// validate lists the expired workload exclusions instead of promoting.
+func validate(prom *promoter.Promoter, log *logrus.Entry) {
+	expired, err := prom.Validate()
 	if err != nil {
-		log.Fatalf("promoter.Promote: %v", err)
+		log.Fatalf("promoter.Validate: %v", err)
+	}
+
+	if len(expired) == 0 {
+		log.Info("No expired exclusions found")
+		return
+	}
+
+	for _, exc := range expired {
+		fmt.Println(exc)
+	}
+	os.Exit(ExitCodeExpiredExclusions)
+}
+
+// drift reports the workloads which drifted from their source instead of promoting.
+func drift(ctx context.Context, prom *promoter.Promoter, args *promoter.Args, log *logrus.Entry) {
+	drifted := false
+	for _, target := range args.TargetEnvs() {
+		report, err := prom.Drift(ctx, target)
+		frozen := errors.Is(err, promoter.ErrChangeFreeze)
+		if frozen {
+			log.Warnf("promoter.Drift: %s: %v", target, err)
+		} else if err != nil {
+			log.Fatalf("promoter.Drift: %s: %v", target, err)
+		}
+		report.Log(log)
+
+		for _, d := range report.Drifts {
+			fmt.Println(d)
+		}
+		if len(report.Drifts) > 0 && (!args.Resync || frozen) {
+			drifted = true
+		}
+	}
+
+	if drifted {
+		os.Exit(ExitCodeDrift)
 	}
 }
 
@@ -65,22 +147,35 @@ func parseArgs() (*promoter.Args, error) {
 
 	configRepoArg := "config-repository"
 	configPathArg := "config-path"
+	configSourceArg := "config-source"
+	configRefArg := "config-ref"
+	previousConfigRefArg := "previous-config-ref"
 
 	committerNameArg := "committer-name"
 	committerEmailArg := "committer-email"
 
 	noIssueUsersArg := "no-issue-users"
+	freezeOverrideArg := "freeze-override"
+	decommissionArg := "decommission"
+	validateArg := "validate"
+	manualArg := "manual"
+	semanticDiffArg := "semantic-diff"
+	driftArg := "drift"
+	resyncArg := "resync"
 
 	owner := flag.String(ownerArg, "form3tech", "The repository organisation")
 	repo := flag.String(repoArg, "", "The name of the target repository")
 	branch := flag.String(branchArg, "master", "The name of the branch you want the changes pushed into")
 
 	commitRange := flag.String(commitRangeArg, "", "The PR commit range which introduces changes to the workloads")
-	target := flag.String(targetArg, "", "The target environment to receive promoted workload")
+	target := flag.String(targetArg, "", "The target environment(s) to receive promoted workload (comma-separated)")
 	gpgKeyPath := flag.String(gpgKeyPathArg, "key.gpg", "Path to the GPG key used to sign commits")
 
 	configRepo := flag.String(configRepoArg, "", "The name of the repository to fetch the config file")
-	configPath := flag.String(configPathArg, "clusters.yaml", "Path to the clusters config file")
+	configPath := flag.String(configPathArg, "clusters.yaml", "Path to the clusters config file, or a directory or glob pattern (e.g. clusters/**/*.yaml) of config files")
+	configSource := flag.String(configSourceArg, string(promoter.ConfigSourceGitHub), "Where to read the clusters config file from: github, git, manifest-repo or local")
+	configRef := flag.String(configRefArg, promoter.DefaultConfigRef, "The ref of the config repository to read the clusters config file from")
+	previousConfigRef := flag.String(previousConfigRefArg, "", "The ref of the clusters config before the promotion, to relocate clusters whose manifest folder changed (the start of the commit range for manifest-repo)")
 
 	committerName := flag.String(committerNameArg, "", "Name of user to commit as")
 	committerEmail := flag.String(committerEmailArg, "", "Email of user to commit as")
@@ -88,6 +183,14 @@ func parseArgs() (*promoter.Args, error) {
 	var noIssueUsers userList
 	flag.Var(&noIssueUsers, noIssueUsersArg, "GitHub user(s) that should not be assigned users (comma-separated)")
 
+	freezeOverride := flag.String(freezeOverrideArg, "", "Reason for an emergency promotion overriding change freezes, recorded in the PRs")
+	decommission := flag.Bool(decommissionArg, false, "Raise PRs removing the folders of clusters which are no longer in the clusters config")
+	validate := flag.Bool(validateArg, false, "List the expired workload exclusions instead of promoting, the target isn't required")
+	manual := flag.Bool(manualArg, false, "The promotion is triggered manually, promoting workloads to the environments they're manually promoted to")
+	semanticDiff := flag.Bool(semanticDiffArg, false, "Skip workload changes which only change comments, whitespace or key order of YAML files")
+	drift := flag.Bool(driftArg, false, "Report the workloads of the target environment(s) which drifted from their source instead of promoting")
+	resync := flag.Bool(resyncArg, false, "With -drift, raise PRs re-syncing the drifted workloads with their source")
+
 	flag.Parse()
 
 	if empty(owner) {
@@ -103,19 +206,29 @@ func parseArgs() (*promoter.Args, error) {
 	if empty(commitRange) {
 		return nil, argError(commitRangeArg)
 	}
-	if empty(target) {
+	if empty(target) && !*validate {
 		return nil, argError(targetArg)
 	}
 	if empty(gpgKeyPath) {
 		return nil, argError(gpgKeyPathArg)
 	}
+	if *resync && !*drift {
+		return nil, fmt.Errorf("%w: -%s requires -%s", ErrInvalidArg, resyncArg, driftArg)
+	}
 
-	if empty(configRepo) {
+	source := promoter.ConfigSource(*configSource)
+	if !validConfigSource(source) {
+		return nil, fmt.Errorf("%w: -%s must be one of %s", ErrInvalidArg, configSourceArg, configSources())
+	}
+	if empty(configRepo) && (source == promoter.ConfigSourceGitHub || source == promoter.ConfigSourceGit) {
 		return nil, argError(configRepoArg)
 	}
 	if empty(configPath) {
 		return nil, argError(configPathArg)
 	}
+	if empty(configRef) {
+		return nil, argError(configRefArg)
+	}
 
 	if empty(committerName) {
 		return nil, argError(committerNameArg)
@@ -151,11 +264,23 @@ func parseArgs() (*promoter.Args, error) {
 		ConfigPath:       *configPath,
 		GPGKeyPath:       *gpgKeyPath,
 		ConfigRepository: *configRepo,
+		ConfigSource:     source,
+		ConfigRef:        *configRef,
+
+		PreviousConfigRef: *previousConfigRef,
 
 		CommitterName:  *committerName,
 		CommitterEmail: *committerEmail,
 
 		NoIssueUsers: noIssueUsers,
+
+		FreezeOverride: *freezeOverride,
+		Decommission:   *decommission,
+		Validate:       *validate,
+		Manual:         *manual,
+		SemanticDiff:   *semanticDiff,
+		Drift:          *drift,
+		Resync:         *resync,
 	}
 
 	return args, nil
@@ -186,6 +311,23 @@ func empty(s *string) bool {
 	return false
 }
 
+func validConfigSource(source promoter.ConfigSource) bool {
+	for _, s := range promoter.ConfigSources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+func configSources() string {
+	var sources []string
+	for _, s := range promoter.ConfigSources {
+		sources = append(sources, string(s))
+	}
+	return strings.Join(sources, ", ")
+}
+
 func argError(a string) error {
 	return fmt.Errorf("%w: -%s is a required argument", ErrMissingArg, a)
 }
@@ -7,17 +7,29 @@ import (
 	"io"
 	"path/filepath"
 	"sort"
+	"time"
 
 	"github.com/form3tech/k8s-promoter/internal/environment"
 
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	ConfigTypeCluster      = "Cluster"
+	ConfigTypeClusterGroup = "ClusterGroup"
+	ConfigTypeEnvironment  = "Environment"
+	ConfigTypeWorkload     = "Workload"
+	ConfigTypeLayout       = "Layout"
+)
+
 type Cluster struct {
 	Version    string          `yaml:"version"`
 	ConfigType string          `yaml:"configType"`
 	Metadata   ClusterMetadata `yaml:"metadata"`
 	Spec       ClusterSpec     `yaml:"spec"`
+
+	// Source is where the cluster was declared, errors about the cluster refer to it.
+	Source Location `yaml:"-"`
 }
 
 type ClusterMetadata struct {
@@ -31,13 +43,25 @@ type Labels map[string]string
 type ClusterSpec struct {
 	ManifestFolder string `yaml:"manifestFolder"`
 	ConfigFolder   string `yaml:"configFolder"`
+	Paused         *Pause `yaml:"paused,omitempty"`
 }
 
 // AllowWorkload should pass if there is a zero-value config. This could happen
 // if there was no workload config file to be parsed, and this is currently acceptable.
+// The cluster must match all the inclusions of the workload and none of its unexpired exclusions.
 func (c *Cluster) AllowWorkload(wr Workload) bool {
+	return c.AllowWorkloadAt(wr, time.Now())
+}
+
+// AllowWorkloadAt is AllowWorkload with the exclusions which haven't expired at t.
+func (c *Cluster) AllowWorkloadAt(wr Workload, t time.Time) bool {
+	for _, inc := range wr.Spec.Inclusions {
+		if !inc.Includes(c.Metadata.Labels) {
+			return false
+		}
+	}
 	for _, exc := range wr.Spec.Exclusions {
-		if exc.Excludes(c.Metadata.Labels) {
+		if !exc.Expired(t) && exc.Excludes(c.Metadata.Labels) {
 			return false
 		}
 	}
@@ -60,6 +84,10 @@ func (c Cluster) WorkloadPath(name string) string {
 	return filepath.Join(c.Spec.ManifestFolder, name)
 }
 
+func (c *Cluster) Environment() environment.Env {
+	return environment.Env(c.Metadata.Labels["environment"])
+}
+
 func (c Cluster) validate() error {
 	if c.Name() == "" {
 		return errors.New("name is required")
@@ -67,7 +95,7 @@ func (c Cluster) validate() error {
 	if c.Spec.ManifestFolder == "" {
 		return errors.New("manifestfolder is required")
 	}
-	return environment.Env(c.Metadata.Labels["environment"]).Validate()
+	return nil
 }
 
 // FilterByKeys returns a new Labels struct containing only labels with
@@ -154,49 +182,179 @@ func (c Clusters) Filter(predicate FilterFn) Clusters {
 	return filtered
 }
 
-// Group groups all development clusters together in one group
-// for test & production, we group the clusters individually.
-func (c Clusters) Group(target environment.Env) []Clusters {
-	var res []Clusters
+// Config holds the documents of the clusters configuration file together with the
+// promotion pipeline they declare.
+type Config struct {
+	Clusters     Clusters
+	Environments Environments
+	Pipeline     environment.Pipeline
+	Layout       Layout
+}
+
+func ParseClusters(in io.Reader) (Clusters, error) {
+	config, err := ParseConfig(in)
+	if err != nil {
+		return Clusters{}, err
+	}
+	return config.Clusters, nil
+}
 
-	if target != environment.Development {
-		for _, cluster := range c {
-			res = append(res, Clusters{cluster})
+// ParseConfig reads all Cluster, ClusterGroup, Environment and Layout documents, expands the cluster groups into
+// clusters, validates the clusters as a set and verifies that every cluster belongs to an environment of
+// the resulting pipeline. All the problems of the clusters are reported at once as ValidationErrors.
+func ParseConfig(in io.Reader) (Config, error) {
+	return ParseConfigFile("", in)
+}
+
+// ParseConfigFile is ParseConfig for the configuration file named file, which errors refer to.
+func ParseConfigFile(file string, in io.Reader) (Config, error) {
+	return ParseConfigFiles(ConfigFile{Name: file, Content: in})
+}
+
+// ParseConfigFiles is ParseConfig for a configuration split across several files. The documents
+// of all files are merged, so clusters are validated as a set across files.
+func ParseConfigFiles(files ...ConfigFile) (Config, error) {
+	config := Config{Clusters: Clusters{}}
+	for _, file := range files {
+		if err := config.decodeFile(file); err != nil {
+			return Config{}, err
 		}
 	}
 
-	if target == environment.Development {
-		res = []Clusters{c}
+	pipeline, err := config.Environments.Pipeline()
+	if err != nil {
+		return Config{}, err
 	}
-	return res
-}
 
-func ParseClusters(in io.Reader) (Clusters, error) {
-	decoder := yaml.NewDecoder(in)
-	clusters := Clusters{}
+	problems := config.Clusters.problems()
+	for i, cluster := range config.Clusters {
+		if cluster.Environment() == "" {
+			continue // reported as a problem of the clusters
+		}
+		if err := pipeline.Validate(cluster.Environment()); err != nil {
+			problems = append(problems, clusterProblem{index: i, err: cluster.Source.wrap(err)})
+		}
+	}
+	// the waves aren't clusters, their problems are reported after the problems of the clusters
+	for i, env := range config.Environments {
+		for _, err := range env.unknownWaveClusters(config.Clusters) {
+			problems = append(problems, clusterProblem{index: len(config.Clusters) + i, err: env.Source.wrap(err)})
+		}
+	}
 
-	for {
-		cluster, err := decodeCluster(decoder)
-		if err != nil {
+	if err := validationErrors(problems); err != nil {
+		return Config{}, err
+	}
+
+	if config.Layout == (Layout{}) {
+		config.Layout = DefaultLayout()
+	}
+	config.Pipeline = pipeline
+	return config, nil
+}
+
+func (c *Config) decodeFile(file ConfigFile) error {
+	decoder := yaml.NewDecoder(file.Content)
+	for index := 1; ; index++ {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
 			if err == io.EOF {
-				break // We've read everything in the file
+				return nil // We've read everything in the file
 			}
-			return Clusters{}, fmt.Errorf("could not read config file: %w", err)
+			return &DocumentError{File: file.Name, Document: index, Err: fmt.Errorf("could not read config file: %w", err)}
+		}
+
+		doc := document{file: file.Name, index: index, node: &node}
+		if doc.empty() {
+			continue
+		}
+
+		if err := c.decode(doc); err != nil {
+			return err
 		}
+	}
+}
+
+// GroupingPolicy returns the grouping policy declared for the environment. By default all
+// clusters of the first environment of the pipeline are grouped together in one group,
+// for the following environments, we group the clusters individually.
+func (c Config) GroupingPolicy(env environment.Env) GroupingPolicy {
+	var policy GroupingPolicy
+	if spec := c.environmentSpec(env); spec.Grouping != nil {
+		policy = *spec.Grouping
+	}
+
+	if policy.Strategy == "" {
+		policy.Strategy = GroupByCluster
+		if c.Pipeline.IsFirst(env) {
+			policy.Strategy = GroupByEnvironment
+		}
+	}
+	return policy
+}
+
+func (c *Config) decode(doc document) error {
+	configType, err := doc.prepare(ConfigTypeCluster)
+	if err != nil {
+		return err
+	}
 
-		clusters = append(clusters, cluster)
+	switch configType {
+	case ConfigTypeEnvironment:
+		env, err := decodeEnvironment(doc)
+		if err != nil {
+			return err
+		}
+		c.Environments = append(c.Environments, env)
+	case ConfigTypeCluster:
+		cluster, err := decodeCluster(doc)
+		if err != nil {
+			return err
+		}
+		c.Clusters = append(c.Clusters, cluster)
+	case ConfigTypeClusterGroup:
+		clusters, err := decodeClusterGroup(doc)
+		if err != nil {
+			return err
+		}
+		c.Clusters = append(c.Clusters, clusters...)
+	case ConfigTypeLayout:
+		if c.Layout != (Layout{}) {
+			return doc.wrap(errors.New("the repository layout must be declared once"))
+		}
+		layout, err := decodeLayout(doc)
+		if err != nil {
+			return err
+		}
+		c.Layout = layout
+	default:
+		return doc.unknownConfigType(configType, ConfigTypeCluster, ConfigTypeClusterGroup, ConfigTypeEnvironment, ConfigTypeLayout)
 	}
-	return clusters, nil
+	return nil
 }
 
-func decodeCluster(decoder *yaml.Decoder) (Cluster, error) {
+func decodeCluster(doc document) (Cluster, error) {
 	cluster := Cluster{}
-	if err := decoder.Decode(&cluster); err != nil {
+	if err := doc.decode(&cluster); err != nil {
 		return Cluster{}, err
 	}
+	cluster.Source = doc.location()
 
 	if err := cluster.validate(); err != nil {
-		return Cluster{}, err
+		return Cluster{}, doc.wrap(err)
 	}
 	return cluster, nil
 }
+
+func decodeEnvironment(doc document) (Environment, error) {
+	env := Environment{}
+	if err := doc.decode(&env); err != nil {
+		return Environment{}, err
+	}
+	env.Source = doc.location()
+
+	if err := env.validate(); err != nil {
+		return Environment{}, doc.wrap(err)
+	}
+	return env, nil
+}
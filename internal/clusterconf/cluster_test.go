@@ -4,6 +4,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/form3tech/k8s-promoter/internal/environment"
 
@@ -180,8 +181,9 @@ func Test_parseClusters(t *testing.T) {
 						},
 					},
 					Spec: ClusterSpec{
-						ManifestFolder: Path("/promoted/development/dev4/cloud1"),
+						ManifestFolder: "/flux/promoted/development/dev4/cloud1",
 					},
+					Source: Location{Document: 1, Line: 1},
 				},
 				Cluster{
 					Version:    "v0.1",
@@ -194,8 +196,9 @@ func Test_parseClusters(t *testing.T) {
 						},
 					},
 					Spec: ClusterSpec{
-						ManifestFolder: Path("/promoted/test/test1/cloud1"),
+						ManifestFolder: "/flux/promoted/test/test1/cloud1",
 					},
+					Source: Location{Document: 2, Line: 11},
 				},
 				Cluster{
 					Version:    "v0.1",
@@ -208,8 +211,9 @@ func Test_parseClusters(t *testing.T) {
 						},
 					},
 					Spec: ClusterSpec{
-						ManifestFolder: Path("/promoted/production/prod1/cloud1"),
+						ManifestFolder: "/flux/promoted/production/prod1/cloud1",
 					},
+					Source: Location{Document: 3, Line: 21},
 				},
 			},
 		},
@@ -227,8 +231,9 @@ func Test_parseClusters(t *testing.T) {
 						},
 					},
 					Spec: ClusterSpec{
-						ManifestFolder: Path("/promoted/development/dev4/cloud1"),
+						ManifestFolder: "/flux/promoted/development/dev4/cloud1",
 					},
+					Source: Location{Document: 1, Line: 1},
 				},
 			},
 		},
@@ -251,3 +256,129 @@ func testName(file string) string {
 	file = strings.ReplaceAll(file, "testdata/", "")
 	return strings.ReplaceAll(file, ".yaml", "")
 }
+
+func Test_parseConfig_Pipeline(t *testing.T) {
+	f, err := os.Open("testdata/clusters/pipeline.yaml")
+	require.NoError(t, err)
+	defer f.Close()
+
+	got, err := ParseConfig(f)
+	require.NoError(t, err)
+
+	require.Len(t, got.Clusters, 1)
+	require.Len(t, got.Environments, 3)
+	require.Equal(t, []environment.Env{"development", "staging", "sandbox"}, got.Pipeline.Environments())
+
+	for target, source := range map[environment.Env]environment.Env{
+		"development": environment.SourceManifest,
+		"staging":     "development",
+		"sandbox":     "development",
+	} {
+		got, err := got.Pipeline.ManifestSource(target)
+		require.NoError(t, err)
+		assert.Equal(t, source, got, target)
+	}
+}
+
+func Test_parseConfig_DefaultPipeline(t *testing.T) {
+	f, err := os.Open("testdata/clusters/many.yaml")
+	require.NoError(t, err)
+	defer f.Close()
+
+	got, err := ParseConfig(f)
+	require.NoError(t, err)
+	require.Empty(t, got.Environments)
+	require.Equal(t, environment.DefaultPipeline(), got.Pipeline)
+}
+
+func Test_parseConfig_UnknownEnvironment(t *testing.T) {
+	f, err := os.Open("testdata/clusters/unknown-environment.yaml")
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = ParseConfig(f)
+	require.EqualError(t, err, "document 1: line 1: env 'staging' is not one of development, test, production")
+}
+
+func Test_parseConfig_MinSoakTime(t *testing.T) {
+	got, err := ParseConfig(strings.NewReader(`version: "v0.1"
+configType: Environment
+metadata:
+  name: development
+---
+version: "v0.1"
+configType: Environment
+metadata:
+  name: test
+spec:
+  minSoakTime: 24h
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Duration(0), got.MinSoakTime(environment.Development))
+	assert.Equal(t, 24*time.Hour, got.MinSoakTime(environment.Test))
+	assert.Equal(t, time.Duration(0), got.MinSoakTime(environment.SourceManifest))
+}
+
+func Test_parseConfigFiles(t *testing.T) {
+	environments := `version: "v0.1"
+configType: Environment
+metadata:
+  name: development
+---
+version: "v0.1"
+configType: Environment
+metadata:
+  name: production
+`
+	development := `version: "v0.1"
+configType: Cluster
+metadata:
+  name: dev1
+  labels:
+    environment: development
+spec:
+  manifestFolder: /promoted/development/dev1
+`
+	production := `version: "v0.1"
+configType: Cluster
+metadata:
+  name: prod1
+  labels:
+    environment: production
+spec:
+  manifestFolder: /promoted/production/prod1
+`
+
+	got, err := ParseConfigFiles(
+		ConfigFile{Name: "clusters/environments.yaml", Content: strings.NewReader(environments)},
+		ConfigFile{Name: "clusters/development.yaml", Content: strings.NewReader(development)},
+		ConfigFile{Name: "clusters/production.yaml", Content: strings.NewReader(production)},
+	)
+	require.NoError(t, err)
+
+	require.Len(t, got.Clusters, 2)
+	assert.Equal(t, Location{File: "clusters/development.yaml", Document: 1, Line: 1}, got.Clusters[0].Source)
+	assert.Equal(t, Location{File: "clusters/production.yaml", Document: 1, Line: 1}, got.Clusters[1].Source)
+	assert.Equal(t, []environment.Env{"development", "production"}, got.Pipeline.Environments())
+}
+
+func Test_parseConfigFiles_ChecksUniquenessAcrossFiles(t *testing.T) {
+	cluster := `version: "v0.1"
+configType: Cluster
+metadata:
+  name: dev1
+  labels:
+    environment: development
+spec:
+  manifestFolder: /promoted/development/dev1
+`
+
+	_, err := ParseConfigFiles(
+		ConfigFile{Name: "clusters/a.yaml", Content: strings.NewReader(cluster)},
+		ConfigFile{Name: "clusters/b.yaml", Content: strings.NewReader("---\n" + cluster)},
+	)
+	require.EqualError(t, err, "2 validation errors:\n"+
+		"clusters/b.yaml: document 1: line 2: cluster dev1: declared more than once, first at clusters/a.yaml: document 1: line 1\n"+
+		"clusters/b.yaml: document 1: line 2: cluster dev1: manifestFolder /promoted/development/dev1 is already used by cluster dev1")
+}
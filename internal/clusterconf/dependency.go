@@ -0,0 +1,114 @@
+package clusterconf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dependencyGraph finds the dependency cycles of the workloads with Tarjan's strongly connected components
+// algorithm. It only walks the workloads reachable from the ones asked about, each of them once, so the
+// workloads elsewhere in the tree don't matter.
+type dependencyGraph struct {
+	dependsOn func(workloadID string) []string
+
+	edges   map[string][]string
+	index   map[string]int
+	lowlink map[string]int
+	onStack map[string]bool
+	stack   []string
+	cycles  map[string]error
+}
+
+func newDependencyGraph(dependsOn func(workloadID string) []string) *dependencyGraph {
+	return &dependencyGraph{
+		dependsOn: dependsOn,
+		edges:     map[string][]string{},
+		index:     map[string]int{},
+		lowlink:   map[string]int{},
+		onStack:   map[string]bool{},
+		cycles:    map[string]error{},
+	}
+}
+
+// cycle returns an ErrDependencyCycle if the workload is on a dependency cycle, with the shortest cycle
+// starting at the workload.
+func (g *dependencyGraph) cycle(workloadID string) error {
+	if _, walked := g.index[workloadID]; !walked {
+		g.connect(workloadID)
+	}
+	return g.cycles[workloadID]
+}
+
+func (g *dependencyGraph) connect(id string) {
+	g.index[id] = len(g.index)
+	g.lowlink[id] = g.index[id]
+	g.stack = append(g.stack, id)
+	g.onStack[id] = true
+
+	g.edges[id] = g.dependsOn(id)
+	for _, dependency := range g.edges[id] {
+		if _, walked := g.index[dependency]; !walked {
+			g.connect(dependency)
+			if g.lowlink[dependency] < g.lowlink[id] {
+				g.lowlink[id] = g.lowlink[dependency]
+			}
+		} else if g.onStack[dependency] && g.index[dependency] < g.lowlink[id] {
+			g.lowlink[id] = g.index[dependency]
+		}
+	}
+
+	if g.lowlink[id] != g.index[id] {
+		return
+	}
+
+	component := map[string]bool{}
+	for {
+		member := g.stack[len(g.stack)-1]
+		g.stack = g.stack[:len(g.stack)-1]
+		g.onStack[member] = false
+		component[member] = true
+		if member == id {
+			break
+		}
+	}
+
+	// Workloads can't depend on themselves, a component of one workload isn't a cycle.
+	if len(component) == 1 {
+		return
+	}
+	for member := range component {
+		cycle := g.shortestCycle(member, component)
+		g.cycles[member] = fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> "))
+	}
+}
+
+// shortestCycle returns the shortest path from the workload back to itself through the workloads of its
+// strongly connected component.
+func (g *dependencyGraph) shortestCycle(start string, component map[string]bool) []string {
+	previous := map[string]string{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		for _, dependency := range g.edges[id] {
+			if dependency == start {
+				cycle := []string{start}
+				for at := id; at != start; at = previous[at] {
+					cycle = append(cycle, at)
+				}
+				for i, j := 1, len(cycle)-1; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+				return append(cycle, start)
+			}
+
+			if _, seen := previous[dependency]; seen || !component[dependency] {
+				continue
+			}
+			previous[dependency] = id
+			queue = append(queue, dependency)
+		}
+	}
+	return nil
+}
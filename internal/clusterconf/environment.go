@@ -0,0 +1,118 @@
+package clusterconf
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/form3tech/k8s-promoter/internal/environment"
+)
+
+// Environment declares a stage of the promotion pipeline. When spec.source is omitted
+// the environment is promoted from the environment declared before it, or from the
+// source manifests if it is the first one.
+type Environment struct {
+	Version    string              `yaml:"version"`
+	ConfigType string              `yaml:"configType"`
+	Metadata   EnvironmentMetadata `yaml:"metadata"`
+	Spec       EnvironmentSpec     `yaml:"spec"`
+
+	// Source is where the environment was declared, errors about its waves refer to it.
+	Source Location `yaml:"-"`
+}
+
+type EnvironmentMetadata struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+}
+
+type EnvironmentSpec struct {
+	Source    string          `yaml:"source,omitempty"`
+	Grouping  *GroupingPolicy `yaml:"grouping,omitempty"`
+	Waves     []Wave          `yaml:"waves,omitempty"`
+	WaveLabel string          `yaml:"waveLabel,omitempty"`
+
+	// MinSoakTime is how long a workload change must have been in this environment
+	// before it is promoted to the environments sourced from it.
+	MinSoakTime time.Duration `yaml:"minSoakTime,omitempty"`
+
+	Freezes []FreezeWindow `yaml:"freezes,omitempty"`
+}
+
+func (e Environment) Name() environment.Env {
+	return environment.Env(e.Metadata.Name)
+}
+
+func (e Environment) validate() error {
+	if e.Name() == "" {
+		return errors.New("name is required")
+	}
+	if e.Spec.Grouping != nil {
+		if err := e.Spec.Grouping.validate(); err != nil {
+			return fmt.Errorf("environment %s: %w", e.Name(), err)
+		}
+	}
+	if e.Spec.MinSoakTime < 0 {
+		return fmt.Errorf("environment %s: minSoakTime must not be negative", e.Name())
+	}
+	if err := validateWaves(e.Spec.Waves, e.Spec.WaveLabel); err != nil {
+		return fmt.Errorf("environment %s: %w", e.Name(), err)
+	}
+	for _, freeze := range e.Spec.Freezes {
+		if err := freeze.validate(); err != nil {
+			return fmt.Errorf("environment %s: %w", e.Name(), err)
+		}
+	}
+	return nil
+}
+
+type Environments []Environment
+
+// Pipeline builds the promotion pipeline out of the declared environments. Without
+// any declared environment the default development -> test -> production chain is used.
+func (e Environments) Pipeline() (environment.Pipeline, error) {
+	if len(e) == 0 {
+		return environment.DefaultPipeline(), nil
+	}
+
+	var stages []environment.Stage
+	previous := environment.SourceManifest
+	for _, env := range e {
+		source := environment.Env(env.Spec.Source)
+		if source == "" {
+			source = previous
+		}
+
+		stages = append(stages, environment.Stage{Source: source, Target: env.Name()})
+		previous = env.Name()
+	}
+
+	return environment.NewPipeline(stages...)
+}
+
+// environmentSpec returns the spec of the declared environment env, or an empty spec
+// if env wasn't declared.
+func (c Config) environmentSpec(env environment.Env) EnvironmentSpec {
+	for _, e := range c.Environments {
+		if e.Name() == env {
+			return e.Spec
+		}
+	}
+	return EnvironmentSpec{}
+}
+
+// MinSoakTime returns how long workload changes must stay in env before being promoted out of it.
+func (c Config) MinSoakTime(env environment.Env) time.Duration {
+	return c.environmentSpec(env).MinSoakTime
+}
+
+// ActiveFreezes returns the freeze windows of env which are in force at t.
+func (c Config) ActiveFreezes(env environment.Env, t time.Time) []FreezeWindow {
+	var active []FreezeWindow
+	for _, freeze := range c.environmentSpec(env).Freezes {
+		if freeze.Active(t) {
+			active = append(active, freeze)
+		}
+	}
+	return active
+}
@@ -0,0 +1,101 @@
+package clusterconf
+
+import (
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ConfigFile is a configuration file to be parsed.
+type ConfigFile struct {
+	Name    string
+	Content io.Reader
+}
+
+// IsConfigPattern reports whether the configuration path is a glob pattern rather than a file or a directory.
+func IsConfigPattern(configPath string) bool {
+	return strings.ContainsAny(configPath, `*?[\`)
+}
+
+// ConfigPathBase returns the directory containing all the files the configuration path can match,
+// i.e. the configuration path itself unless it is a glob pattern.
+func ConfigPathBase(configPath string) string {
+	var base []string
+	for _, segment := range splitPath(configPath) {
+		if IsConfigPattern(segment) {
+			break
+		}
+		base = append(base, segment)
+	}
+
+	if strings.HasPrefix(configPath, "/") {
+		return "/" + strings.Join(base, "/")
+	}
+	return strings.Join(base, "/")
+}
+
+// MatchConfigFiles returns the files, in lexical order, selected by the configuration path among
+// files, the slash separated paths of the files of a tree. The configuration path is either a
+// file, a directory, whose YAML files are all selected, or a glob pattern where ** matches any
+// number of directories.
+func MatchConfigFiles(configPath string, files []string) []string {
+	var matches []string
+	for _, file := range files {
+		if matchConfigFile(configPath, file) {
+			matches = append(matches, file)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+func matchConfigFile(configPath, file string) bool {
+	pattern, name := splitPath(configPath), splitPath(file)
+	if IsConfigPattern(configPath) {
+		return matchSegments(pattern, name)
+	}
+
+	if len(name) == len(pattern) {
+		return strings.Join(name, "/") == strings.Join(pattern, "/")
+	}
+	return isYAML(file) && len(name) > len(pattern) && strings.Join(name[:len(pattern)], "/") == strings.Join(pattern, "/")
+}
+
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(name) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
+
+func splitPath(p string) []string {
+	var segments []string
+	for _, segment := range strings.Split(path.Clean("/"+p), "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+func isYAML(file string) bool {
+	ext := path.Ext(file)
+	return ext == ".yaml" || ext == ".yml"
+}
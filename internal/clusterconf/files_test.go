@@ -0,0 +1,64 @@
+package clusterconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchConfigFiles(t *testing.T) {
+	files := []string{
+		"clusters.yaml",
+		"clusters/production/eu.yaml",
+		"clusters/production/us.yml",
+		"clusters/production/README.md",
+		"clusters/test.yaml",
+		"other/clusters.yaml",
+	}
+
+	tests := map[string]struct {
+		configPath string
+		want       []string
+	}{
+		"file": {
+			configPath: "clusters.yaml",
+			want:       []string{"clusters.yaml"},
+		},
+		"file with leading slash": {
+			configPath: "/clusters/test.yaml",
+			want:       []string{"clusters/test.yaml"},
+		},
+		"directory": {
+			configPath: "clusters",
+			want:       []string{"clusters/production/eu.yaml", "clusters/production/us.yml", "clusters/test.yaml"},
+		},
+		"glob": {
+			configPath: "clusters/*.yaml",
+			want:       []string{"clusters/test.yaml"},
+		},
+		"recursive glob": {
+			configPath: "clusters/**/*.yaml",
+			want:       []string{"clusters/production/eu.yaml", "clusters/test.yaml"},
+		},
+		"recursive glob from the root": {
+			configPath: "**/clusters.yaml",
+			want:       []string{"clusters.yaml", "other/clusters.yaml"},
+		},
+		"no match": {
+			configPath: "missing/*.yaml",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchConfigFiles(tt.configPath, files))
+		})
+	}
+}
+
+func TestConfigPathBase(t *testing.T) {
+	assert.Equal(t, "clusters.yaml", ConfigPathBase("clusters.yaml"))
+	assert.Equal(t, "clusters", ConfigPathBase("clusters/**/*.yaml"))
+	assert.Equal(t, "/etc/promoter", ConfigPathBase("/etc/promoter/*.yaml"))
+	assert.Equal(t, "", ConfigPathBase("*.yaml"))
+}
@@ -0,0 +1,95 @@
+package clusterconf
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxFreezeDuration bounds recurring freeze windows, which are looked up minute by minute.
+const maxFreezeDuration = 31 * 24 * time.Hour
+
+// FreezeWindow is a period during which workloads aren't promoted to an environment. It is either
+// a date range between start and end, or a recurring window opening on a cron schedule (in UTC) and
+// lasting for duration. A window listing workloads only freezes those workloads.
+type FreezeWindow struct {
+	Name      string        `yaml:"name"`
+	Reason    string        `yaml:"reason,omitempty"`
+	Start     time.Time     `yaml:"start,omitempty"`
+	End       time.Time     `yaml:"end,omitempty"`
+	Schedule  string        `yaml:"schedule,omitempty"`
+	Duration  time.Duration `yaml:"duration,omitempty"`
+	Workloads []string      `yaml:"workloads,omitempty"`
+}
+
+func (w FreezeWindow) validate() error {
+	if w.Name == "" {
+		return errors.New("freeze name is required")
+	}
+
+	isRange := !w.Start.IsZero() || !w.End.IsZero()
+	isRecurring := w.Schedule != "" || w.Duration != 0
+	switch {
+	case isRange && isRecurring:
+		return fmt.Errorf("freeze %s: start and end can't be combined with schedule and duration", w.Name)
+	case isRange:
+		if !w.Start.Before(w.End) {
+			return fmt.Errorf("freeze %s: start must be before end", w.Name)
+		}
+	case isRecurring:
+		if w.Duration <= 0 || w.Duration > maxFreezeDuration {
+			return fmt.Errorf("freeze %s: duration must be positive and at most %s", w.Name, maxFreezeDuration)
+		}
+		if _, err := parseSchedule(w.Schedule); err != nil {
+			return fmt.Errorf("freeze %s: %w", w.Name, err)
+		}
+	default:
+		return fmt.Errorf("freeze %s: either start and end or schedule and duration are required", w.Name)
+	}
+	return nil
+}
+
+// Active reports whether the window is in force at t.
+func (w FreezeWindow) Active(t time.Time) bool {
+	_, ok := w.EndsAt(t)
+	return ok
+}
+
+// EndsAt returns when the window in force at t ends. It returns false if the window isn't in force at t.
+func (w FreezeWindow) EndsAt(t time.Time) (time.Time, bool) {
+	if w.Schedule == "" {
+		return w.End, !t.Before(w.Start) && t.Before(w.End)
+	}
+
+	s, err := parseSchedule(w.Schedule)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	start, ok := s.lastStart(t.UTC(), w.Duration)
+	if !ok {
+		return time.Time{}, false
+	}
+	return start.Add(w.Duration), true
+}
+
+// Freezes returns whether the window applies to the workload.
+func (w FreezeWindow) Freezes(workload string) bool {
+	if len(w.Workloads) == 0 {
+		return true
+	}
+	for _, name := range w.Workloads {
+		if name == workload {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the window for logs and pull request descriptions.
+func (w FreezeWindow) String() string {
+	if w.Reason == "" {
+		return w.Name
+	}
+	return fmt.Sprintf("%s (%s)", w.Name, w.Reason)
+}
@@ -0,0 +1,179 @@
+package clusterconf
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/form3tech/k8s-promoter/internal/environment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezeWindow_Active(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return tm
+	}
+
+	quarterEnd := FreezeWindow{
+		Name:  "quarter-end",
+		Start: at("2022-03-25T00:00:00Z"),
+		End:   at("2022-04-01T00:00:00Z"),
+	}
+	// from Friday 18:00 until Monday 06:00
+	weekend := FreezeWindow{
+		Name:     "weekend",
+		Schedule: "0 18 * * 5",
+		Duration: 60 * time.Hour,
+	}
+	holidays := FreezeWindow{
+		Name:     "holidays",
+		Schedule: "0 0 24-26 12 *",
+		Duration: 24 * time.Hour,
+	}
+	// Fridays on odd days of the month, as a day field starting with '*' doesn't widen the other one
+	oddFridays := FreezeWindow{
+		Name:     "odd-days",
+		Schedule: "0 0 */2 * 5",
+		Duration: 24 * time.Hour,
+	}
+	// every day is selected, so only Fridays match
+	everyDayAndFridays := FreezeWindow{
+		Name:     "fridays",
+		Schedule: "0 0 */1 * 5",
+		Duration: 24 * time.Hour,
+	}
+
+	tests := map[string]struct {
+		window FreezeWindow
+		at     string
+		active bool
+	}{
+		"before range":           {window: quarterEnd, at: "2022-03-24T23:59:59Z", active: false},
+		"start of range":         {window: quarterEnd, at: "2022-03-25T00:00:00Z", active: true},
+		"end of range":           {window: quarterEnd, at: "2022-04-01T00:00:00Z", active: false},
+		"friday afternoon":       {window: weekend, at: "2022-04-01T17:59:00Z", active: false},
+		"friday evening":         {window: weekend, at: "2022-04-01T18:00:00Z", active: true},
+		"sunday":                 {window: weekend, at: "2022-04-03T12:00:00Z", active: true},
+		"monday morning":         {window: weekend, at: "2022-04-04T05:59:00Z", active: true},
+		"monday after window":    {window: weekend, at: "2022-04-04T06:00:00Z", active: false},
+		"wednesday":              {window: weekend, at: "2022-04-06T12:00:00Z", active: false},
+		"schedule in other zone": {window: weekend, at: "2022-04-01T20:00:00+02:00", active: true},
+		"christmas":              {window: holidays, at: "2022-12-25T10:00:00Z", active: true},
+		"new year":               {window: holidays, at: "2023-01-01T10:00:00Z", active: false},
+		"odd friday":             {window: oddFridays, at: "2022-04-01T10:00:00Z", active: true},
+		"odd day":                {window: oddFridays, at: "2022-04-05T10:00:00Z", active: false},
+		"even friday":            {window: oddFridays, at: "2022-04-08T10:00:00Z", active: false},
+		"even day":               {window: oddFridays, at: "2022-04-06T10:00:00Z", active: false},
+		"friday every day":       {window: everyDayAndFridays, at: "2022-04-08T10:00:00Z", active: true},
+		"other day every day":    {window: everyDayAndFridays, at: "2022-04-05T10:00:00Z", active: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.active, tt.window.Active(at(tt.at)))
+		})
+	}
+}
+
+func TestFreezeWindow_Freezes(t *testing.T) {
+	assert.True(t, FreezeWindow{}.Freezes("foo"))
+	assert.True(t, FreezeWindow{Workloads: []string{"bar", "foo"}}.Freezes("foo"))
+	assert.False(t, FreezeWindow{Workloads: []string{"bar"}}.Freezes("foo"))
+}
+
+func TestConfig_ActiveFreezes(t *testing.T) {
+	config, err := ParseConfig(strings.NewReader(`version: "v0.1"
+configType: Environment
+metadata:
+  name: development
+---
+version: "v0.1"
+configType: Environment
+metadata:
+  name: production
+spec:
+  freezes:
+  - name: quarter-end
+    reason: End of quarter reporting
+    start: 2022-03-25T00:00:00Z
+    end: 2022-04-01T00:00:00Z
+  - name: weekend
+    schedule: "0 18 * * 5"
+    duration: 60h
+    workloads:
+    - foo
+`))
+	require.NoError(t, err)
+
+	at := time.Date(2022, 3, 25, 19, 0, 0, 0, time.UTC)
+	active := config.ActiveFreezes(environment.Production, at)
+	require.Len(t, active, 2)
+	assert.Equal(t, "quarter-end (End of quarter reporting)", active[0].String())
+	assert.Equal(t, []string{"foo"}, active[1].Workloads)
+
+	assert.Empty(t, config.ActiveFreezes(environment.Development, at))
+	assert.Empty(t, config.ActiveFreezes(environment.Production, at.AddDate(0, 0, 12)))
+}
+
+func TestConfig_InvalidFreezes(t *testing.T) {
+	tests := map[string]struct {
+		freeze string
+		err    string
+	}{
+		"missing name": {
+			freeze: "schedule: \"0 0 * * *\"\n    duration: 1h",
+			err:    "document 1: line 1: environment production: freeze name is required",
+		},
+		"no period": {
+			freeze: "name: f",
+			err:    "document 1: line 1: environment production: freeze f: either start and end or schedule and duration are required",
+		},
+		"range and schedule": {
+			freeze: "name: f\n    start: 2022-03-25T00:00:00Z\n    end: 2022-04-01T00:00:00Z\n    schedule: \"0 0 * * *\"",
+			err:    "document 1: line 1: environment production: freeze f: start and end can't be combined with schedule and duration",
+		},
+		"end before start": {
+			freeze: "name: f\n    start: 2022-04-01T00:00:00Z\n    end: 2022-03-25T00:00:00Z",
+			err:    "document 1: line 1: environment production: freeze f: start must be before end",
+		},
+		"missing duration": {
+			freeze: "name: f\n    schedule: \"0 0 * * *\"",
+			err:    "document 1: line 1: environment production: freeze f: duration must be positive and at most 744h0m0s",
+		},
+		"invalid schedule": {
+			freeze: "name: f\n    schedule: \"0 25 * * *\"\n    duration: 1h",
+			err:    "document 1: line 1: environment production: freeze f: schedule '0 25 * * *': hour '25' out of range 0-23",
+		},
+		"schedule with too few fields": {
+			freeze: "name: f\n    schedule: \"0 0 *\"\n    duration: 1h",
+			err:    "document 1: line 1: environment production: freeze f: schedule '0 0 *' must have 5 fields",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfig(strings.NewReader(`version: "v0.1"
+configType: Environment
+metadata:
+  name: production
+spec:
+  freezes:
+  - ` + tt.freeze + "\n"))
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestFreezeWindow_EndsAt(t *testing.T) {
+	weekend := FreezeWindow{Name: "weekend", Schedule: "0 18 * * 5", Duration: 60 * time.Hour}
+
+	end, ok := weekend.EndsAt(time.Date(2022, 4, 2, 10, 30, 0, 0, time.UTC))
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2022, 4, 4, 6, 0, 0, 0, time.UTC), end)
+
+	_, ok = weekend.EndsAt(time.Date(2022, 4, 5, 10, 30, 0, 0, time.UTC))
+	assert.False(t, ok)
+}
@@ -0,0 +1,168 @@
+package clusterconf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ClusterGroup declares clusters sharing labels and a folder layout. The folders are templates
+// expanded for each member cluster with its labels and its name, e.g.
+// /promoted/{{.environment}}/{{.name}}/{{.cloud}}. Members inherit the labels and folders of
+// the group and can override them.
+type ClusterGroup struct {
+	Version    string               `yaml:"version"`
+	ConfigType string               `yaml:"configType"`
+	Metadata   ClusterGroupMetadata `yaml:"metadata"`
+	Spec       ClusterGroupSpec     `yaml:"spec"`
+}
+
+type ClusterGroupMetadata struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Labels      Labels `yaml:"labels"`
+}
+
+type ClusterGroupSpec struct {
+	ManifestFolder string               `yaml:"manifestFolder"`
+	ConfigFolder   string               `yaml:"configFolder"`
+	Clusters       []ClusterGroupMember `yaml:"clusters"`
+}
+
+// ClusterGroupMember is a cluster of a group. Its labels are merged into the labels of the group,
+// its folders replace the folders of the group.
+type ClusterGroupMember struct {
+	Name           string `yaml:"name"`
+	Description    string `yaml:"description,omitempty"`
+	Labels         Labels `yaml:"labels,omitempty"`
+	ManifestFolder string `yaml:"manifestFolder,omitempty"`
+	ConfigFolder   string `yaml:"configFolder,omitempty"`
+	Paused         *Pause `yaml:"paused,omitempty"`
+}
+
+func (g ClusterGroup) validate() error {
+	if g.Metadata.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(g.Spec.Clusters) == 0 {
+		return fmt.Errorf("cluster group %s: clusters are required", g.Metadata.Name)
+	}
+	return nil
+}
+
+// expand returns the member of the group as a plain cluster.
+func (g ClusterGroup) expand(member ClusterGroupMember) (Cluster, error) {
+	labels := Labels{}
+	for k, v := range g.Metadata.Labels {
+		labels[k] = v
+	}
+	for k, v := range member.Labels {
+		labels[k] = v
+	}
+
+	manifestFolder, configFolder := g.Spec.ManifestFolder, g.Spec.ConfigFolder
+	if member.ManifestFolder != "" {
+		manifestFolder = member.ManifestFolder
+	}
+	if member.ConfigFolder != "" {
+		configFolder = member.ConfigFolder
+	}
+
+	var err error
+	if manifestFolder, err = expandFolder(manifestFolder, member.Name, labels); err != nil {
+		return Cluster{}, fmt.Errorf("cluster %s: manifestFolder: %w", member.Name, err)
+	}
+	if configFolder, err = expandFolder(configFolder, member.Name, labels); err != nil {
+		return Cluster{}, fmt.Errorf("cluster %s: configFolder: %w", member.Name, err)
+	}
+
+	return Cluster{
+		Version:    CurrentVersion,
+		ConfigType: ConfigTypeCluster,
+		Metadata: ClusterMetadata{
+			Name:        member.Name,
+			Description: member.Description,
+			Labels:      labels,
+		},
+		Spec: ClusterSpec{
+			ManifestFolder: manifestFolder,
+			ConfigFolder:   configFolder,
+			Paused:         member.Paused,
+		},
+	}, nil
+}
+
+// expandFolder executes the folder template with the labels of the cluster and its name.
+func expandFolder(folder, name string, labels Labels) (string, error) {
+	if folder == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New("folder").Option("missingkey=error").Parse(folder)
+	if err != nil {
+		return "", err
+	}
+
+	data := map[string]string{}
+	for k, v := range labels {
+		data[k] = v
+	}
+	data["name"] = name
+
+	buf := bytes.NewBuffer(nil)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// decodeClusterGroup decodes a ClusterGroup document into the clusters it declares. Each cluster
+// is located at its entry in the clusters of the group.
+func decodeClusterGroup(doc document) (Clusters, error) {
+	group := ClusterGroup{}
+	if err := doc.decode(&group); err != nil {
+		return nil, err
+	}
+
+	if err := group.validate(); err != nil {
+		return nil, doc.wrap(err)
+	}
+
+	members := memberNodes(doc)
+	clusters := Clusters{}
+	for i, member := range group.Spec.Clusters {
+		source := doc.location()
+		if i < len(members) {
+			source.Line = members[i].Line
+		}
+
+		cluster, err := group.expand(member)
+		if err != nil {
+			return nil, source.wrap(fmt.Errorf("cluster group %s: %w", group.Metadata.Name, err))
+		}
+		cluster.Source = source
+
+		if err := cluster.validate(); err != nil {
+			return nil, source.wrap(fmt.Errorf("cluster group %s: %w", group.Metadata.Name, err))
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+// memberNodes returns the nodes of the member clusters of a ClusterGroup document.
+func memberNodes(doc document) []*yaml.Node {
+	spec := mappingValue(doc.mapping(), "spec")
+	if spec == nil || spec.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	clusters := mappingValue(spec, "clusters")
+	if clusters == nil || clusters.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return clusters.Content
+}
@@ -0,0 +1,177 @@
+package clusterconf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseClusters_ExpandsClusterGroups(t *testing.T) {
+	got, err := ParseClusters(strings.NewReader(`version: "v0.1"
+configType: ClusterGroup
+metadata:
+  name: development
+  labels:
+    environment: development
+    cloud: cloud1
+spec:
+  manifestFolder: /promoted/{{.environment}}/{{.name}}/{{.cloud}}
+  configFolder: /config/{{.environment}}/{{.name}}/{{.cloud}}
+  clusters:
+  - name: dev1
+  - name: dev2
+    labels:
+      cloud: cloud2
+  - name: dev3
+    description: Legacy layout
+    manifestFolder: /promoted/legacy/{{.name}}
+---
+version: "v0.1"
+configType: Cluster
+metadata:
+  name: test1
+  labels:
+    environment: test
+spec:
+  manifestFolder: /promoted/test/test1
+`))
+
+	require.NoError(t, err)
+	assert.Equal(t, Clusters{
+		{
+			Version:    "v0.1",
+			ConfigType: "Cluster",
+			Metadata: ClusterMetadata{
+				Name:   "dev1",
+				Labels: Labels{"environment": "development", "cloud": "cloud1"},
+			},
+			Spec: ClusterSpec{
+				ManifestFolder: "/promoted/development/dev1/cloud1",
+				ConfigFolder:   "/config/development/dev1/cloud1",
+			},
+			Source: Location{Document: 1, Line: 12},
+		},
+		{
+			Version:    "v0.1",
+			ConfigType: "Cluster",
+			Metadata: ClusterMetadata{
+				Name:   "dev2",
+				Labels: Labels{"environment": "development", "cloud": "cloud2"},
+			},
+			Spec: ClusterSpec{
+				ManifestFolder: "/promoted/development/dev2/cloud2",
+				ConfigFolder:   "/config/development/dev2/cloud2",
+			},
+			Source: Location{Document: 1, Line: 13},
+		},
+		{
+			Version:    "v0.1",
+			ConfigType: "Cluster",
+			Metadata: ClusterMetadata{
+				Name:        "dev3",
+				Description: "Legacy layout",
+				Labels:      Labels{"environment": "development", "cloud": "cloud1"},
+			},
+			Spec: ClusterSpec{
+				ManifestFolder: "/promoted/legacy/dev3",
+				ConfigFolder:   "/config/development/dev3/cloud1",
+			},
+			Source: Location{Document: 1, Line: 16},
+		},
+		{
+			Version:    "v0.1",
+			ConfigType: "Cluster",
+			Metadata: ClusterMetadata{
+				Name:   "test1",
+				Labels: Labels{"environment": "test"},
+			},
+			Spec: ClusterSpec{
+				ManifestFolder: "/promoted/test/test1",
+			},
+			Source: Location{Document: 2, Line: 20},
+		},
+	}, got)
+}
+
+func Test_parseConfigFile_ClusterGroupErrors(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		err    string
+	}{
+		"missing label in template": {
+			config: `version: "v0.1"
+configType: ClusterGroup
+metadata:
+  name: development
+  labels:
+    environment: development
+spec:
+  manifestFolder: /promoted/{{.environment}}/{{.name}}/{{.cloud}}
+  clusters:
+  - name: dev1
+    labels:
+      cloud: cloud1
+  - name: dev2
+`,
+			err: `clusters.yaml: document 1: line 13: cluster group development: cluster dev2: manifestFolder: template: folder:1:39: executing "folder" at <.cloud>: map has no entry for key "cloud"`,
+		},
+		"no clusters": {
+			config: `version: "v0.1"
+configType: ClusterGroup
+metadata:
+  name: development
+spec:
+  manifestFolder: /promoted/{{.name}}
+`,
+			err: "clusters.yaml: document 1: line 1: cluster group development: clusters are required",
+		},
+		"missing manifest folder": {
+			config: `version: "v0.1"
+configType: ClusterGroup
+metadata:
+  name: development
+  labels:
+    environment: development
+spec:
+  clusters:
+  - name: dev1
+`,
+			err: "clusters.yaml: document 1: line 9: cluster group development: manifestfolder is required",
+		},
+		"members are validated as a set with clusters": {
+			config: `version: "v0.1"
+configType: Cluster
+metadata:
+  name: dev1
+  labels:
+    environment: development
+spec:
+  manifestFolder: /promoted/development/dev1
+---
+version: "v0.1"
+configType: ClusterGroup
+metadata:
+  name: development
+  labels:
+    environment: development
+spec:
+  manifestFolder: /promoted/{{.environment}}/{{.name}}
+  clusters:
+  - name: dev1
+`,
+			err: "2 validation errors:\n" +
+				"clusters.yaml: document 2: line 19: cluster dev1: declared more than once, first at clusters.yaml: document 1: line 1\n" +
+				"clusters.yaml: document 2: line 19: cluster dev1: manifestFolder /promoted/development/dev1 is already used by cluster dev1",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfigFile("clusters.yaml", strings.NewReader(tt.config))
+			require.Error(t, err)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
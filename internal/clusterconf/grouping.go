@@ -0,0 +1,88 @@
+package clusterconf
+
+import (
+	"errors"
+	"fmt"
+)
+
+type GroupingStrategy string
+
+const (
+	// GroupByCluster raises one pull request per cluster.
+	GroupByCluster GroupingStrategy = "Cluster"
+	// GroupByEnvironment raises one pull request for all clusters of the environment.
+	GroupByEnvironment GroupingStrategy = "Environment"
+	// GroupByLabel raises one pull request per value of the label, e.g. per cloud.
+	GroupByLabel GroupingStrategy = "Label"
+)
+
+// GroupingPolicy decides how clusters of an environment are grouped into pull requests.
+// MaxSize, when set, splits groups so no pull request targets more than MaxSize clusters.
+type GroupingPolicy struct {
+	Strategy GroupingStrategy `yaml:"strategy,omitempty"`
+	Label    string           `yaml:"label,omitempty"`
+	MaxSize  int              `yaml:"maxSize,omitempty"`
+}
+
+func (g GroupingPolicy) validate() error {
+	switch g.Strategy {
+	case "", GroupByCluster, GroupByEnvironment:
+		if g.Label != "" {
+			return fmt.Errorf("grouping label is only allowed with strategy %s", GroupByLabel)
+		}
+	case GroupByLabel:
+		if g.Label == "" {
+			return errors.New("grouping label is required with strategy Label")
+		}
+	default:
+		return fmt.Errorf("unknown grouping strategy: %s", g.Strategy)
+	}
+
+	if g.MaxSize < 0 {
+		return errors.New("grouping maxSize must not be negative")
+	}
+	return nil
+}
+
+// Group splits the clusters into groups according to the policy, keeping the
+// order in which clusters are declared.
+func (c Clusters) Group(policy GroupingPolicy) []Clusters {
+	var groups []Clusters
+
+	switch policy.Strategy {
+	case GroupByEnvironment:
+		if len(c) > 0 {
+			groups = []Clusters{c}
+		}
+	case GroupByLabel:
+		index := map[string]int{}
+		for _, cluster := range c {
+			value := cluster.Metadata.Labels[policy.Label]
+			i, ok := index[value]
+			if !ok {
+				i = len(groups)
+				index[value] = i
+				groups = append(groups, Clusters{})
+			}
+			groups[i] = append(groups[i], cluster)
+		}
+	default:
+		for _, cluster := range c {
+			groups = append(groups, Clusters{cluster})
+		}
+	}
+
+	if policy.MaxSize == 0 {
+		return groups
+	}
+
+	var limited []Clusters
+	for _, group := range groups {
+		for len(group) > policy.MaxSize {
+			limited = append(limited, group[:policy.MaxSize])
+			group = group[policy.MaxSize:]
+		}
+		limited = append(limited, group)
+	}
+	return limited
+}
@@ -0,0 +1,91 @@
+package clusterconf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/form3tech/k8s-promoter/internal/environment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusters_Group(t *testing.T) {
+	c := func(name, cloud string) Cluster {
+		return Cluster{Metadata: ClusterMetadata{Name: name, Labels: Labels{"cloud": cloud}}}
+	}
+	clusters := Clusters{c("a", "cloud1"), c("b", "cloud2"), c("c", "cloud1"), c("d", "cloud1")}
+
+	tests := map[string]struct {
+		policy GroupingPolicy
+		want   []Clusters
+	}{
+		"by cluster": {
+			policy: GroupingPolicy{Strategy: GroupByCluster},
+			want:   []Clusters{{c("a", "cloud1")}, {c("b", "cloud2")}, {c("c", "cloud1")}, {c("d", "cloud1")}},
+		},
+		"by environment": {
+			policy: GroupingPolicy{Strategy: GroupByEnvironment},
+			want:   []Clusters{clusters},
+		},
+		"by label": {
+			policy: GroupingPolicy{Strategy: GroupByLabel, Label: "cloud"},
+			want:   []Clusters{{c("a", "cloud1"), c("c", "cloud1"), c("d", "cloud1")}, {c("b", "cloud2")}},
+		},
+		"by label with max size": {
+			policy: GroupingPolicy{Strategy: GroupByLabel, Label: "cloud", MaxSize: 2},
+			want:   []Clusters{{c("a", "cloud1"), c("c", "cloud1")}, {c("d", "cloud1")}, {c("b", "cloud2")}},
+		},
+		"by environment with max size": {
+			policy: GroupingPolicy{Strategy: GroupByEnvironment, MaxSize: 3},
+			want:   []Clusters{{c("a", "cloud1"), c("b", "cloud2"), c("c", "cloud1")}, {c("d", "cloud1")}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, clusters.Group(tt.policy))
+		})
+	}
+}
+
+func TestConfig_GroupingPolicy(t *testing.T) {
+	config, err := ParseConfig(strings.NewReader(`version: "v0.1"
+configType: Environment
+metadata:
+  name: development
+---
+version: "v0.1"
+configType: Environment
+metadata:
+  name: test
+spec:
+  grouping:
+    strategy: Label
+    label: cloud
+---
+version: "v0.1"
+configType: Environment
+metadata:
+  name: production
+spec:
+  grouping:
+    maxSize: 2
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, GroupingPolicy{Strategy: GroupByEnvironment}, config.GroupingPolicy(environment.Development))
+	assert.Equal(t, GroupingPolicy{Strategy: GroupByLabel, Label: "cloud"}, config.GroupingPolicy(environment.Test))
+	assert.Equal(t, GroupingPolicy{Strategy: GroupByCluster, MaxSize: 2}, config.GroupingPolicy(environment.Production))
+}
+
+func TestConfig_InvalidGroupingPolicy(t *testing.T) {
+	_, err := ParseConfig(strings.NewReader(`version: "v0.1"
+configType: Environment
+metadata:
+  name: test
+spec:
+  grouping:
+    strategy: Label
+`))
+	require.EqualError(t, err, "document 1: line 1: environment test: grouping label is required with strategy Label")
+}
@@ -4,8 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/form3tech/k8s-promoter/internal/environment"
+	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-git/v5"
 	"github.com/sirupsen/logrus"
 )
@@ -14,41 +17,82 @@ var ErrRepoNotInitialised = errors.New("repo reference is nil")
 
 type ClusterInspecter struct {
 	Repo   *git.Repository
+	Layout Layout
+
+	// Previous are the clusters of the configuration before the promotion, which tell the former manifest
+	// folder of the relocated clusters.
+	Previous Clusters
+
 	logger *logrus.Entry
 }
 
+// Relocation is a cluster whose manifest folder changed in the clusters configuration, while its
+// directories are still at the folders of From, the orphaned cluster at its previous manifest folder.
+type Relocation struct {
+	Cluster Cluster
+	From    Cluster
+}
+
 type ClusterDetection struct {
 	All         Clusters
 	New         Clusters
 	Existing    Clusters
 	PreviousEnv Clusters
+
+	// Orphaned are the cluster directories of the target environment which don't belong to any
+	// configured cluster anymore. They are named after their path within the environment directory.
+	Orphaned Clusters
+
+	// Relocated are the clusters of the target environment whose manifest folder changed. They are
+	// neither new nor orphaned.
+	Relocated []Relocation
+
+	// SourceEnv is the environment the target environment is promoted from.
+	SourceEnv environment.Env
+
+	// Layout is the layout of the repository the clusters were detected in.
+	Layout Layout
 }
 
-func NewClusterInspecter(repo *git.Repository, log *logrus.Entry) (*ClusterInspecter, error) {
+func NewClusterInspecter(repo *git.Repository, layout Layout, log *logrus.Entry) (*ClusterInspecter, error) {
 	if repo == nil {
 		return nil, ErrRepoNotInitialised
 	}
 
-	return &ClusterInspecter{Repo: repo, logger: log.WithField("module", "ClusterInspector")}, nil
+	return &ClusterInspecter{Repo: repo, Layout: layout, logger: log.WithField("module", "ClusterInspector")}, nil
 }
 
 // Detect analyses cluster config and local repository directory structure to work out newly added clusters,
-// existing clusters and clusters belonging to the previous environment.
-func (c *ClusterInspecter) Detect(all Clusters, targetEnv environment.Env) (ClusterDetection, error) {
+// existing clusters, relocated clusters, orphaned clusters and clusters belonging to the previous environment
+// of the pipeline.
+func (c *ClusterInspecter) Detect(all Clusters, pipeline environment.Pipeline, targetEnv environment.Env) (ClusterDetection, error) {
+	// An unknown target environment is reported when promoting, so we don't fail here.
+	source, _ := pipeline.ManifestSource(targetEnv)
+
 	inEnvironment := all.Filter(ByEnvironment(targetEnv))
 	new, err := c.newClusters(inEnvironment)
 	if err != nil {
 		return ClusterDetection{}, err
 	}
 
+	orphaned, err := c.orphanedClusters(all, targetEnv)
+	if err != nil {
+		return ClusterDetection{}, err
+	}
+
 	existing := c.existingClusters(inEnvironment, new)
-	previous := c.fromPreviousEnv(all, targetEnv)
+	previous := c.fromPreviousEnv(all, source)
+	relocated, new, orphaned := relocatedClusters(new, orphaned, c.Previous)
 
 	return ClusterDetection{
 		All:         all,
 		New:         new,
 		Existing:    existing,
 		PreviousEnv: previous,
+		Orphaned:    orphaned,
+		Relocated:   relocated,
+		SourceEnv:   source,
+		Layout:      c.Layout,
 	}, nil
 }
 
@@ -78,10 +122,153 @@ func (c *ClusterInspecter) existingClusters(all Clusters, new Clusters) []Cluste
 	return all.Filter(Without(new))
 }
 
-func (c *ClusterInspecter) fromPreviousEnv(all Clusters, targetEnv environment.Env) []Cluster {
-	source, err := targetEnv.ManifestSource()
-	if err != nil {
+func (c *ClusterInspecter) fromPreviousEnv(all Clusters, source environment.Env) []Cluster {
+	if source == "" || source == environment.SourceManifest {
 		return []Cluster{}
 	}
 	return all.Filter(ByEnvironment(source))
 }
+
+// orphanedClusters walks the directory of the environment for cluster directories, i.e. directories with a
+// kustomization.yaml, which are neither the manifest folder of a configured cluster nor contain one.
+// The config folder of an orphaned cluster is found by following the layout of the configured clusters.
+func (c *ClusterInspecter) orphanedClusters(all Clusters, env environment.Env) (Clusters, error) {
+	wt, err := c.Repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("worktree: %w", err)
+	}
+
+	root := c.Layout.EnvironmentDir(env)
+	if _, err := wt.Filesystem.Stat(root); err != nil {
+		if err == os.ErrNotExist {
+			return Clusters{}, nil
+		}
+		return nil, err
+	}
+
+	configured, configuredConfig := map[string]bool{}, map[string]bool{}
+	for _, cluster := range all {
+		configured[cleanFolder(cluster.ManifestFolder())] = true
+		if cluster.ConfigFolder() != "" {
+			configuredConfig[cleanFolder(cluster.ConfigFolder())] = true
+		}
+	}
+
+	var dirs []string
+	if err := findOrphanedDirs(wt.Filesystem, root, configured, &dirs); err != nil {
+		return nil, err
+	}
+
+	configRoots := configRoots(all.Filter(ByEnvironment(env)), root)
+
+	orphaned := Clusters{}
+	for _, dir := range dirs {
+		name := strings.TrimPrefix(dir, root+"/")
+		orphan := Cluster{
+			Metadata: ClusterMetadata{Name: name, Labels: Labels{"environment": string(env)}},
+			Spec:     ClusterSpec{ManifestFolder: dir},
+		}
+
+		for _, configRoot := range configRoots {
+			configFolder := filepath.Join(configRoot, name)
+			if _, err := wt.Filesystem.Stat(configFolder); err == nil && !configuredConfig[configFolder] {
+				orphan.Spec.ConfigFolder = configFolder
+				break
+			}
+		}
+		orphaned = append(orphaned, orphan)
+	}
+	return orphaned, nil
+}
+
+func findOrphanedDirs(fs billy.Filesystem, dir string, configured map[string]bool, orphaned *[]string) error {
+	if configured[dir] {
+		return nil
+	}
+
+	containsConfigured := false
+	for folder := range configured {
+		if isNested(folder, dir) {
+			containsConfigured = true
+			break
+		}
+	}
+
+	if !containsConfigured {
+		if _, err := fs.Stat(filepath.Join(dir, "kustomization.yaml")); err == nil {
+			*orphaned = append(*orphaned, dir)
+			return nil
+		}
+	}
+
+	entries, err := fs.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read directory %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if err := findOrphanedDirs(fs, filepath.Join(dir, entry.Name()), configured, orphaned); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// configRoots returns the directories which hold the config folders of the clusters, when their config
+// folder has the same path relative to it as their manifest folder relative to root.
+func configRoots(clusters Clusters, root string) []string {
+	seen := map[string]bool{}
+	var roots []string
+	for _, cluster := range clusters {
+		if cluster.ConfigFolder() == "" {
+			continue
+		}
+
+		name := strings.TrimPrefix(cleanFolder(cluster.ManifestFolder()), root+"/")
+		configFolder := cleanFolder(cluster.ConfigFolder())
+		if !strings.HasSuffix(configFolder, "/"+name) {
+			continue
+		}
+
+		configRoot := strings.TrimSuffix(configFolder, "/"+name)
+		if !seen[configRoot] {
+			seen[configRoot] = true
+			roots = append(roots, configRoot)
+		}
+	}
+	return roots
+}
+
+// relocatedClusters matches the new clusters with the orphaned clusters at the manifest folder the cluster of
+// the same name had in the previous configuration, i.e. the clusters whose manifest folder changed.
+func relocatedClusters(new, orphaned, previous Clusters) ([]Relocation, Clusters, Clusters) {
+	previousFolders := map[string]string{}
+	for _, cluster := range previous {
+		previousFolders[cluster.Name()] = cleanFolder(cluster.ManifestFolder())
+	}
+
+	orphanedByFolder := map[string]Cluster{}
+	for _, orphan := range orphaned {
+		orphanedByFolder[cleanFolder(orphan.ManifestFolder())] = orphan
+	}
+
+	var relocated []Relocation
+	var movedTo, movedFrom Clusters
+	for _, cluster := range new {
+		from, ok := orphanedByFolder[previousFolders[cluster.Name()]]
+		if !ok {
+			continue
+		}
+		delete(orphanedByFolder, previousFolders[cluster.Name()])
+
+		relocated = append(relocated, Relocation{Cluster: cluster, From: from})
+		movedTo = append(movedTo, cluster)
+		movedFrom = append(movedFrom, from)
+	}
+
+	if len(relocated) == 0 {
+		return nil, new, orphaned
+	}
+	return relocated, new.Filter(Without(movedTo)), orphaned.Filter(Without(movedFrom))
+}
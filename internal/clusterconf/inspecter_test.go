@@ -34,7 +34,7 @@ func TestCluster_New(t *testing.T) {
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
 			l := logrus.NewEntry(logrus.New())
-			got, err := clusterconf.NewClusterInspecter(tt.gitRepo, l)
+			got, err := clusterconf.NewClusterInspecter(tt.gitRepo, clusterconf.DefaultLayout(), l)
 			if tt.expectedErr != nil {
 				require.ErrorIs(t, err, tt.expectedErr)
 				require.Empty(t, got)
@@ -53,6 +53,9 @@ func TestCluster_Detect(t *testing.T) {
 		allClusters         []clusterconf.Cluster
 		newClusters         []clusterconf.Cluster
 		previousEnvClusters []clusterconf.Cluster
+		orphanedClusters    []clusterconf.Cluster
+		relocatedClusters   []clusterconf.Relocation
+		previousClusters    []clusterconf.Cluster
 		env                 environment.Env
 	}{
 		"no drift: empty upstream clusters + empty repo": {
@@ -158,16 +161,200 @@ func TestCluster_Detect(t *testing.T) {
 			previousEnvClusters: []clusterconf.Cluster{},
 			env:                 environment.Development,
 		},
+		"orphans: repo clusters missing upstream": {
+			repo: testutils.RepoWith(t,
+				testutils.AddContent(
+					[]testutils.Content{
+						{
+							Path:    "flux/promoted/development/cluster-1/kustomization.yaml",
+							Content: "some content",
+						},
+						{
+							Path:    "flux/promoted/development/cluster-2/cloud1/kustomization.yaml",
+							Content: "some content",
+						},
+						{
+							Path:    "flux/promoted/development/cluster-2/cloud1/workload/kustomization.yaml",
+							Content: "some content",
+						},
+						{
+							Path:    "flux/config/development/cluster-1/kustomization.yaml",
+							Content: "some content",
+						},
+						{
+							Path:    "flux/config/development/cluster-2/cloud1/kustomization.yaml",
+							Content: "some content",
+						},
+						{
+							Path:    "flux/promoted/test/cluster-test-1/kustomization.yaml",
+							Content: "some content",
+						},
+					},
+					"first commit",
+				),
+			),
+			allClusters: []clusterconf.Cluster{
+				withConfigFolder(cluster("cluster-1", environment.Development), "/flux/config/development/cluster-1"),
+			},
+			newClusters:         []clusterconf.Cluster{},
+			previousEnvClusters: []clusterconf.Cluster{},
+			orphanedClusters: []clusterconf.Cluster{
+				{
+					Spec: clusterconf.ClusterSpec{
+						ManifestFolder: "/flux/promoted/development/cluster-2/cloud1",
+						ConfigFolder:   "/flux/config/development/cluster-2/cloud1",
+					},
+					Metadata: clusterconf.ClusterMetadata{
+						Name:   "cluster-2/cloud1",
+						Labels: clusterconf.Labels{"environment": "development"},
+					},
+				},
+			},
+			env: environment.Development,
+		},
+		"relocations: upstream clusters with a changed manifest folder": {
+			repo: testutils.RepoWith(t,
+				testutils.AddContent(
+					[]testutils.Content{
+						{
+							Path:    "flux/promoted/development/cluster-1/kustomization.yaml",
+							Content: "some content",
+						},
+						{
+							Path:    "flux/promoted/development/cluster-2/kustomization.yaml",
+							Content: "some content",
+						},
+						{
+							Path:    "flux/promoted/development/cluster-3/cloud1/kustomization.yaml",
+							Content: "some content",
+						},
+					},
+					"first commit",
+				),
+			),
+			allClusters: []clusterconf.Cluster{
+				cluster("cluster-1", environment.Development),
+				withManifestFolder(cluster("cluster-2", environment.Development), "flux/promoted/development/cloud1/cluster-2"),
+				withManifestFolder(cluster("cluster-3-cloud1", environment.Development), "flux/promoted/development/cloud1/cluster-3"),
+				cluster("cluster-4", environment.Development),
+			},
+			previousClusters: []clusterconf.Cluster{
+				cluster("cluster-1", environment.Development),
+				cluster("cluster-2", environment.Development),
+				withManifestFolder(cluster("cluster-3-cloud1", environment.Development), "flux/promoted/development/cluster-3/cloud1"),
+			},
+			newClusters: []clusterconf.Cluster{
+				cluster("cluster-4", environment.Development),
+			},
+			previousEnvClusters: []clusterconf.Cluster{},
+			orphanedClusters:    []clusterconf.Cluster{},
+			relocatedClusters: []clusterconf.Relocation{
+				{
+					Cluster: withManifestFolder(cluster("cluster-2", environment.Development), "flux/promoted/development/cloud1/cluster-2"),
+					From: clusterconf.Cluster{
+						Spec: clusterconf.ClusterSpec{ManifestFolder: "/flux/promoted/development/cluster-2"},
+						Metadata: clusterconf.ClusterMetadata{
+							Name:   "cluster-2",
+							Labels: clusterconf.Labels{"environment": "development"},
+						},
+					},
+				},
+				{
+					Cluster: withManifestFolder(cluster("cluster-3-cloud1", environment.Development), "flux/promoted/development/cloud1/cluster-3"),
+					From: clusterconf.Cluster{
+						Spec: clusterconf.ClusterSpec{ManifestFolder: "/flux/promoted/development/cluster-3/cloud1"},
+						Metadata: clusterconf.ClusterMetadata{
+							Name:   "cluster-3/cloud1",
+							Labels: clusterconf.Labels{"environment": "development"},
+						},
+					},
+				},
+			},
+			env: environment.Development,
+		},
+		"relocations: clusters named after their environment folder": {
+			repo: testutils.RepoWith(t,
+				testutils.AddContent(
+					[]testutils.Content{
+						{
+							Path:    "flux/promoted/development/dev4/cloud1/kustomization.yaml",
+							Content: "some content",
+						},
+					},
+					"first commit",
+				),
+			),
+			allClusters: []clusterconf.Cluster{
+				withManifestFolder(cluster("dev4", environment.Development), "/flux/promoted/development/cloud1/dev4"),
+			},
+			previousClusters: []clusterconf.Cluster{
+				withManifestFolder(cluster("dev4", environment.Development), "/flux/promoted/development/dev4/cloud1"),
+			},
+			newClusters:         []clusterconf.Cluster{},
+			previousEnvClusters: []clusterconf.Cluster{},
+			orphanedClusters:    []clusterconf.Cluster{},
+			relocatedClusters: []clusterconf.Relocation{
+				{
+					Cluster: withManifestFolder(cluster("dev4", environment.Development), "/flux/promoted/development/cloud1/dev4"),
+					From: clusterconf.Cluster{
+						Spec: clusterconf.ClusterSpec{ManifestFolder: "/flux/promoted/development/dev4/cloud1"},
+						Metadata: clusterconf.ClusterMetadata{
+							Name:   "dev4/cloud1",
+							Labels: clusterconf.Labels{"environment": "development"},
+						},
+					},
+				},
+			},
+			env: environment.Development,
+		},
+		"relocations: orphaned clusters at no previous manifest folder are left as new and orphaned clusters": {
+			repo: testutils.RepoWith(t,
+				testutils.AddContent(
+					[]testutils.Content{
+						{
+							Path:    "flux/promoted/development/cluster-1/cloud1/kustomization.yaml",
+							Content: "some content",
+						},
+					},
+					"first commit",
+				),
+			),
+			allClusters: []clusterconf.Cluster{
+				withManifestFolder(cluster("cluster-1-cloud1", environment.Development), "flux/promoted/development/cloud1/cluster-1"),
+			},
+			previousClusters: []clusterconf.Cluster{
+				withManifestFolder(cluster("cluster-1-cloud1", environment.Development), "flux/promoted/development/cloud2/cluster-1"),
+			},
+			newClusters: []clusterconf.Cluster{
+				withManifestFolder(cluster("cluster-1-cloud1", environment.Development), "flux/promoted/development/cloud1/cluster-1"),
+			},
+			previousEnvClusters: []clusterconf.Cluster{},
+			orphanedClusters: []clusterconf.Cluster{
+				{
+					Spec: clusterconf.ClusterSpec{ManifestFolder: "/flux/promoted/development/cluster-1/cloud1"},
+					Metadata: clusterconf.ClusterMetadata{
+						Name:   "cluster-1/cloud1",
+						Labels: clusterconf.Labels{"environment": "development"},
+					},
+				},
+			},
+			env: environment.Development,
+		},
 	}
+
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
 			c := &clusterconf.ClusterInspecter{
-				Repo: tt.repo.Repo,
+				Repo:     tt.repo.Repo,
+				Layout:   clusterconf.DefaultLayout(),
+				Previous: tt.previousClusters,
 			}
-			got, err := c.Detect(tt.allClusters, tt.env)
+			got, err := c.Detect(tt.allClusters, environment.DefaultPipeline(), tt.env)
 			require.NoError(t, err)
 			require.ElementsMatch(t, tt.newClusters, got.New)
 			require.ElementsMatch(t, tt.previousEnvClusters, got.PreviousEnv)
+			require.ElementsMatch(t, tt.orphanedClusters, got.Orphaned)
+			require.ElementsMatch(t, tt.relocatedClusters, got.Relocated)
 		})
 	}
 }
@@ -183,3 +370,13 @@ func cluster(name string, env environment.Env) clusterconf.Cluster {
 		},
 	}
 }
+
+func withConfigFolder(cluster clusterconf.Cluster, configFolder string) clusterconf.Cluster {
+	cluster.Spec.ConfigFolder = configFolder
+	return cluster
+}
+
+func withManifestFolder(cluster clusterconf.Cluster, manifestFolder string) clusterconf.Cluster {
+	cluster.Spec.ManifestFolder = manifestFolder
+	return cluster
+}
@@ -0,0 +1,300 @@
+package clusterconf
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/form3tech/k8s-promoter/internal/environment"
+	"github.com/go-git/go-billy/v5"
+)
+
+const (
+	layoutEnvironment = "environment"
+	layoutWorkload    = "workload"
+)
+
+var layoutPlaceholder = regexp.MustCompile(`^{{\s*\.(\w+)\s*}}$`)
+
+// Layout declares where the workloads are in the manifest repository. Its paths are templates
+// whose placeholders are whole path segments, e.g.
+//
+//	source:   deploy/base/{{.workload}}
+//	promoted: deploy/clusters/{{.environment}}/{{.cluster}}/{{.workload}}
+//
+// The source path must end with {{.workload}}. The promoted path must contain {{.environment}},
+// followed by the directories of the clusters, and end with {{.workload}}. The placeholders of the
+// cluster directories, e.g. {{.cluster}} or {{.cloud}}, only document the layout: each matches
+// any directory.
+//
+// Workloads can be grouped in namespace directories, e.g. the workload payments/api is in
+// deploy/base/payments/api. A directory is a workload when it contains a workload.yaml, or when it is
+// workloadDepth directories deep, 1 by default, otherwise it is a namespace. Workload directories
+// are never nested.
+type Layout struct {
+	Version    string     `yaml:"version"`
+	ConfigType string     `yaml:"configType"`
+	Spec       LayoutSpec `yaml:"spec"`
+}
+
+type LayoutSpec struct {
+	Source        string `yaml:"source"`
+	Promoted      string `yaml:"promoted"`
+	WorkloadDepth int    `yaml:"workloadDepth,omitempty"`
+}
+
+// DefaultLayout is the layout of the repositories whose clusters configuration doesn't declare one.
+func DefaultLayout() Layout {
+	return Layout{
+		Version:    CurrentVersion,
+		ConfigType: ConfigTypeLayout,
+		Spec: LayoutSpec{
+			Source:   "flux/manifests/{{.workload}}",
+			Promoted: "flux/promoted/{{.environment}}/{{.cluster}}/{{.cloud}}/{{.workload}}",
+		},
+	}
+}
+
+// LayoutPath locates a workload directory: either the source manifests of the workload, whose
+// environment is environment.SourceManifest, or the copy of the workload promoted to a cluster.
+type LayoutPath struct {
+	Environment environment.Env
+	// Cluster is the directory of the cluster within the environment directory, e.g. dev1/cloud1.
+	Cluster string
+	// Workload is the ID of the workload, its directory within the source directory, e.g. payments/api.
+	Workload string
+}
+
+// HasWorkloadFileFn reports whether dir, a directory of the repository, contains a workload.yaml.
+type HasWorkloadFileFn func(dir string) bool
+
+// layoutSegment is a segment of a layout path, value is the name of the placeholder if it is one.
+type layoutSegment struct {
+	value       string
+	placeholder bool
+}
+
+func parseLayoutTemplate(template string) []layoutSegment {
+	var segments []layoutSegment
+	for _, s := range splitPath(template) {
+		if match := layoutPlaceholder.FindStringSubmatch(s); match != nil {
+			segments = append(segments, layoutSegment{value: match[1], placeholder: true})
+			continue
+		}
+		segments = append(segments, layoutSegment{value: s})
+	}
+	return segments
+}
+
+// staticPrefix returns the segments before the first placeholder.
+func staticPrefix(segments []layoutSegment) []string {
+	var prefix []string
+	for _, s := range segments {
+		if s.placeholder {
+			break
+		}
+		prefix = append(prefix, s.value)
+	}
+	return prefix
+}
+
+func (l Layout) source() []layoutSegment {
+	return parseLayoutTemplate(l.Spec.Source)
+}
+
+func (l Layout) promoted() []layoutSegment {
+	return parseLayoutTemplate(l.Spec.Promoted)
+}
+
+func (l Layout) validate() error {
+	for _, template := range []string{l.Spec.Source, l.Spec.Promoted} {
+		for _, s := range splitPath(template) {
+			if strings.Contains(s, "{{") && !layoutPlaceholder.MatchString(s) {
+				return fmt.Errorf("layout %q: placeholders must be whole path segments", template)
+			}
+		}
+	}
+
+	source := l.source()
+	if len(source) < 2 || len(staticPrefix(source)) != len(source)-1 || source[len(source)-1].value != layoutWorkload {
+		return fmt.Errorf("layout source %q: expected a directory followed by {{.%s}}", l.Spec.Source, layoutWorkload)
+	}
+
+	promoted := l.promoted()
+	prefix := staticPrefix(promoted)
+	if len(prefix) == 0 || len(promoted) < len(prefix)+3 || promoted[len(prefix)].value != layoutEnvironment {
+		return fmt.Errorf("layout promoted %q: expected a directory followed by {{.%s}}, the cluster directories and {{.%s}}",
+			l.Spec.Promoted, layoutEnvironment, layoutWorkload)
+	}
+	if last := promoted[len(promoted)-1]; !last.placeholder || last.value != layoutWorkload {
+		return fmt.Errorf("layout promoted %q: expected to end with {{.%s}}", l.Spec.Promoted, layoutWorkload)
+	}
+	for _, s := range promoted[len(prefix)+1 : len(promoted)-1] {
+		if s.placeholder && (s.value == layoutEnvironment || s.value == layoutWorkload) {
+			return fmt.Errorf("layout promoted %q: {{.%s}} must appear once", l.Spec.Promoted, s.value)
+		}
+	}
+
+	if l.Spec.WorkloadDepth < 0 {
+		return errors.New("layout workloadDepth must not be negative")
+	}
+
+	sourceDir, promotedDir := l.SourceDir(), filepath.Join("/", filepath.Join(prefix...))
+	if sourceDir == promotedDir || isNested(sourceDir, promotedDir) || isNested(promotedDir, sourceDir) {
+		return errors.New("layout source and promoted directories must not be nested")
+	}
+	return nil
+}
+
+// SourceDir returns the directory of the source manifests of the workloads.
+func (l Layout) SourceDir() string {
+	return filepath.Join("/", filepath.Join(staticPrefix(l.source())...))
+}
+
+// SourcePath returns the directory of the source manifests of the workload.
+func (l Layout) SourcePath(workload string) string {
+	return filepath.Join(l.SourceDir(), workload)
+}
+
+// EnvironmentDir returns the directory of the clusters of the environment.
+func (l Layout) EnvironmentDir(env environment.Env) string {
+	return filepath.Join("/", filepath.Join(staticPrefix(l.promoted())...), string(env))
+}
+
+// Path returns the workload directory located by p.
+func (l Layout) Path(p LayoutPath) string {
+	if p.Environment == environment.SourceManifest {
+		return l.SourcePath(p.Workload)
+	}
+	return filepath.Join(l.EnvironmentDir(p.Environment), p.Cluster, p.Workload)
+}
+
+// InSourceDir reports whether path is within the directory of the source manifests.
+func (l Layout) InSourceDir(path string) bool {
+	return hasSegmentsPrefix(splitPath(path), staticPrefix(l.source()))
+}
+
+// Parse locates the workload directory containing path, a path of the repository. It returns false
+// when path doesn't belong to a workload, e.g. the kustomization.yaml of a cluster directory.
+// hasWorkloadFile finds the workloads which aren't workloadDepth directories deep, it can be nil.
+func (l Layout) Parse(path string, hasWorkloadFile HasWorkloadFileFn) (LayoutPath, bool) {
+	segments := splitPath(path)
+
+	source := l.source()
+	if root := len(source) - 1; hasSegmentsPrefix(segments, staticPrefix(source)) {
+		workload, ok := l.workloadIn(segments[:root], segments[root:], hasWorkloadFile)
+		if !ok {
+			return LayoutPath{}, false
+		}
+		return LayoutPath{Environment: environment.SourceManifest, Workload: workload}, true
+	}
+
+	promoted := l.promoted()
+	root := len(promoted) - 1
+	if len(segments) <= root {
+		return LayoutPath{}, false
+	}
+	for i, s := range promoted[:root] {
+		if !s.placeholder && segments[i] != s.value {
+			return LayoutPath{}, false
+		}
+	}
+
+	workload, ok := l.workloadIn(segments[:root], segments[root:], hasWorkloadFile)
+	if !ok {
+		return LayoutPath{}, false
+	}
+
+	env := len(staticPrefix(promoted))
+	return LayoutPath{
+		Environment: environment.Env(segments[env]),
+		Cluster:     strings.Join(segments[env+1:root], "/"),
+		Workload:    workload,
+	}, true
+}
+
+// workloadIn returns the workload of the file whose path within the directory root is rest.
+func (l Layout) workloadIn(root, rest []string, hasWorkloadFile HasWorkloadFileFn) (string, bool) {
+	for depth := 1; depth < len(rest); depth++ {
+		workload := strings.Join(rest[:depth], "/")
+		if l.isWorkload(workload, func(workload string) bool {
+			return hasWorkloadFile != nil && hasWorkloadFile(strings.Join(append(root[:len(root):len(root)], workload), "/"))
+		}) {
+			return workload, true
+		}
+	}
+	return "", false
+}
+
+// isWorkload reports whether the directory of workload, relative to the source directory or to the
+// manifest folder of a cluster, is a workload rather than a namespace.
+func (l Layout) isWorkload(workload string, hasWorkloadFile HasWorkloadFileFn) bool {
+	depth := l.Spec.WorkloadDepth
+	if depth == 0 {
+		depth = 1
+	}
+	return len(splitPath(workload)) >= depth || hasWorkloadFile(workload)
+}
+
+// Workloads returns the IDs of the workloads in dir, the source directory or the manifest folder of a cluster.
+func (l Layout) Workloads(fs billy.Filesystem, dir string) ([]string, error) {
+	var workloads []string
+	if err := l.findWorkloads(fs, dir, "", &workloads); err != nil {
+		return nil, err
+	}
+	return workloads, nil
+}
+
+func (l Layout) findWorkloads(fs billy.Filesystem, dir, namespace string, workloads *[]string) error {
+	entries, err := fs.ReadDir(filepath.Join(dir, namespace))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		workload := filepath.Join(namespace, entry.Name())
+		if l.isWorkload(workload, func(workload string) bool {
+			_, err := fs.Stat(filepath.Join(dir, workload, WorkloadFile))
+			return err == nil
+		}) {
+			*workloads = append(*workloads, workload)
+			continue
+		}
+
+		if err := l.findWorkloads(fs, dir, workload, workloads); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasSegmentsPrefix(segments, prefix []string) bool {
+	if len(segments) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if segments[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeLayout decodes a Layout document.
+func decodeLayout(doc document) (Layout, error) {
+	layout := Layout{}
+	if err := doc.decode(&layout); err != nil {
+		return Layout{}, err
+	}
+
+	if err := layout.validate(); err != nil {
+		return Layout{}, doc.wrap(err)
+	}
+	return layout, nil
+}
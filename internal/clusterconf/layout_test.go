@@ -0,0 +1,259 @@
+package clusterconf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/form3tech/k8s-promoter/internal/environment"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func deployLayout() Layout {
+	return Layout{
+		Version:    "v0.1",
+		ConfigType: "Layout",
+		Spec: LayoutSpec{
+			Source:   "deploy/base/{{.workload}}",
+			Promoted: "deploy/clusters/{{.environment}}/{{.cluster}}/{{.workload}}",
+		},
+	}
+}
+
+func TestLayout_Parse(t *testing.T) {
+	tests := map[string]struct {
+		layout Layout
+		path   string
+		want   LayoutPath
+		ok     bool
+	}{
+		"default source manifest": {
+			layout: DefaultLayout(),
+			path:   "flux/manifests/foo/kustomization.yaml",
+			want:   LayoutPath{Environment: environment.SourceManifest, Workload: "foo"},
+			ok:     true,
+		},
+		"default promoted manifest": {
+			layout: DefaultLayout(),
+			path:   "/flux/promoted/development/dev1/cloud1/foo/deployment.yaml",
+			want:   LayoutPath{Environment: "development", Cluster: "dev1/cloud1", Workload: "foo"},
+			ok:     true,
+		},
+		"default cluster kustomization": {
+			layout: DefaultLayout(),
+			path:   "flux/promoted/development/dev1/cloud1/kustomization.yaml",
+		},
+		"default source file outside of workloads": {
+			layout: DefaultLayout(),
+			path:   "flux/manifests/README.md",
+		},
+		"deploy source manifest": {
+			layout: deployLayout(),
+			path:   "deploy/base/foo/nested/deployment.yaml",
+			want:   LayoutPath{Environment: environment.SourceManifest, Workload: "foo"},
+			ok:     true,
+		},
+		"deploy promoted manifest": {
+			layout: deployLayout(),
+			path:   "deploy/clusters/test/test1/foo/deployment.yaml",
+			want:   LayoutPath{Environment: "test", Cluster: "test1", Workload: "foo"},
+			ok:     true,
+		},
+		"deploy layout ignores the default directories": {
+			layout: deployLayout(),
+			path:   "flux/manifests/foo/kustomization.yaml",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := tt.layout.Parse(tt.path, nil)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLayout_Parse_Namespaces(t *testing.T) {
+	layout := DefaultLayout()
+	layout.Spec.WorkloadDepth = 2
+
+	workloadFiles := map[string]bool{
+		"flux/manifests/tool":                        true,
+		"flux/promoted/development/dev1/cloud1/tool": true,
+	}
+	hasWorkloadFile := func(dir string) bool {
+		return workloadFiles[dir]
+	}
+
+	tests := map[string]struct {
+		path string
+		want LayoutPath
+		ok   bool
+	}{
+		"source manifest of a namespaced workload": {
+			path: "flux/manifests/payments/api/deployment.yaml",
+			want: LayoutPath{Environment: environment.SourceManifest, Workload: "payments/api"},
+			ok:   true,
+		},
+		"source manifest of a workload with a workload.yaml": {
+			path: "flux/manifests/tool/deployment.yaml",
+			want: LayoutPath{Environment: environment.SourceManifest, Workload: "tool"},
+			ok:   true,
+		},
+		"promoted manifest of a namespaced workload": {
+			path: "flux/promoted/development/dev1/cloud1/payments/api/config/deployment.yaml",
+			want: LayoutPath{Environment: "development", Cluster: "dev1/cloud1", Workload: "payments/api"},
+			ok:   true,
+		},
+		"promoted manifest of a workload with a workload.yaml": {
+			path: "flux/promoted/development/dev1/cloud1/tool/deployment.yaml",
+			want: LayoutPath{Environment: "development", Cluster: "dev1/cloud1", Workload: "tool"},
+			ok:   true,
+		},
+		"file of a namespace": {
+			path: "flux/manifests/payments/README.md",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := layout.Parse(tt.path, hasWorkloadFile)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLayout_Workloads(t *testing.T) {
+	fs := memfs.New()
+	for _, file := range []string{
+		"/flux/manifests/payments/api/deployment.yaml",
+		"/flux/manifests/payments/ledger/nested/deployment.yaml",
+		"/flux/manifests/payments/README.md",
+		"/flux/manifests/tool/workload.yaml",
+		"/flux/manifests/tool/nested/deployment.yaml",
+	} {
+		f, err := fs.Create(file)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	layout := DefaultLayout()
+	got, err := layout.Workloads(fs, layout.SourceDir())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments", "tool"}, got)
+
+	layout.Spec.WorkloadDepth = 2
+	got, err = layout.Workloads(fs, layout.SourceDir())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments/api", "payments/ledger", "tool"}, got)
+}
+
+func TestLayout_Path(t *testing.T) {
+	layout := deployLayout()
+
+	assert.Equal(t, "/deploy/base", layout.SourceDir())
+	assert.Equal(t, "/deploy/base/foo", layout.SourcePath("foo"))
+	assert.Equal(t, "/deploy/clusters/test", layout.EnvironmentDir(environment.Test))
+	assert.Equal(t, "/deploy/base/foo", layout.Path(LayoutPath{Environment: environment.SourceManifest, Workload: "foo"}))
+	assert.Equal(t, "/deploy/clusters/test/test1/foo", layout.Path(LayoutPath{Environment: "test", Cluster: "test1", Workload: "foo"}))
+
+	for _, path := range []string{"deploy/base/foo/kustomization.yaml", "deploy/clusters/test/test1/foo/kustomization.yaml"} {
+		location, ok := layout.Parse(path, nil)
+		require.True(t, ok)
+		assert.True(t, strings.HasPrefix("/"+path, layout.Path(location)+"/"))
+	}
+}
+
+func TestLayout_Validate(t *testing.T) {
+	tests := map[string]struct {
+		spec LayoutSpec
+		err  string
+	}{
+		"default": {
+			spec: DefaultLayout().Spec,
+		},
+		"deploy": {
+			spec: deployLayout().Spec,
+		},
+		"source without workload": {
+			spec: LayoutSpec{Source: "deploy/base", Promoted: "deploy/clusters/{{.environment}}/{{.cluster}}/{{.workload}}"},
+			err:  `layout source "deploy/base": expected a directory followed by {{.workload}}`,
+		},
+		"promoted without cluster directory": {
+			spec: LayoutSpec{Source: "deploy/base/{{.workload}}", Promoted: "deploy/clusters/{{.environment}}/{{.workload}}"},
+			err:  `layout promoted "deploy/clusters/{{.environment}}/{{.workload}}": expected a directory followed by {{.environment}}, the cluster directories and {{.workload}}`,
+		},
+		"promoted not ending with workload": {
+			spec: LayoutSpec{Source: "deploy/base/{{.workload}}", Promoted: "deploy/clusters/{{.environment}}/{{.workload}}/{{.cluster}}"},
+			err:  `layout promoted "deploy/clusters/{{.environment}}/{{.workload}}/{{.cluster}}": expected to end with {{.workload}}`,
+		},
+		"placeholder within a segment": {
+			spec: LayoutSpec{Source: "deploy/base/{{.workload}}", Promoted: "deploy/clusters/{{.environment}}/cluster-{{.cluster}}/{{.workload}}"},
+			err:  `layout "deploy/clusters/{{.environment}}/cluster-{{.cluster}}/{{.workload}}": placeholders must be whole path segments`,
+		},
+		"nested directories": {
+			spec: LayoutSpec{Source: "deploy/{{.workload}}", Promoted: "deploy/clusters/{{.environment}}/{{.cluster}}/{{.workload}}"},
+			err:  "layout source and promoted directories must not be nested",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Layout{Spec: tt.spec}.validate()
+			if tt.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func Test_parseConfig_Layout(t *testing.T) {
+	config, err := ParseConfig(strings.NewReader(`version: "v0.1"
+configType: Layout
+spec:
+  source: deploy/base/{{.workload}}
+  promoted: deploy/clusters/{{.environment}}/{{.cluster}}/{{.workload}}
+---
+version: "v0.1"
+configType: Cluster
+metadata:
+  name: dev1
+  labels:
+    environment: development
+spec:
+  manifestFolder: /deploy/clusters/development/dev1
+`))
+	require.NoError(t, err)
+	assert.Equal(t, deployLayout(), config.Layout)
+
+	config, err = ParseConfig(strings.NewReader(`version: "v0.1"
+configType: Cluster
+metadata:
+  name: dev1
+  labels:
+    environment: development
+spec:
+  manifestFolder: /flux/promoted/development/dev1
+`))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLayout(), config.Layout)
+
+	_, err = ParseConfig(strings.NewReader(`version: "v0.1"
+configType: Layout
+spec:
+  source: deploy/base/{{.workload}}
+  promoted: deploy/clusters/{{.environment}}/{{.cluster}}/{{.workload}}
+---
+version: "v0.1"
+configType: Layout
+spec:
+  source: base/{{.workload}}
+  promoted: clusters/{{.environment}}/{{.cluster}}/{{.workload}}
+`))
+	require.EqualError(t, err, "document 2: line 7: the repository layout must be declared once")
+}
@@ -0,0 +1,41 @@
+package clusterconf
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pause stops promotions to a cluster, e.g. while it is under maintenance, without removing it from
+// the configuration. A pause without until lasts until it is removed.
+type Pause struct {
+	Reason string    `yaml:"reason,omitempty"`
+	Until  time.Time `yaml:"until,omitempty"`
+}
+
+// Active reports whether the pause is in force at t.
+func (p *Pause) Active(t time.Time) bool {
+	return p != nil && (p.Until.IsZero() || t.Before(p.Until))
+}
+
+func (p Pause) String() string {
+	s := "paused"
+	if !p.Until.IsZero() {
+		s += fmt.Sprintf(" until %s", p.Until.UTC().Format(time.RFC3339))
+	}
+	if p.Reason != "" {
+		s += fmt.Sprintf(": %s", p.Reason)
+	}
+	return s
+}
+
+// IsPaused reports whether promotions to the cluster are paused at t.
+func (c Cluster) IsPaused(t time.Time) bool {
+	return c.Spec.Paused.Active(t)
+}
+
+// NotPaused keeps the clusters which aren't paused at t.
+func NotPaused(t time.Time) FilterFn {
+	return func(c Cluster) bool {
+		return !c.IsPaused(t)
+	}
+}
@@ -0,0 +1,74 @@
+package clusterconf
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_IsPaused(t *testing.T) {
+	now := time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		paused *Pause
+		want   bool
+	}{
+		"not paused": {
+			paused: nil,
+			want:   false,
+		},
+		"paused without expiry": {
+			paused: &Pause{Reason: "maintenance"},
+			want:   true,
+		},
+		"paused until later": {
+			paused: &Pause{Until: now.Add(time.Hour)},
+			want:   true,
+		},
+		"expired pause": {
+			paused: &Pause{Until: now.Add(-time.Hour)},
+			want:   false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := Cluster{Spec: ClusterSpec{Paused: tt.paused}}
+			assert.Equal(t, tt.want, c.IsPaused(now))
+		})
+	}
+}
+
+func TestPause_String(t *testing.T) {
+	assert.Equal(t, "paused", Pause{}.String())
+	assert.Equal(t, "paused: rebuild", Pause{Reason: "rebuild"}.String())
+	assert.Equal(t, "paused until 2022-03-11T00:00:00Z: rebuild", Pause{
+		Reason: "rebuild",
+		Until:  time.Date(2022, 3, 11, 0, 0, 0, 0, time.UTC),
+	}.String())
+}
+
+func Test_parseClusters_Paused(t *testing.T) {
+	clusters, err := ParseClusters(strings.NewReader(`version: "v0.1"
+configType: Cluster
+metadata:
+  name: dev1
+  labels:
+    environment: development
+spec:
+  manifestFolder: /promoted/development/dev1
+  paused:
+    reason: rebuild
+    until: 2022-03-11T00:00:00Z
+`))
+
+	require.NoError(t, err)
+	require.Len(t, clusters, 1)
+	assert.Equal(t, &Pause{
+		Reason: "rebuild",
+		Until:  time.Date(2022, 3, 11, 0, 0, 0, 0, time.UTC),
+	}, clusters[0].Spec.Paused)
+}
@@ -1,15 +1,24 @@
 package clusterconf
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/go-git/go-billy/v5"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
 
+var ErrDependencyCycle = errors.New("workload dependency cycle")
+
+// WorkloadFile is the configuration file of a workload, in the directory of the workload.
+const WorkloadFile = "workload.yaml"
+
 type WorkloadRegistry interface {
 	Get(workloadID string) (Workload, error)
 	GetAll() ([]Workload, error)
@@ -17,23 +26,36 @@ type WorkloadRegistry interface {
 
 type FSWorkloadRegistry struct {
 	fs      billy.Filesystem
+	layout  Layout
 	rootDir string
 	logger  *logrus.Entry
+
+	// dependencies walks the dependencies of the workloads to find their cycles. It is never invalidated: the
+	// registry reads the source manifests, which don't change while the promoter runs, each promotion branch
+	// starting from the same commit.
+	dependencies *dependencyGraph
 }
 
-func NewWorkloadRegistry(fs billy.Filesystem, rootDir string, log *logrus.Entry) *FSWorkloadRegistry {
-	return &FSWorkloadRegistry{
+func NewWorkloadRegistry(fs billy.Filesystem, layout Layout, log *logrus.Entry) *FSWorkloadRegistry {
+	registry := &FSWorkloadRegistry{
 		fs:      fs,
-		rootDir: rootDir,
+		layout:  layout,
+		rootDir: strings.TrimPrefix(layout.SourceDir(), "/"),
 		logger:  log.WithField("module", "WorkloadRegistry"),
 	}
+	registry.dependencies = newDependencyGraph(registry.dependsOn)
+	return registry
 }
 
 // Get returns the Workload struct with workloadID set if the file cannot be found
 // This means the workload does not have any exclusion rules applied.
+// The dependencies of the workload must exist, and must not depend on it, transitively.
 func (r *FSWorkloadRegistry) Get(workloadID string) (Workload, error) {
 	workload, err := r.parseWorkload(workloadID)
 	if err == nil {
+		if err := r.validateDependencies(workloadID, workload); err != nil {
+			return workload, fmt.Errorf("error loading workload `%s`: %w", workloadID, err)
+		}
 		return workload, nil
 	}
 
@@ -46,19 +68,16 @@ func (r *FSWorkloadRegistry) Get(workloadID string) (Workload, error) {
 	return workload, fmt.Errorf("error loading workload `%s`: %w", workloadID, err)
 }
 
+// GetAll returns the workloads of the source directory, including the workloads of namespaces.
 func (r *FSWorkloadRegistry) GetAll() ([]Workload, error) {
-	fileInfos, err := r.fs.ReadDir(r.rootDir)
+	ids, err := r.layout.Workloads(r.fs, r.rootDir)
 	if err != nil {
 		return nil, err
 	}
 
 	var workloads []Workload
-	for _, fileInfo := range fileInfos {
-		if !fileInfo.IsDir() {
-			continue
-		}
-
-		workload, err := r.Get(fileInfo.Name())
+	for _, id := range ids {
+		workload, err := r.Get(id)
 		if err != nil {
 			return nil, err
 		}
@@ -69,19 +88,36 @@ func (r *FSWorkloadRegistry) GetAll() ([]Workload, error) {
 	return workloads, nil
 }
 
-func (r *FSWorkloadRegistry) parseWorkload(workloadID string) (Workload, error) {
-	workload := Workload{
-		Version:    "v0.1",
-		ConfigType: "Workload",
-		Metadata: WorkloadMetadata{
-			Name: workloadID,
-		},
+// validateDependencies checks that the dependencies of the workload exist and that the workload isn't on a
+// dependency cycle.
+func (r *FSWorkloadRegistry) validateDependencies(workloadID string, workload Workload) error {
+	for _, name := range workload.Spec.DependsOn {
+		if _, err := r.fs.Stat(filepath.Join(r.rootDir, name)); err != nil {
+			return fmt.Errorf("workload %s depends on unknown workload %s: %w", workload.Name(), name, err)
+		}
 	}
 
-	path := filepath.Join(r.rootDir, workloadID, "workload.yaml")
+	if len(workload.Spec.DependsOn) == 0 {
+		return nil
+	}
+	return r.dependencies.cycle(workloadID)
+}
+
+// dependsOn returns the dependencies of the workload. The workloads which can't be parsed have none, their
+// errors are reported when they are loaded.
+func (r *FSWorkloadRegistry) dependsOn(workloadID string) []string {
+	workload, err := r.parseWorkload(workloadID)
+	if err != nil {
+		return nil
+	}
+	return workload.Spec.DependsOn
+}
+
+func (r *FSWorkloadRegistry) parseWorkload(workloadID string) (Workload, error) {
+	path := filepath.Join(r.rootDir, workloadID, WorkloadFile)
 	f, err := r.fs.Open(path)
 	if err != nil {
-		return workload, err
+		return NewWorkload(workloadID), err
 	}
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -89,14 +125,62 @@ func (r *FSWorkloadRegistry) parseWorkload(workloadID string) (Workload, error)
 		}
 	}()
 
-	err = yaml.NewDecoder(f).Decode(&workload)
+	return ParseWorkload(workloadID, path, f)
+}
+
+// NewWorkload returns the configuration of a workload without workload.yaml.
+func NewWorkload(workloadID string) Workload {
+	return Workload{
+		Version:    "v0.1",
+		ConfigType: "Workload",
+		Metadata: WorkloadMetadata{
+			Name: path.Base(workloadID),
+		},
+		Namespace: namespace(workloadID),
+	}
+}
+
+// ParseWorkload parses the workload.yaml of the workload, read from file.
+func ParseWorkload(workloadID, file string, r io.Reader) (Workload, error) {
+	workload := NewWorkload(workloadID)
+
+	var node yaml.Node
+	err := yaml.NewDecoder(r).Decode(&node)
 	if err != nil {
 		return workload, fmt.Errorf("could not decode workload config file: %w", err)
 	}
 
-	if err := workload.Validate(); err != nil {
+	doc := document{file: file, index: 1, node: &node}
+	configType, err := doc.prepare(ConfigTypeWorkload)
+	if err != nil {
 		return workload, err
 	}
+	if configType != ConfigTypeWorkload {
+		return workload, doc.unknownConfigType(configType, ConfigTypeWorkload)
+	}
+
+	if err := doc.decode(&workload); err != nil {
+		return workload, err
+	}
+
+	if err := workload.Validate(); err != nil {
+		return workload, doc.wrap(err)
+	}
+
+	for i := range workload.Spec.Inclusions {
+		(*Exclusion)(&workload.Spec.Inclusions[i]).compile()
+	}
+	for i := range workload.Spec.Exclusions {
+		workload.Spec.Exclusions[i].compile()
+	}
 
 	return workload, nil
 }
+
+// namespace returns the namespace of the workload ID, e.g. payments for payments/api.
+func namespace(workloadID string) string {
+	if dir := path.Dir(workloadID); dir != "." {
+		return dir
+	}
+	return ""
+}
@@ -1,9 +1,13 @@
 package clusterconf
 
 import (
+	"fmt"
+	"path/filepath"
 	"testing"
 
+	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,6 +25,9 @@ func Test_FSRegistry_Get(t *testing.T) {
 					Name:        "workload",
 					Description: "A workload",
 				},
+				Spec: WorkloadSpec{
+					Path: "/flux/manifests/workload",
+				},
 			},
 		},
 		{
@@ -41,6 +48,7 @@ func Test_FSRegistry_Get(t *testing.T) {
 					Description: "A workload",
 				},
 				Spec: WorkloadSpec{
+					Path: "/flux/manifests/workload",
 					Exclusions: []Exclusion{
 						{
 							Key:      "cloud",
@@ -61,7 +69,7 @@ func Test_FSRegistry_Get(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(testName(tt.workload.Name()), func(t *testing.T) {
 			log := logrus.NewEntry(logrus.New())
-			registry := NewWorkloadRegistry(osfs.New("."), "testdata/workloads", log)
+			registry := NewWorkloadRegistry(osfs.New("."), workloadsLayout("testdata/workloads"), log)
 
 			workload, err := registry.Get(tt.workload.Name())
 			require.NoError(t, err)
@@ -72,7 +80,7 @@ func Test_FSRegistry_Get(t *testing.T) {
 
 func Test_FSRegistry_GetAll(t *testing.T) {
 	log := logrus.NewEntry(logrus.New())
-	registry := NewWorkloadRegistry(osfs.New("."), "testdata/workloads", log)
+	registry := NewWorkloadRegistry(osfs.New("."), workloadsLayout("testdata/workloads"), log)
 
 	got, err := registry.GetAll()
 	require.NoError(t, err)
@@ -89,6 +97,37 @@ func Test_FSRegistry_GetAll(t *testing.T) {
 	assert.ElementsMatch(t, want, got)
 }
 
+func Test_FSRegistry_Namespaces(t *testing.T) {
+	log := logrus.NewEntry(logrus.New())
+	layout := workloadsLayout("testdata/workloads-namespaced")
+	layout.Spec.WorkloadDepth = 2
+	registry := NewWorkloadRegistry(osfs.New("."), layout, log)
+
+	got, err := registry.GetAll()
+	require.NoError(t, err)
+
+	var names []string
+	for _, workload := range got {
+		names = append(names, workload.Name())
+	}
+	assert.Equal(t, []string{"payments/api", "payments/ledger", "tool"}, names)
+
+	api, err := registry.Get("payments/api")
+	require.NoError(t, err)
+	assert.Equal(t, Workload{
+		Version:    "v0.1",
+		ConfigType: "Workload",
+		Metadata: WorkloadMetadata{
+			Name:        "api",
+			Description: "The payments API",
+		},
+		Spec: WorkloadSpec{
+			DependsOn: []string{"payments/ledger"},
+		},
+		Namespace: "payments",
+	}, api)
+}
+
 func Test_FSRegistry_ErrorCases(t *testing.T) {
 	tests := []struct {
 		name string
@@ -103,14 +142,46 @@ func Test_FSRegistry_ErrorCases(t *testing.T) {
 		{
 			"workload-with-invalid-exclusion",
 			"testdata/workloads-error-cases/workload-with-invalid-exclusion/workload.yaml",
-			"error loading workload `workload-with-invalid-exclusion`: unknown operator: foo",
+			"error loading workload `workload-with-invalid-exclusion`: " +
+				"testdata/workloads-error-cases/workload-with-invalid-exclusion/workload.yaml: document 1: line 1: unknown operator: foo",
+		},
+		{
+			"workload-with-unknown-field",
+			"testdata/workloads-error-cases/workload-with-unknown-field/workload.yaml",
+			"error loading workload `workload-with-unknown-field`: " +
+				"testdata/workloads-error-cases/workload-with-unknown-field/workload.yaml: document 1: line 7: field exclusion not found in type WorkloadSpec",
+		},
+		{
+			"workload-with-unsupported-version",
+			"testdata/workloads-error-cases/workload-with-unsupported-version/workload.yaml",
+			"error loading workload `workload-with-unsupported-version`: " +
+				"testdata/workloads-error-cases/workload-with-unsupported-version/workload.yaml: document 1: line 1: unsupported version 'v9', expected v0.1",
+		},
+		{
+			"workload-with-wrong-config-type",
+			"testdata/workloads-error-cases/workload-with-wrong-config-type/workload.yaml",
+			"error loading workload `workload-with-wrong-config-type`: " +
+				"testdata/workloads-error-cases/workload-with-wrong-config-type/workload.yaml: document 1: line 2: unknown configType 'Cluster', expected one of Workload",
+		},
+		{
+			"workload-with-dependency-cycle",
+			"testdata/workloads-error-cases/workload-with-dependency-cycle/workload.yaml",
+			"error loading workload `workload-with-dependency-cycle`: workload dependency cycle: " +
+				"workload-with-dependency-cycle -> workload-depending-on-cycle -> workload-with-dependency-cycle",
+		},
+		{
+			"workload-with-unknown-dependency",
+			"testdata/workloads-error-cases/workload-with-unknown-dependency/workload.yaml",
+			"error loading workload `workload-with-unknown-dependency`: " +
+				"workload workload-with-unknown-dependency depends on unknown workload missing-workload: " +
+				"stat testdata/workloads-error-cases/missing-workload: no such file or directory",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(testName(tt.file), func(t *testing.T) {
 			log := logrus.NewEntry(logrus.New())
-			registry := NewWorkloadRegistry(osfs.New("."), "testdata/workloads-error-cases", log)
+			registry := NewWorkloadRegistry(osfs.New("."), workloadsLayout("testdata/workloads-error-cases"), log)
 
 			got, err := registry.Get(tt.name)
 			assert.EqualError(t, err, tt.err)
@@ -119,3 +190,64 @@ func Test_FSRegistry_ErrorCases(t *testing.T) {
 		})
 	}
 }
+
+func Test_FSRegistry_DependencyCycles(t *testing.T) {
+	fs := memfs.New()
+	for name, dependsOn := range map[string]string{"api": "ledger", "ledger": "api", "ui": "api", "tool": ""} {
+		config := fmt.Sprintf("version: v0.1\nconfigType: Workload\nmetadata:\n  name: %s\n", name)
+		if dependsOn != "" {
+			config += fmt.Sprintf("spec:\n  dependsOn: [%s]\n", dependsOn)
+		}
+		require.NoError(t, util.WriteFile(fs, filepath.Join("workloads", name, WorkloadFile), []byte(config), 0o644))
+	}
+
+	log := logrus.NewEntry(logrus.New())
+	registry := NewWorkloadRegistry(fs, workloadsLayout("workloads"), log)
+
+	_, err := registry.Get("api")
+	assert.EqualError(t, err, "error loading workload `api`: workload dependency cycle: api -> ledger -> api")
+	_, err = registry.Get("ledger")
+	assert.EqualError(t, err, "error loading workload `ledger`: workload dependency cycle: ledger -> api -> ledger")
+
+	// the workloads depending on a cycle fail when their dependencies are loaded
+	_, err = registry.Get("ui")
+	assert.NoError(t, err)
+	_, err = registry.Get("tool")
+	assert.NoError(t, err)
+
+	_, err = registry.GetAll()
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+}
+
+func Test_FSRegistry_DependencyCyclesOnlyFailTheirWorkloads(t *testing.T) {
+	fs := memfs.New()
+	for name, dependsOn := range map[string]string{"a": "b, c", "b": "a", "c": "b", "ui": "a", "tool": "ui"} {
+		config := fmt.Sprintf("version: v0.1\nconfigType: Workload\nmetadata:\n  name: %s\nspec:\n  dependsOn: [%s]\n", name, dependsOn)
+		require.NoError(t, util.WriteFile(fs, filepath.Join("workloads", name, WorkloadFile), []byte(config), 0o644))
+	}
+	require.NoError(t, util.WriteFile(fs, filepath.Join("workloads", "broken", WorkloadFile), []byte("version: [v0.1"), 0o644))
+
+	log := logrus.NewEntry(logrus.New())
+	registry := NewWorkloadRegistry(fs, workloadsLayout("workloads"), log)
+
+	_, err := registry.Get("tool")
+	assert.NoError(t, err)
+	_, err = registry.Get("ui")
+	assert.NoError(t, err)
+
+	// c is on the cycle a -> c -> b -> a, which isn't walked from a first
+	_, err = registry.Get("a")
+	assert.EqualError(t, err, "error loading workload `a`: workload dependency cycle: a -> b -> a")
+	_, err = registry.Get("c")
+	assert.EqualError(t, err, "error loading workload `c`: workload dependency cycle: c -> b -> a -> c")
+	_, err = registry.Get("b")
+	assert.EqualError(t, err, "error loading workload `b`: workload dependency cycle: b -> a -> b")
+
+	_, err = registry.Get("broken")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrDependencyCycle)
+}
+
+func workloadsLayout(dir string) Layout {
+	return Layout{Spec: LayoutSpec{Source: dir + "/{{.workload}}"}}
+}
@@ -0,0 +1,130 @@
+package clusterconf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schedule is a parsed cron expression made of the five standard fields:
+// minute, hour, day of month, month and day of week.
+type schedule struct {
+	minutes, hours, daysOfMonth, months, daysOfWeek map[int]bool
+
+	// as in cron, a time matches if either day field matches, unless one of them starts with '*'
+	starredDayOfMonth, starredDayOfWeek bool
+}
+
+type scheduleField struct {
+	name     string
+	min, max int
+}
+
+var scheduleFields = []scheduleField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7},
+}
+
+func parseSchedule(expr string) (schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(scheduleFields) {
+		return schedule{}, fmt.Errorf("schedule '%s' must have %d fields", expr, len(scheduleFields))
+	}
+
+	values := make([]map[int]bool, len(parts))
+	for i, part := range parts {
+		v, err := parseScheduleField(part, scheduleFields[i])
+		if err != nil {
+			return schedule{}, fmt.Errorf("schedule '%s': %w", expr, err)
+		}
+		values[i] = v
+	}
+
+	// Sunday is either 0 or 7
+	if values[4][7] {
+		values[4][0] = true
+	}
+
+	return schedule{
+		minutes:           values[0],
+		hours:             values[1],
+		daysOfMonth:       values[2],
+		months:            values[3],
+		daysOfWeek:        values[4],
+		starredDayOfMonth: isWildcard(parts[2]),
+		starredDayOfWeek:  isWildcard(parts[4]),
+	}, nil
+}
+
+// isWildcard reports whether the field starts with '*', with or without a step. As in Vixie cron, a day field
+// starting with '*', e.g. '*/2', doesn't widen the other one: both day fields must match.
+func isWildcard(expr string) bool {
+	return strings.HasPrefix(expr, "*")
+}
+
+// parseScheduleField parses a comma separated list of '*', values or ranges, each optionally followed by a step.
+func parseScheduleField(expr string, field scheduleField) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, item := range strings.Split(expr, ",") {
+		rng, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			s, err := strconv.Atoi(item[i+1:])
+			if err != nil || s <= 0 {
+				return nil, fmt.Errorf("invalid step in %s '%s'", field.name, item)
+			}
+			rng, step = item[:i], s
+		}
+
+		from, to := field.min, field.max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid %s '%s'", field.name, item)
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid %s '%s'", field.name, item)
+				}
+			}
+		}
+
+		if from < field.min || to > field.max || from > to {
+			return nil, fmt.Errorf("%s '%s' out of range %d-%d", field.name, item, field.min, field.max)
+		}
+
+		for v := from; v <= to; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+func (s schedule) matches(t time.Time) bool {
+	if !s.minutes[t.Minute()] || !s.hours[t.Hour()] || !s.months[int(t.Month())] {
+		return false
+	}
+
+	dayOfMonth := s.daysOfMonth[t.Day()]
+	dayOfWeek := s.daysOfWeek[int(t.Weekday())]
+	if s.starredDayOfMonth || s.starredDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// lastStart returns the latest time the schedule fired in the period (t-d, t].
+func (s schedule) lastStart(t time.Time, d time.Duration) (time.Time, bool) {
+	t = t.Truncate(time.Minute)
+	for start := t; t.Sub(start) < d; start = start.Add(-time.Minute) {
+		if s.matches(start) {
+			return start, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,277 @@
+package clusterconf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the version of the configuration documents. Documents of older versions are
+// migrated to it in memory when they are read.
+const CurrentVersion = "v0.1"
+
+// migration upgrades a document to the version following the one it is registered for.
+type migration struct {
+	to      string
+	migrate func(doc *yaml.Node) error
+}
+
+var migrations = map[string]migration{
+	// Documents written before versioning was introduced have no version, they are identical to v0.1.
+	"": {to: "v0.1", migrate: func(*yaml.Node) error { return nil }},
+}
+
+// DocumentError locates an error in a configuration file.
+type DocumentError struct {
+	File     string
+	Document int
+	Line     int
+	Err      error
+}
+
+func (e *DocumentError) Error() string {
+	location := Location{File: e.File, Document: e.Document, Line: e.Line}.String()
+	if location == "" {
+		return e.Err.Error()
+	}
+	return location + ": " + e.Err.Error()
+}
+
+func (e *DocumentError) Unwrap() error {
+	return e.Err
+}
+
+// Location is where a document was read from.
+type Location struct {
+	File     string
+	Document int
+	Line     int
+}
+
+func (l Location) String() string {
+	var location []string
+	if l.File != "" {
+		location = append(location, l.File)
+	}
+	if l.Document > 0 {
+		location = append(location, fmt.Sprintf("document %d", l.Document))
+	}
+	if l.Line > 0 {
+		location = append(location, fmt.Sprintf("line %d", l.Line))
+	}
+	return strings.Join(location, ": ")
+}
+
+// wrap locates err at l.
+func (l Location) wrap(err error) error {
+	return &DocumentError{File: l.File, Document: l.Document, Line: l.Line, Err: err}
+}
+
+// document is a single YAML document of a configuration file.
+type document struct {
+	file  string
+	index int
+	node  *yaml.Node
+}
+
+func (d document) mapping() *yaml.Node {
+	if d.node.Kind == yaml.DocumentNode && len(d.node.Content) > 0 {
+		return d.node.Content[0]
+	}
+	return d.node
+}
+
+func (d document) empty() bool {
+	m := d.mapping()
+	return m.Kind == yaml.DocumentNode || (m.Kind == yaml.ScalarNode && m.Tag == "!!null")
+}
+
+func (d document) location() Location {
+	return Location{File: d.file, Document: d.index, Line: d.mapping().Line}
+}
+
+// wrap locates err in the document. Errors which already carry a line keep it.
+func (d document) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if docErr, ok := err.(*DocumentError); ok {
+		docErr.File, docErr.Document = d.file, d.index
+		return docErr
+	}
+	return d.location().wrap(err)
+}
+
+// prepare migrates the document to the current version and returns its config type, which defaults to
+// defaultConfigType when it isn't set.
+func (d document) prepare(defaultConfigType string) (string, error) {
+	m := d.mapping()
+	if m.Kind != yaml.MappingNode {
+		return "", d.wrap(errors.New("document must be a mapping"))
+	}
+
+	version := ""
+	if node := mappingValue(m, "version"); node != nil {
+		version = node.Value
+	}
+
+	for version != CurrentVersion {
+		mig, ok := migrations[version]
+		if !ok {
+			node := mappingValue(m, "version")
+			return "", d.wrap(&DocumentError{
+				Line: node.Line,
+				Err:  fmt.Errorf("unsupported version '%s', expected %s", version, CurrentVersion),
+			})
+		}
+
+		if err := mig.migrate(m); err != nil {
+			return "", d.wrap(fmt.Errorf("migrating from version '%s' to %s: %w", version, mig.to, err))
+		}
+		version = mig.to
+		setMappingValue(m, "version", version)
+	}
+
+	node := mappingValue(m, "configType")
+	if node == nil {
+		setMappingValue(m, "configType", defaultConfigType)
+		return defaultConfigType, nil
+	}
+	return node.Value, nil
+}
+
+// unknownConfigType returns the error for a document whose config type isn't one of expected.
+func (d document) unknownConfigType(configType string, expected ...string) error {
+	return d.wrap(&DocumentError{
+		Line: mappingValue(d.mapping(), "configType").Line,
+		Err:  fmt.Errorf("unknown configType '%s', expected one of %s", configType, strings.Join(expected, ", ")),
+	})
+}
+
+// decode decodes the document into out, rejecting fields which out doesn't declare.
+func (d document) decode(out interface{}) error {
+	if err := checkKnownFields(d.mapping(), reflect.TypeOf(out)); err != nil {
+		return d.wrap(err)
+	}
+	if err := d.node.Decode(out); err != nil {
+		return d.wrap(fmt.Errorf("could not read config file: %w", err))
+	}
+	return nil
+}
+
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(m *yaml.Node, key, value string) {
+	if node := mappingValue(m, key); node != nil {
+		node.Value = value
+		return
+	}
+
+	m.Content = append(m.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+// checkKnownFields walks node and returns an error for the first mapping key which has no matching field in t.
+func checkKnownFields(node *yaml.Node, t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) || t == timeType {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				return &DocumentError{Line: key.Line, Err: fmt.Errorf("field %s not found in type %s", key.Value, t.Name())}
+			}
+			if err := checkKnownFields(value, fieldType); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for _, item := range node.Content {
+			if err := checkKnownFields(item, t.Elem()); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := checkKnownFields(node.Content[i], t.Elem()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// yamlFields returns the types of the fields of struct t by their YAML key, following yaml.v3 conventions.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+
+		parts := strings.SplitN(tag, ",", 2)
+		if len(parts) == 2 && strings.Contains(parts[1], "inline") {
+			for k, v := range yamlFields(field.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+
+		name := parts[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
@@ -0,0 +1,122 @@
+package clusterconf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseConfigFile_Errors(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		err    string
+	}{
+		"unknown field": {
+			config: `version: "v0.1"
+configType: Cluster
+metadata:
+  name: dev1
+  labels:
+    environment: development
+spec:
+  manifestFolder: /flux/promoted/development/dev1
+---
+version: "v0.1"
+configType: Cluster
+metadata:
+  name: dev2
+  labels:
+    environment: development
+spec:
+  manifestFolder: /flux/promoted/development/dev2
+  manifestFolders: /flux/promoted/development/dev3
+`,
+			err: "clusters.yaml: document 2: line 18: field manifestFolders not found in type ClusterSpec",
+		},
+		"unknown nested field": {
+			config: `version: "v0.1"
+configType: Environment
+metadata:
+  name: development
+spec:
+  grouping:
+    strategy: Label
+    lable: cloud
+`,
+			err: "clusters.yaml: document 1: line 8: field lable not found in type GroupingPolicy",
+		},
+		"unsupported version": {
+			config: `configType: Cluster
+version: "v1"
+`,
+			err: "clusters.yaml: document 1: line 2: unsupported version 'v1', expected v0.1",
+		},
+		"unknown config type": {
+			config: `version: "v0.1"
+configType: Workload
+`,
+			err: "clusters.yaml: document 1: line 2: unknown configType 'Workload', expected one of Cluster, ClusterGroup, Environment, Layout",
+		},
+		"invalid cluster": {
+			config: `version: "v0.1"
+configType: Environment
+metadata:
+  name: development
+---
+version: "v0.1"
+configType: Cluster
+metadata:
+  name: dev1
+`,
+			err: "clusters.yaml: document 2: line 6: manifestfolder is required",
+		},
+		"not a mapping": {
+			config: `- version: "v0.1"`,
+			err:    "clusters.yaml: document 1: line 1: document must be a mapping",
+		},
+		"invalid yaml": {
+			config: "version: [",
+			err:    "clusters.yaml: document 1: could not read config file: yaml: line 1: did not find expected node content",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfigFile("clusters.yaml", strings.NewReader(tt.config))
+
+			var docErr *DocumentError
+			require.ErrorAs(t, err, &docErr)
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func Test_parseConfigFile_MigratesUnversionedDocuments(t *testing.T) {
+	config, err := ParseConfigFile("clusters.yaml", strings.NewReader(`metadata:
+  name: dev1
+  labels:
+    environment: development
+spec:
+  manifestFolder: /flux/promoted/development/dev1
+`))
+	require.NoError(t, err)
+	require.Len(t, config.Clusters, 1)
+
+	assert.Equal(t, CurrentVersion, config.Clusters[0].Version)
+	assert.Equal(t, ConfigTypeCluster, config.Clusters[0].ConfigType)
+}
+
+func Test_parseConfigFile_SkipsEmptyDocuments(t *testing.T) {
+	config, err := ParseConfigFile("clusters.yaml", strings.NewReader(`---
+version: "v0.1"
+configType: Environment
+metadata:
+  name: development
+---
+---
+`))
+	require.NoError(t, err)
+	require.Len(t, config.Environments, 1)
+}
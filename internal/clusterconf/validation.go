@@ -0,0 +1,115 @@
+package clusterconf
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ValidationErrors aggregates validation errors so that they are all reported at once.
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d validation errors:\n%s", len(e), strings.Join(msgs, "\n"))
+}
+
+// clusterProblem is a validation error of the cluster at index in a set of clusters, located at
+// the source of the cluster.
+type clusterProblem struct {
+	index int
+	err   error
+}
+
+// validationErrors reports the problems sorted by cluster as ValidationErrors, or nil without problems.
+func validationErrors(problems []clusterProblem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+
+	sortProblems(problems)
+
+	var errs ValidationErrors
+	for _, p := range problems {
+		errs = append(errs, p.err)
+	}
+	return errs
+}
+
+// problems checks the clusters as a set: names and manifest folders must be unique, manifest folders
+// can't be nested in one another or overlap with config folders, and every cluster needs an environment label.
+func (c Clusters) problems() []clusterProblem {
+	var problems []clusterProblem
+	add := func(index int, format string, args ...interface{}) {
+		problems = append(problems, clusterProblem{index: index, err: c[index].Source.wrap(fmt.Errorf(format, args...))})
+	}
+
+	names := map[string]int{}
+	for i, cluster := range c {
+		if cluster.Environment() == "" {
+			add(i, "cluster %s: environment label is required", cluster.Name())
+		}
+
+		if first, ok := names[cluster.Name()]; !ok {
+			names[cluster.Name()] = i
+		} else if source := c[first].Source.String(); source != "" {
+			add(i, "cluster %s: declared more than once, first at %s", cluster.Name(), source)
+		} else {
+			add(i, "cluster %s: declared more than once", cluster.Name())
+		}
+
+		manifestFolder := cleanFolder(cluster.ManifestFolder())
+		for _, other := range c[:i] {
+			otherFolder := cleanFolder(other.ManifestFolder())
+			switch {
+			case manifestFolder == otherFolder:
+				add(i, "cluster %s: manifestFolder %s is already used by cluster %s", cluster.Name(), cluster.ManifestFolder(), other.Name())
+			case isNested(manifestFolder, otherFolder):
+				add(i, "cluster %s: manifestFolder %s is nested in manifestFolder %s of cluster %s", cluster.Name(), cluster.ManifestFolder(), other.ManifestFolder(), other.Name())
+			case isNested(otherFolder, manifestFolder):
+				add(i, "cluster %s: manifestFolder %s contains manifestFolder %s of cluster %s", cluster.Name(), cluster.ManifestFolder(), other.ManifestFolder(), other.Name())
+			}
+		}
+	}
+
+	for i, cluster := range c {
+		if cluster.ConfigFolder() == "" {
+			continue
+		}
+
+		configFolder := cleanFolder(cluster.ConfigFolder())
+		for _, other := range c {
+			manifestFolder := cleanFolder(other.ManifestFolder())
+			if configFolder == manifestFolder || isNested(configFolder, manifestFolder) || isNested(manifestFolder, configFolder) {
+				add(i, "cluster %s: configFolder %s overlaps with manifestFolder %s of cluster %s", cluster.Name(), cluster.ConfigFolder(), other.ManifestFolder(), other.Name())
+			}
+		}
+	}
+
+	sortProblems(problems)
+	return problems
+}
+
+// sortProblems orders problems by cluster, keeping the order of the problems of each cluster.
+func sortProblems(problems []clusterProblem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].index < problems[j].index
+	})
+}
+
+func cleanFolder(folder string) string {
+	return filepath.Clean("/" + folder)
+}
+
+// isNested reports whether folder is inside parent.
+func isNested(folder, parent string) bool {
+	return strings.HasPrefix(folder, strings.TrimSuffix(parent, "/")+"/")
+}
@@ -0,0 +1,131 @@
+package clusterconf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusters_problems(t *testing.T) {
+	c := func(name, env, manifestFolder, configFolder string) Cluster {
+		return Cluster{
+			Metadata: ClusterMetadata{Name: name, Labels: Labels{"environment": env}},
+			Spec:     ClusterSpec{ManifestFolder: manifestFolder, ConfigFolder: configFolder},
+		}
+	}
+
+	tests := map[string]struct {
+		clusters Clusters
+		wantErr  string
+	}{
+		"valid clusters": {
+			clusters: Clusters{
+				c("dev1", "development", "/promoted/development/dev1", "/config/development/dev1"),
+				c("dev2", "development", "/promoted/development/dev2", "/config/development/dev2"),
+				c("dev10", "development", "/promoted/development/dev10", ""),
+			},
+		},
+		"duplicate name": {
+			clusters: Clusters{
+				c("dev1", "development", "/promoted/development/dev1", ""),
+				c("dev1", "development", "/promoted/development/dev2", ""),
+			},
+			wantErr: "cluster dev1: declared more than once",
+		},
+		"duplicate manifest folder": {
+			clusters: Clusters{
+				c("dev1", "development", "/promoted/development/dev1", ""),
+				c("dev2", "development", "promoted/development/dev1/", ""),
+			},
+			wantErr: "cluster dev2: manifestFolder promoted/development/dev1/ is already used by cluster dev1",
+		},
+		"nested manifest folder": {
+			clusters: Clusters{
+				c("dev1", "development", "/promoted/development", ""),
+				c("dev2", "development", "/promoted/development/dev2", ""),
+			},
+			wantErr: "cluster dev2: manifestFolder /promoted/development/dev2 is nested in manifestFolder /promoted/development of cluster dev1",
+		},
+		"manifest folder containing another": {
+			clusters: Clusters{
+				c("dev1", "development", "/promoted/development/dev1", ""),
+				c("dev2", "development", "/promoted", ""),
+			},
+			wantErr: "cluster dev2: manifestFolder /promoted contains manifestFolder /promoted/development/dev1 of cluster dev1",
+		},
+		"config folder overlapping a manifest folder": {
+			clusters: Clusters{
+				c("dev1", "development", "/promoted/development/dev1", "/promoted/development/dev2/config"),
+				c("dev2", "development", "/promoted/development/dev2", ""),
+			},
+			wantErr: "cluster dev1: configFolder /promoted/development/dev2/config overlaps with manifestFolder /promoted/development/dev2 of cluster dev2",
+		},
+		"missing environment label": {
+			clusters: Clusters{
+				c("dev1", "", "/promoted/development/dev1", ""),
+			},
+			wantErr: "cluster dev1: environment label is required",
+		},
+		"all problems": {
+			clusters: Clusters{
+				c("dev1", "development", "/promoted/development/dev1", ""),
+				c("dev1", "", "/promoted/development/dev1", ""),
+			},
+			wantErr: "3 validation errors:\n" +
+				"cluster dev1: environment label is required\n" +
+				"cluster dev1: declared more than once\n" +
+				"cluster dev1: manifestFolder /promoted/development/dev1 is already used by cluster dev1",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validationErrors(tt.clusters.problems())
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, err.Error())
+		})
+	}
+}
+
+func Test_parseConfigFile_ReportsAllClusterProblems(t *testing.T) {
+	_, err := ParseConfigFile("clusters.yaml", strings.NewReader(`version: "v0.1"
+configType: Cluster
+metadata:
+  name: dev1
+  labels:
+    environment: development
+spec:
+  manifestFolder: /promoted/development/dev1
+---
+version: "v0.1"
+configType: Cluster
+metadata:
+  name: dev1
+  labels:
+    environment: staging
+spec:
+  manifestFolder: /promoted/development/dev2
+---
+version: "v0.1"
+configType: Cluster
+metadata:
+  name: dev3
+spec:
+  manifestFolder: /promoted/development/dev1/nested
+`))
+
+	require.Error(t, err)
+	assert.IsType(t, ValidationErrors{}, err)
+	assert.Equal(t, "4 validation errors:\n"+
+		"clusters.yaml: document 2: line 10: cluster dev1: declared more than once, first at clusters.yaml: document 1: line 1\n"+
+		"clusters.yaml: document 2: line 10: env 'staging' is not one of development, test, production\n"+
+		"clusters.yaml: document 3: line 19: cluster dev3: environment label is required\n"+
+		"clusters.yaml: document 3: line 19: cluster dev3: manifestFolder /promoted/development/dev1/nested is nested in manifestFolder /promoted/development/dev1 of cluster dev1",
+		err.Error())
+}
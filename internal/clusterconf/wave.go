@@ -0,0 +1,98 @@
+package clusterconf
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/form3tech/k8s-promoter/internal/environment"
+)
+
+// Wave is a subset of the clusters of an environment which is promoted before the following waves.
+// Clusters belong to a wave either by being listed in it or by having the environment's wave label
+// set to the wave name. Clusters which don't belong to any wave are promoted last.
+type Wave struct {
+	Name     string   `yaml:"name"`
+	Clusters []string `yaml:"clusters,omitempty"`
+}
+
+func validateWaves(waves []Wave, waveLabel string) error {
+	if waveLabel != "" && len(waves) == 0 {
+		return errors.New("waveLabel requires waves to be declared")
+	}
+
+	seen := map[string]bool{}
+	for _, wave := range waves {
+		if wave.Name == "" {
+			return errors.New("wave name is required")
+		}
+		if seen[wave.Name] {
+			return fmt.Errorf("wave %s declared more than once", wave.Name)
+		}
+		seen[wave.Name] = true
+	}
+	return nil
+}
+
+// unknownWaveClusters reports the clusters listed in the waves of the environment which aren't clusters of the
+// environment. A typo would otherwise silently move the cluster into the implicit last wave.
+func (e Environment) unknownWaveClusters(clusters Clusters) []error {
+	known := map[string]bool{}
+	for _, cluster := range clusters.Filter(ByEnvironment(e.Name())) {
+		known[cluster.Name()] = true
+	}
+
+	var errs []error
+	for _, wave := range e.Spec.Waves {
+		for _, name := range wave.Clusters {
+			if !known[name] {
+				errs = append(errs, fmt.Errorf("environment %s: wave %s lists unknown cluster %s", e.Name(), wave.Name, name))
+			}
+		}
+	}
+	return errs
+}
+
+// Waves splits the clusters into the rollout waves declared for the environment, in rollout order.
+// Without declared waves, all clusters belong to a single wave.
+func (c Config) Waves(env environment.Env, clusters Clusters) []Clusters {
+	spec := c.environmentSpec(env)
+	if len(spec.Waves) == 0 {
+		return []Clusters{clusters}
+	}
+
+	// the extra bucket holds clusters which are not part of any declared wave
+	buckets := make([]Clusters, len(spec.Waves)+1)
+	for _, cluster := range clusters {
+		i := waveIndex(spec, cluster)
+		buckets[i] = append(buckets[i], cluster)
+	}
+
+	var waves []Clusters
+	for _, bucket := range buckets {
+		if len(bucket) > 0 {
+			waves = append(waves, bucket)
+		}
+	}
+	return waves
+}
+
+func waveIndex(spec EnvironmentSpec, cluster Cluster) int {
+	for i, wave := range spec.Waves {
+		for _, name := range wave.Clusters {
+			if name == cluster.Name() {
+				return i
+			}
+		}
+	}
+
+	if spec.WaveLabel != "" {
+		value, ok := cluster.Metadata.Labels[spec.WaveLabel]
+		for i, wave := range spec.Waves {
+			if ok && value == wave.Name {
+				return i
+			}
+		}
+	}
+
+	return len(spec.Waves)
+}
@@ -0,0 +1,121 @@
+package clusterconf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/form3tech/k8s-promoter/internal/environment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Waves(t *testing.T) {
+	c := func(name, ring string) Cluster {
+		return Cluster{Metadata: ClusterMetadata{Name: name, Labels: Labels{"environment": "production", "ring": ring}}}
+	}
+	clusters := Clusters{c("a", "late"), c("b", "early"), c("c", ""), c("d", "late")}
+
+	config, err := ParseConfig(strings.NewReader(`version: "v0.1"
+configType: Environment
+metadata:
+  name: test
+---
+version: "v0.1"
+configType: Environment
+metadata:
+  name: production
+spec:
+  waveLabel: ring
+  waves:
+  - name: canary
+    clusters:
+    - c
+  - name: early
+  - name: late
+---
+version: "v0.1"
+configType: Cluster
+metadata:
+  name: c
+  labels:
+    environment: production
+spec:
+  manifestFolder: /promoted/production/c
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Clusters{{c("c", "")}, {c("b", "early")}, {c("a", "late"), c("d", "late")}}, config.Waves(environment.Production, clusters))
+	assert.Equal(t, []Clusters{clusters}, config.Waves(environment.Test, clusters))
+}
+
+func TestConfig_UnassignedClustersArePromotedLast(t *testing.T) {
+	c := func(name string) Cluster {
+		return Cluster{Metadata: ClusterMetadata{Name: name}}
+	}
+
+	config := Config{Environments: Environments{{
+		Metadata: EnvironmentMetadata{Name: "production"},
+		Spec:     EnvironmentSpec{Waves: []Wave{{Name: "canary", Clusters: []string{"b"}}}},
+	}}}
+
+	assert.Equal(t, []Clusters{{c("b")}, {c("a"), c("c")}}, config.Waves(environment.Production, Clusters{c("a"), c("b"), c("c")}))
+}
+
+func TestConfig_InvalidWaves(t *testing.T) {
+	_, err := ParseConfig(strings.NewReader(`version: "v0.1"
+configType: Environment
+metadata:
+  name: production
+spec:
+  waves:
+  - name: canary
+  - name: canary
+`))
+	require.EqualError(t, err, "document 1: line 1: environment production: wave canary declared more than once")
+}
+
+func TestConfig_WavesListUnknownClusters(t *testing.T) {
+	environments := `version: "v0.1"
+configType: Environment
+metadata:
+  name: test
+---
+version: "v0.1"
+configType: Environment
+metadata:
+  name: production
+spec:
+  waves:
+  - name: canary
+    clusters:
+    - prod1
+    - prod2
+    - test1
+`
+	clusters := `version: "v0.1"
+configType: Cluster
+metadata:
+  name: test1
+  labels:
+    environment: test
+spec:
+  manifestFolder: /promoted/test/test1
+---
+version: "v0.1"
+configType: Cluster
+metadata:
+  name: prod1
+  labels:
+    environment: production
+spec:
+  manifestFolder: /promoted/production/prod1
+`
+
+	_, err := ParseConfigFiles(
+		ConfigFile{Name: "clusters/environments.yaml", Content: strings.NewReader(environments)},
+		ConfigFile{Name: "clusters/clusters.yaml", Content: strings.NewReader(clusters)},
+	)
+	require.EqualError(t, err, "2 validation errors:\n"+
+		"clusters/environments.yaml: document 2: line 6: environment production: wave canary lists unknown cluster prod2\n"+
+		"clusters/environments.yaml: document 2: line 6: environment production: wave canary lists unknown cluster test1")
+}
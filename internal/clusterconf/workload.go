@@ -3,6 +3,11 @@ package clusterconf
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/form3tech/k8s-promoter/internal/environment"
 )
 
 type Workload struct {
@@ -10,12 +15,42 @@ type Workload struct {
 	ConfigType string           `yaml:"configType"`
 	Metadata   WorkloadMetadata `yaml:"metadata"`
 	Spec       WorkloadSpec     `yaml:"spec"`
+
+	// Namespace is the directory of the namespace the workload is in, if any, e.g. payments.
+	// Workload names only need to be unique within their namespace.
+	Namespace string `yaml:"-"`
 }
 
 func (w Workload) Validate() error {
-	if w.Name() == "" {
+	if w.Metadata.Name == "" {
 		return fmt.Errorf("workload name must not be blank")
 	}
+	if strings.Contains(w.Metadata.Name, "/") {
+		return fmt.Errorf("workload name %s must not contain /, the namespace is the directory of the workload", w.Metadata.Name)
+	}
+
+	if err := w.Metadata.Owners.validate(); err != nil {
+		return err
+	}
+
+	if err := w.Spec.Promotion.validate(); err != nil {
+		return err
+	}
+
+	for _, dependency := range w.Spec.DependsOn {
+		if dependency == "" {
+			return errors.New("dependsOn must not contain blank workload names")
+		}
+		if dependency == w.Name() {
+			return fmt.Errorf("workload %s must not depend on itself", w.Name())
+		}
+	}
+
+	for _, inclusion := range w.Spec.Inclusions {
+		if err := inclusion.validate(); err != nil {
+			return err
+		}
+	}
 
 	for _, exclusion := range w.Spec.Exclusions {
 		if err := exclusion.validate(); err != nil {
@@ -25,62 +60,306 @@ func (w Workload) Validate() error {
 	return nil
 }
 
+// Name returns the ID of the workload, its name prefixed with its namespace, e.g. payments/api.
 func (w Workload) Name() string {
-	return w.Metadata.Name
+	if w.Namespace == "" {
+		return w.Metadata.Name
+	}
+	return w.Namespace + "/" + w.Metadata.Name
+}
+
+// ExpiredExclusions returns the exclusions of the workload which no longer apply at t.
+func (w Workload) ExpiredExclusions(t time.Time) []Exclusion {
+	var expired []Exclusion
+	for _, exc := range w.Spec.Exclusions {
+		if exc.Expired(t) {
+			expired = append(expired, exc)
+		}
+	}
+	return expired
 }
 
 type WorkloadMetadata struct {
-	Name        string `yaml:"name"`
-	Description string `yaml:"description"`
+	Name        string         `yaml:"name"`
+	Description string         `yaml:"description"`
+	Owners      WorkloadOwners `yaml:"owners,omitempty"`
+}
+
+// WorkloadOwners are the GitHub users and teams owning a workload. They are requested to review the
+// pull requests promoting the workload, and the users are assigned to them. Teams are the slugs of
+// teams of the repository's organisation.
+type WorkloadOwners struct {
+	Users []string `yaml:"users,omitempty"`
+	Teams []string `yaml:"teams,omitempty"`
+}
+
+func (o WorkloadOwners) validate() error {
+	for _, user := range o.Users {
+		if user == "" || strings.HasPrefix(user, "@") {
+			return fmt.Errorf("invalid owner user %q, expected a GitHub login", user)
+		}
+	}
+	for _, team := range o.Teams {
+		if team == "" || strings.ContainsAny(team, "@/") {
+			return fmt.Errorf("invalid owner team %q, expected the slug of a team of the organisation", team)
+		}
+	}
+	return nil
 }
 
+// WorkloadSpec selects the clusters the workload is deployed to. A cluster must match all the inclusions,
+// if any, and none of the exclusions: exclusions take precedence over inclusions.
 type WorkloadSpec struct {
+	// Path is the directory of the workload's source manifests. It is informational only, the
+	// directory is always derived from the workload name.
+	Path string `yaml:"path,omitempty"`
+
+	Inclusions []Inclusion `yaml:"inclusions,omitempty"`
 	Exclusions []Exclusion `yaml:"exclusions"`
+
+	Promotion WorkloadPromotion `yaml:"promotion,omitempty"`
+
+	// DependsOn are the workloads which must be promoted to a cluster, at the version of the source
+	// environment or newer, before the workload is.
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+}
+
+// WorkloadPromotion is the promotion policy of a workload. The zero value promotes the workload
+// automatically to all environments.
+type WorkloadPromotion struct {
+	// Environments are the target environments the workload is promoted to, all of them when empty.
+	Environments []environment.Env `yaml:"environments,omitempty"`
+	// Manual are the target environments the workload is only promoted to by manually triggered promotions.
+	Manual []environment.Env `yaml:"manual,omitempty"`
+	// MaxClustersPerPR limits the number of clusters of the pull requests promoting the workload.
+	MaxClustersPerPR int `yaml:"maxClustersPerPR,omitempty"`
+}
+
+func (p WorkloadPromotion) validate() error {
+	for _, env := range append(append([]environment.Env{}, p.Environments...), p.Manual...) {
+		if env == "" {
+			return errors.New("promotion environments must not be empty")
+		}
+	}
+	if p.MaxClustersPerPR < 0 {
+		return errors.New("promotion maxClustersPerPR must not be negative")
+	}
+	return nil
+}
+
+// SkipReason returns why the workload isn't promoted to the target environment, or an empty string
+// when it is. manual tells whether the promotion was triggered manually.
+func (p WorkloadPromotion) SkipReason(target environment.Env, manual bool) string {
+	if len(p.Environments) > 0 && !containsEnv(p.Environments, target) {
+		return fmt.Sprintf("not promoted to %s", target)
+	}
+	if !manual && containsEnv(p.Manual, target) {
+		return fmt.Sprintf("promoted to %s manually only", target)
+	}
+	return ""
+}
+
+func containsEnv(envs []environment.Env, env environment.Env) bool {
+	for _, e := range envs {
+		if e == env {
+			return true
+		}
+	}
+	return false
 }
 
 type Operator string
 
 const (
-	OperatorNotEqual Operator = "NotEqual"
-	OperatorEqual    Operator = "Equal"
+	OperatorNotEqual     Operator = "NotEqual"
+	OperatorEqual        Operator = "Equal"
+	OperatorIn           Operator = "In"
+	OperatorNotIn        Operator = "NotIn"
+	OperatorExists       Operator = "Exists"
+	OperatorDoesNotExist Operator = "DoesNotExist"
+	OperatorMatches      Operator = "Matches"
 )
 
 func (o Operator) validate() error {
 	switch o {
-	case OperatorEqual, OperatorNotEqual:
+	case OperatorEqual, OperatorNotEqual, OperatorIn, OperatorNotIn, OperatorExists, OperatorDoesNotExist, OperatorMatches:
 		return nil
 	}
 	return fmt.Errorf("unknown operator: %s", o)
 }
 
+// MissingLabel tells whether an exclusion applies to clusters which don't have the exclusion's key.
+type MissingLabel string
+
+const (
+	// MissingLabelIgnore doesn't exclude clusters missing the label. This is the default.
+	MissingLabelIgnore MissingLabel = "Ignore"
+	// MissingLabelExclude excludes clusters missing the label.
+	MissingLabelExclude MissingLabel = "Exclude"
+)
+
+// Exclusion excludes a workload from the clusters whose labels match it:
+//   - Equal, NotEqual: the label is (not) equal to value
+//   - In, NotIn: the label is (not) one of values
+//   - Matches: the whole label matches the regular expression in value
+//   - Exists, DoesNotExist: the cluster has (not) the label
+//
+// Except for Exists and DoesNotExist, clusters missing the label are handled according to onMissingLabel.
+// An exclusion with until, e.g. while an incident is investigated, stops applying at that time.
+// The regular expression of Matches is compiled once when the workload is parsed, or on each match otherwise.
 type Exclusion struct {
-	Key      string   `yaml:"key"`
-	Operator Operator `yaml:"operator"`
-	Value    string   `yaml:"value"`
+	Key            string       `yaml:"key"`
+	Operator       Operator     `yaml:"operator"`
+	Value          string       `yaml:"value,omitempty"`
+	Values         []string     `yaml:"values,omitempty"`
+	OnMissingLabel MissingLabel `yaml:"onMissingLabel,omitempty"`
+	Until          time.Time    `yaml:"until,omitempty"`
+	Reason         string       `yaml:"reason,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Expired reports whether the exclusion no longer applies at t.
+func (e Exclusion) Expired(t time.Time) bool {
+	return !e.Until.IsZero() && !t.Before(e.Until)
+}
+
+func (e Exclusion) String() string {
+	s := fmt.Sprintf("%s %s", e.Key, e.Operator)
+	switch {
+	case e.Value != "":
+		s += " " + e.Value
+	case len(e.Values) > 0:
+		s += fmt.Sprintf(" [%s]", strings.Join(e.Values, ", "))
+	}
+
+	if !e.Until.IsZero() {
+		s += fmt.Sprintf(" until %s", e.Until.UTC().Format(time.RFC3339))
+	}
+	if e.Reason != "" {
+		s += fmt.Sprintf(" (%s)", e.Reason)
+	}
+	return s
 }
 
 func (e Exclusion) validate() error {
+	return e.validateAs("Exclusion")
+}
+
+// validateAs validates the requirement, naming it kind in errors as exclusions and inclusions share their fields.
+func (e Exclusion) validateAs(kind string) error {
 	if e.Key == "" {
-		return errors.New("Exclusion.Key must not be empty")
+		return fmt.Errorf("%s.Key must not be empty", kind)
+	}
+	if err := e.Operator.validate(); err != nil {
+		return err
+	}
+
+	switch e.Operator {
+	case OperatorEqual, OperatorNotEqual, OperatorMatches:
+		if e.Value == "" {
+			return fmt.Errorf("%s.Value must not be empty", kind)
+		}
+		if len(e.Values) > 0 {
+			return fmt.Errorf("%s.Values must be empty with operator %s", kind, e.Operator)
+		}
+	case OperatorIn, OperatorNotIn:
+		if len(e.Values) == 0 {
+			return fmt.Errorf("%s.Values must not be empty with operator %s", kind, e.Operator)
+		}
+		if e.Value != "" {
+			return fmt.Errorf("%s.Value must be empty with operator %s", kind, e.Operator)
+		}
+	case OperatorExists, OperatorDoesNotExist:
+		if e.Value != "" || len(e.Values) > 0 {
+			return fmt.Errorf("%s.Value and %s.Values must be empty with operator %s", kind, kind, e.Operator)
+		}
+		if e.OnMissingLabel != "" {
+			return fmt.Errorf("%s.OnMissingLabel can't be used with operator %s", kind, e.Operator)
+		}
+	}
+
+	if e.Operator == OperatorMatches {
+		if _, err := regexp.Compile(e.Value); err != nil {
+			return fmt.Errorf("%s.Value is not a valid regular expression: %w", kind, err)
+		}
 	}
-	if e.Value == "" {
-		return errors.New("Exclusion.Value must not be empty")
+
+	switch e.OnMissingLabel {
+	case "", MissingLabelIgnore, MissingLabelExclude:
+		return nil
 	}
-	return e.Operator.validate()
+	return fmt.Errorf("unknown onMissingLabel: %s", e.OnMissingLabel)
 }
 
 func (e Exclusion) Excludes(labels Labels) bool {
-	for key, value := range labels {
-		switch e.Operator {
-		case OperatorNotEqual:
-			if e.Key == key && e.Value != value {
-				return true
-			}
-		case OperatorEqual:
-			if e.Key == key && e.Value == value {
-				return true
-			}
+	value, ok := labels[e.Key]
+
+	switch e.Operator {
+	case OperatorExists:
+		return ok
+	case OperatorDoesNotExist:
+		return !ok
+	}
+
+	if !ok {
+		return e.OnMissingLabel == MissingLabelExclude
+	}
+
+	switch e.Operator {
+	case OperatorNotEqual:
+		return e.Value != value
+	case OperatorEqual:
+		return e.Value == value
+	case OperatorIn:
+		return contains(e.Values, value)
+	case OperatorNotIn:
+		return !contains(e.Values, value)
+	case OperatorMatches:
+		return e.matcher().MatchString(value)
+	}
+	return false
+}
+
+// compile compiles the regular expression of Matches, which must be valid.
+func (e *Exclusion) compile() {
+	if e.Operator == OperatorMatches {
+		e.pattern = e.matcher()
+	}
+}
+
+// matcher returns the regular expression of Matches, anchored to match the whole label. It panics if the
+// expression isn't valid, as validation rejects those.
+func (e Exclusion) matcher() *regexp.Regexp {
+	if e.pattern != nil {
+		return e.pattern
+	}
+	return regexp.MustCompile("^(?:" + e.Value + ")$")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
 		}
 	}
 	return false
 }
+
+// Inclusion restricts a workload to the clusters whose labels match it. It supports the same operators
+// as Exclusion, but a cluster missing the label never matches, except with DoesNotExist.
+type Inclusion Exclusion
+
+func (i Inclusion) validate() error {
+	if i.OnMissingLabel != "" {
+		return errors.New("Inclusion.OnMissingLabel is not supported, clusters missing the label are never included")
+	}
+	if !i.Until.IsZero() {
+		return errors.New("Inclusion.Until is not supported")
+	}
+	return Exclusion(i).validateAs("Inclusion")
+}
+
+func (i Inclusion) Includes(labels Labels) bool {
+	return Exclusion(i).Excludes(labels)
+}
@@ -0,0 +1,371 @@
+package clusterconf
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/form3tech/k8s-promoter/internal/environment"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExclusion_Excludes(t *testing.T) {
+	cloud1 := Labels{"cloud": "cloud1", "environment": "production"}
+	cloud2 := Labels{"cloud": "cloud2", "environment": "production"}
+	noCloud := Labels{"environment": "production"}
+
+	tests := map[string]struct {
+		exclusion Exclusion
+		excludes  []Labels
+		includes  []Labels
+	}{
+		"Equal": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorEqual, Value: "cloud1"},
+			excludes:  []Labels{cloud1},
+			includes:  []Labels{cloud2, noCloud},
+		},
+		"NotEqual": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorNotEqual, Value: "cloud1"},
+			excludes:  []Labels{cloud2},
+			includes:  []Labels{cloud1, noCloud},
+		},
+		"NotEqual excluding missing label": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorNotEqual, Value: "cloud1", OnMissingLabel: MissingLabelExclude},
+			excludes:  []Labels{cloud2, noCloud},
+			includes:  []Labels{cloud1},
+		},
+		"In": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorIn, Values: []string{"cloud1", "cloud3"}},
+			excludes:  []Labels{cloud1},
+			includes:  []Labels{cloud2, noCloud},
+		},
+		"NotIn": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorNotIn, Values: []string{"cloud1", "cloud3"}},
+			excludes:  []Labels{cloud2},
+			includes:  []Labels{cloud1, noCloud},
+		},
+		"NotIn excluding missing label": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorNotIn, Values: []string{"cloud1", "cloud3"}, OnMissingLabel: MissingLabelExclude},
+			excludes:  []Labels{cloud2, noCloud},
+			includes:  []Labels{cloud1},
+		},
+		"Exists": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorExists},
+			excludes:  []Labels{cloud1, cloud2},
+			includes:  []Labels{noCloud},
+		},
+		"DoesNotExist": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorDoesNotExist},
+			excludes:  []Labels{noCloud},
+			includes:  []Labels{cloud1, cloud2},
+		},
+		"Matches": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorMatches, Value: "cloud[2-9]"},
+			excludes:  []Labels{cloud2},
+			includes:  []Labels{cloud1, noCloud, {"cloud": "cloud20"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, tt.exclusion.validate())
+			for _, labels := range tt.excludes {
+				assert.True(t, tt.exclusion.Excludes(labels), labels)
+			}
+			for _, labels := range tt.includes {
+				assert.False(t, tt.exclusion.Excludes(labels), labels)
+			}
+		})
+	}
+}
+
+func TestParseWorkload_CompilesMatches(t *testing.T) {
+	config := `version: v0.1
+configType: Workload
+metadata:
+  name: foo
+spec:
+  exclusions:
+    - key: cloud
+      operator: Matches
+      value: cloud[2-9]
+`
+	w, err := ParseWorkload("foo", "workload.yaml", strings.NewReader(config))
+	require.NoError(t, err)
+	require.Len(t, w.Spec.Exclusions, 1)
+
+	exclusion := w.Spec.Exclusions[0]
+	require.NotNil(t, exclusion.pattern)
+	assert.True(t, exclusion.Excludes(Labels{"cloud": "cloud2"}))
+	assert.False(t, exclusion.Excludes(Labels{"cloud": "cloud20"}))
+}
+
+func TestExclusion_ExcludesWithoutParsing(t *testing.T) {
+	exclusion := Exclusion{Key: "cloud", Operator: OperatorMatches, Value: "cloud[2-9]"}
+	assert.True(t, exclusion.Excludes(Labels{"cloud": "cloud2"}))
+	assert.False(t, exclusion.Excludes(Labels{"cloud": "cloud20"}))
+
+	assert.Panics(t, func() {
+		Exclusion{Key: "cloud", Operator: OperatorMatches, Value: "cloud["}.Excludes(Labels{"cloud": "cloud2"})
+	})
+}
+
+func TestExclusion_Validate(t *testing.T) {
+	tests := map[string]struct {
+		exclusion Exclusion
+		err       string
+	}{
+		"missing key": {
+			exclusion: Exclusion{Operator: OperatorEqual, Value: "cloud1"},
+			err:       "Exclusion.Key must not be empty",
+		},
+		"unknown operator": {
+			exclusion: Exclusion{Key: "cloud", Operator: "Like", Value: "cloud1"},
+			err:       "unknown operator: Like",
+		},
+		"Equal without value": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorEqual},
+			err:       "Exclusion.Value must not be empty",
+		},
+		"Equal with values": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorEqual, Value: "cloud1", Values: []string{"cloud2"}},
+			err:       "Exclusion.Values must be empty with operator Equal",
+		},
+		"In without values": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorIn},
+			err:       "Exclusion.Values must not be empty with operator In",
+		},
+		"NotIn with value": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorNotIn, Value: "cloud1", Values: []string{"cloud2"}},
+			err:       "Exclusion.Value must be empty with operator NotIn",
+		},
+		"Exists with value": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorExists, Value: "cloud1"},
+			err:       "Exclusion.Value and Exclusion.Values must be empty with operator Exists",
+		},
+		"DoesNotExist with onMissingLabel": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorDoesNotExist, OnMissingLabel: MissingLabelExclude},
+			err:       "Exclusion.OnMissingLabel can't be used with operator DoesNotExist",
+		},
+		"invalid regular expression": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorMatches, Value: "cloud("},
+			err:       "Exclusion.Value is not a valid regular expression: error parsing regexp: missing closing ): `cloud(`",
+		},
+		"unknown onMissingLabel": {
+			exclusion: Exclusion{Key: "cloud", Operator: OperatorEqual, Value: "cloud1", OnMissingLabel: "Maybe"},
+			err:       "unknown onMissingLabel: Maybe",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.EqualError(t, tt.exclusion.validate(), tt.err)
+		})
+	}
+}
+
+func TestInclusion_Validate(t *testing.T) {
+	require.NoError(t, Inclusion{Key: "tier", Operator: OperatorEqual, Value: "edge"}.validate())
+	require.EqualError(t, Inclusion{Operator: OperatorEqual, Value: "edge"}.validate(), "Inclusion.Key must not be empty")
+	require.EqualError(t, Inclusion{Key: "tier", Operator: OperatorIn}.validate(), "Inclusion.Values must not be empty with operator In")
+	require.EqualError(t,
+		Inclusion{Key: "tier", Operator: OperatorEqual, Value: "edge", OnMissingLabel: MissingLabelExclude}.validate(),
+		"Inclusion.OnMissingLabel is not supported, clusters missing the label are never included")
+	require.EqualError(t,
+		Inclusion{Key: "tier", Operator: OperatorEqual, Value: "edge", Until: time.Now()}.validate(),
+		"Inclusion.Until is not supported")
+}
+
+func TestWorkload_Validate_Owners(t *testing.T) {
+	w := Workload{Metadata: WorkloadMetadata{Name: "foo"}}
+
+	w.Metadata.Owners = WorkloadOwners{Users: []string{"octocat"}, Teams: []string{"payments"}}
+	require.NoError(t, w.Validate())
+
+	w.Metadata.Owners = WorkloadOwners{Users: []string{"@octocat"}}
+	require.EqualError(t, w.Validate(), `invalid owner user "@octocat", expected a GitHub login`)
+
+	w.Metadata.Owners = WorkloadOwners{Teams: []string{"form3tech/payments"}}
+	require.EqualError(t, w.Validate(), `invalid owner team "form3tech/payments", expected the slug of a team of the organisation`)
+}
+
+func TestWorkload_Namespace(t *testing.T) {
+	w := Workload{Metadata: WorkloadMetadata{Name: "api"}, Namespace: "payments"}
+	require.Equal(t, "payments/api", w.Name())
+	require.NoError(t, w.Validate())
+
+	w.Metadata.Name = "payments/api"
+	require.EqualError(t, w.Validate(), "workload name payments/api must not contain /, the namespace is the directory of the workload")
+}
+
+func TestWorkloadPromotion_SkipReason(t *testing.T) {
+	tests := map[string]struct {
+		promotion WorkloadPromotion
+		target    environment.Env
+		manual    bool
+		want      string
+	}{
+		"no policy": {
+			target: environment.Production,
+		},
+		"allowed environment": {
+			promotion: WorkloadPromotion{Environments: []environment.Env{environment.Development, environment.Test}},
+			target:    environment.Test,
+		},
+		"environment not allowed": {
+			promotion: WorkloadPromotion{Environments: []environment.Env{environment.Development}},
+			target:    environment.Test,
+			want:      "not promoted to test",
+		},
+		"manual environment": {
+			promotion: WorkloadPromotion{Manual: []environment.Env{environment.Production}},
+			target:    environment.Production,
+			want:      "promoted to production manually only",
+		},
+		"manual environment promoted manually": {
+			promotion: WorkloadPromotion{Manual: []environment.Env{environment.Production}},
+			target:    environment.Production,
+			manual:    true,
+		},
+		"manual promotion to an environment not allowed": {
+			promotion: WorkloadPromotion{Environments: []environment.Env{environment.Development}},
+			target:    environment.Production,
+			manual:    true,
+			want:      "not promoted to production",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.promotion.SkipReason(tt.target, tt.manual))
+		})
+	}
+}
+
+func TestWorkload_Validate_Promotion(t *testing.T) {
+	w := Workload{Metadata: WorkloadMetadata{Name: "foo"}}
+
+	w.Spec.Promotion = WorkloadPromotion{MaxClustersPerPR: -1}
+	require.EqualError(t, w.Validate(), "promotion maxClustersPerPR must not be negative")
+
+	w.Spec.Promotion = WorkloadPromotion{Manual: []environment.Env{""}}
+	require.EqualError(t, w.Validate(), "promotion environments must not be empty")
+}
+
+func TestWorkload_Validate_DependsOn(t *testing.T) {
+	w := Workload{Metadata: WorkloadMetadata{Name: "operator"}, Spec: WorkloadSpec{DependsOn: []string{"crds"}}}
+	require.NoError(t, w.Validate())
+
+	w.Spec.DependsOn = []string{"operator"}
+	require.EqualError(t, w.Validate(), "workload operator must not depend on itself")
+
+	w.Spec.DependsOn = []string{""}
+	require.EqualError(t, w.Validate(), "dependsOn must not contain blank workload names")
+}
+
+func TestExclusion_Expired(t *testing.T) {
+	until := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	exc := Exclusion{Key: "cloud", Operator: OperatorEqual, Value: "cloud1", Until: until, Reason: "INC-42"}
+
+	assert.False(t, exc.Expired(until.Add(-time.Second)))
+	assert.True(t, exc.Expired(until))
+	assert.False(t, Exclusion{Key: "cloud", Operator: OperatorEqual, Value: "cloud1"}.Expired(until))
+	assert.Equal(t, "cloud Equal cloud1 until 2021-06-01T00:00:00Z (INC-42)", exc.String())
+
+	w := Workload{
+		Metadata: WorkloadMetadata{Name: "foo"},
+		Spec: WorkloadSpec{
+			Exclusions: []Exclusion{exc, {Key: "tier", Operator: OperatorIn, Values: []string{"edge", "core"}}},
+		},
+	}
+	assert.Equal(t, []Exclusion{exc}, w.ExpiredExclusions(until))
+	assert.Empty(t, w.ExpiredExclusions(until.Add(-time.Second)))
+}
+
+func TestWorkload_Validate_Inclusions(t *testing.T) {
+	w := Workload{
+		Metadata: WorkloadMetadata{Name: "foo"},
+		Spec: WorkloadSpec{
+			Inclusions: []Inclusion{{Key: "tier", Operator: "Like", Value: "edge"}},
+		},
+	}
+	require.EqualError(t, w.Validate(), "unknown operator: Like")
+}
+
+func TestCluster_AllowWorkload(t *testing.T) {
+	edge := Cluster{Metadata: ClusterMetadata{Name: "edge", Labels: Labels{"tier": "edge", "cloud": "cloud1"}}}
+	edge2 := Cluster{Metadata: ClusterMetadata{Name: "edge2", Labels: Labels{"tier": "edge", "cloud": "cloud2"}}}
+	core := Cluster{Metadata: ClusterMetadata{Name: "core", Labels: Labels{"tier": "core", "cloud": "cloud1"}}}
+	unlabelled := Cluster{Metadata: ClusterMetadata{Name: "unlabelled", Labels: Labels{"cloud": "cloud1"}}}
+
+	tests := map[string]struct {
+		spec    WorkloadSpec
+		allowed []Cluster
+		denied  []Cluster
+	}{
+		"no selectors": {
+			allowed: []Cluster{edge, edge2, core, unlabelled},
+		},
+		"inclusion": {
+			spec: WorkloadSpec{
+				Inclusions: []Inclusion{{Key: "tier", Operator: OperatorEqual, Value: "edge"}},
+			},
+			allowed: []Cluster{edge, edge2},
+			denied:  []Cluster{core, unlabelled},
+		},
+		"all inclusions must match": {
+			spec: WorkloadSpec{
+				Inclusions: []Inclusion{
+					{Key: "tier", Operator: OperatorEqual, Value: "edge"},
+					{Key: "cloud", Operator: OperatorIn, Values: []string{"cloud2", "cloud3"}},
+				},
+			},
+			allowed: []Cluster{edge2},
+			denied:  []Cluster{edge, core, unlabelled},
+		},
+		"exclusions take precedence over inclusions": {
+			spec: WorkloadSpec{
+				Inclusions: []Inclusion{{Key: "tier", Operator: OperatorEqual, Value: "edge"}},
+				Exclusions: []Exclusion{{Key: "cloud", Operator: OperatorEqual, Value: "cloud2"}},
+			},
+			allowed: []Cluster{edge},
+			denied:  []Cluster{edge2, core, unlabelled},
+		},
+		"inclusion of clusters missing the label": {
+			spec: WorkloadSpec{
+				Inclusions: []Inclusion{{Key: "tier", Operator: OperatorDoesNotExist}},
+			},
+			allowed: []Cluster{unlabelled},
+			denied:  []Cluster{edge, edge2, core},
+		},
+		"expired exclusion": {
+			spec: WorkloadSpec{
+				Exclusions: []Exclusion{{Key: "cloud", Operator: OperatorEqual, Value: "cloud2", Until: time.Now().Add(-time.Hour)}},
+			},
+			allowed: []Cluster{edge, edge2, core, unlabelled},
+		},
+		"exclusion not yet expired": {
+			spec: WorkloadSpec{
+				Exclusions: []Exclusion{{Key: "cloud", Operator: OperatorEqual, Value: "cloud2", Until: time.Now().Add(time.Hour)}},
+			},
+			allowed: []Cluster{edge, core, unlabelled},
+			denied:  []Cluster{edge2},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := Workload{Metadata: WorkloadMetadata{Name: "foo"}, Spec: tt.spec}
+			require.NoError(t, w.Validate())
+
+			for _, c := range tt.allowed {
+				assert.True(t, c.AllowWorkload(w), c.Name())
+			}
+			for _, c := range tt.denied {
+				assert.False(t, c.AllowWorkload(w), c.Name())
+			}
+		})
+	}
+}
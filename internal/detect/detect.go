@@ -3,7 +3,9 @@ package detect
 import (
 	"errors"
 	"fmt"
+	"path"
 	"regexp"
+	"strings"
 
 	"github.com/form3tech/k8s-promoter/internal/clusterconf"
 	"github.com/form3tech/k8s-promoter/internal/environment"
@@ -25,21 +27,27 @@ type Detect struct {
 	Repo     *git.Repository
 	Inferer  *Inferer
 	Registry clusterconf.WorkloadRegistry
+	Layout   clusterconf.Layout
+
+	// SemanticDiff drops the workload changes which only modify YAML files cosmetically, i.e. whose
+	// Kubernetes objects are structurally equal before and after the change.
+	SemanticDiff bool
 
 	CR     *gitint.CommitRange
 	logger *logrus.Entry
 }
 
-func NewDetect(repo *git.Repository, commitRange *gitint.CommitRange, registry clusterconf.WorkloadRegistry, log *logrus.Entry) (*Detect, error) {
+func NewDetect(repo *git.Repository, commitRange *gitint.CommitRange, registry clusterconf.WorkloadRegistry, layout clusterconf.Layout, log *logrus.Entry) (*Detect, error) {
 	if repo == nil {
 		return nil, ErrRepoNotInitialised
 	}
 
-	inferer := NewInferer(repo, commitRange.ToPrefix, log)
+	inferer := NewInferer(repo, commitRange, layout, log)
 	return &Detect{
 		Repo:     repo,
 		Inferer:  inferer,
 		Registry: registry,
+		Layout:   layout,
 		CR:       commitRange,
 		logger:   log.WithField("module", "Detect"),
 	}, nil
@@ -76,19 +84,14 @@ func (d *Detect) WorkloadChange() ([]WorkloadChange, error) {
 }
 
 // NewClusterWorkloads generates a slice or WorkloadChange to be promoted to new clusters.
-// For development, we look at flux/manifests to figure out what to promote.
-// For test/production, we look into previous environment to figure out all workloads.
-func (d *Detect) NewClusterWorkloads(targetEnv environment.Env, previousEnvClusters clusterconf.Clusters) ([]WorkloadChange, error) {
-	source, err := targetEnv.ManifestSource()
-	if err != nil {
-		return nil, err
+// For the first environment of the pipeline, we look at the source manifests to figure out what to promote.
+// For the following environments, we look into previous environment to figure out all workloads.
+func (d *Detect) NewClusterWorkloads(sourceEnv environment.Env, previousEnvClusters clusterconf.Clusters) ([]WorkloadChange, error) {
+	if sourceEnv == environment.SourceManifest {
+		return d.fromManifestSource(sourceEnv)
 	}
 
-	if targetEnv == environment.Development {
-		return d.fromManifestSource(source)
-	}
-
-	return d.fromClustersInPreviousEnv(source, previousEnvClusters)
+	return d.fromClustersInPreviousEnv(sourceEnv, previousEnvClusters)
 }
 
 func (d *Detect) fromManifestSource(sourceEnv environment.Env) ([]WorkloadChange, error) {
@@ -121,17 +124,13 @@ func (d *Detect) fromClustersInPreviousEnv(sourceEnv environment.Env, previousEn
 
 	promotedWorkloads := make(map[string]struct{})
 	for _, cluster := range previousEnvClusters {
-		fileInfos, err := wt.Filesystem.ReadDir(cluster.ManifestFolder())
+		workloads, err := d.Layout.Workloads(wt.Filesystem, cluster.ManifestFolder())
 		if err != nil {
 			return nil, err
 		}
 
-		for _, fileInfo := range fileInfos {
-			if !fileInfo.IsDir() {
-				continue
-			}
-
-			promotedWorkloads[fileInfo.Name()] = struct{}{}
+		for _, workload := range workloads {
+			promotedWorkloads[workload] = struct{}{}
 		}
 	}
 
@@ -152,6 +151,7 @@ func (d *Detect) fromClustersInPreviousEnv(sourceEnv environment.Env, previousEn
 
 func (d *Detect) processDiffs(diffs object.Changes) (WorkloadChanges, error) {
 	var wc []WorkloadChange
+	cosmeticFiles := map[Workload][]string{}
 
 	for _, gitChange := range diffs {
 		changes, err := d.Inferer.WorkloadChanges(gitChange)
@@ -159,10 +159,58 @@ func (d *Detect) processDiffs(diffs object.Changes) (WorkloadChanges, error) {
 			return nil, err
 		}
 
+		if d.SemanticDiff && len(changes) > 0 && isCosmetic(gitChange) {
+			for _, change := range changes {
+				cosmeticFiles[change.W] = append(cosmeticFiles[change.W], gitChange.To.Name)
+			}
+			continue
+		}
+
 		wc = append(wc, changes...)
 	}
 
-	return Distinct(wc...), nil
+	changes := Distinct(wc...)
+	d.logCosmeticChanges(changes, cosmeticFiles)
+	return changes, nil
+}
+
+// PreviousWorkload returns the configuration of the workload at the start of the commit range, read from
+// the workload.yaml in dir, the directory of the workload in the source environment. It returns false when
+// the directory didn't exist, e.g. the workload was added by the commit range.
+func (d *Detect) PreviousWorkload(workloadID, dir string) (clusterconf.Workload, bool, error) {
+	t, err := d.Inferer.tree(d.CR.FromPrefix)
+	if err != nil {
+		return clusterconf.Workload{}, false, err
+	}
+
+	dir = strings.TrimPrefix(dir, "/")
+	if _, err := t.Tree(dir); err != nil {
+		if errors.Is(err, object.ErrDirectoryNotFound) {
+			return clusterconf.Workload{}, false, nil
+		}
+		return clusterconf.Workload{}, false, fmt.Errorf("t.Tree: %s: %w", dir, err)
+	}
+
+	file := path.Join(dir, clusterconf.WorkloadFile)
+	f, err := t.File(file)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return clusterconf.NewWorkload(workloadID), true, nil
+	}
+	if err != nil {
+		return clusterconf.Workload{}, false, fmt.Errorf("t.File: %s: %w", file, err)
+	}
+
+	reader, err := f.Reader()
+	if err != nil {
+		return clusterconf.Workload{}, false, fmt.Errorf("f.Reader: %s: %w", file, err)
+	}
+	defer reader.Close()
+
+	workload, err := clusterconf.ParseWorkload(workloadID, file, reader)
+	if err != nil {
+		return clusterconf.Workload{}, false, err
+	}
+	return workload, true, nil
 }
 
 func (d *Detect) GetSourceCommits() ([]*github.Commit, error) {
@@ -10,6 +10,7 @@ import (
 	"github.com/form3tech/k8s-promoter/internal/testutils"
 	"github.com/go-git/go-git/v5"
 	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/require"
 )
 
@@ -45,7 +46,7 @@ func TestNew(t *testing.T) {
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
 			l := logrus.NewEntry(logrus.New())
-			got, err := detect.NewDetect(tt.gitRepo, tt.commitRange, dummyWorkloadRegistry{}, l)
+			got, err := detect.NewDetect(tt.gitRepo, tt.commitRange, dummyWorkloadRegistry{}, clusterconf.DefaultLayout(), l)
 			if tt.expectedErr != nil {
 				require.ErrorIs(t, err, tt.expectedErr)
 				require.Empty(t, got)
@@ -708,7 +709,7 @@ func TestDiff(t *testing.T) {
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
 			l := logrus.NewEntry(logrus.New())
-			d, err := detect.NewDetect(tt.TestRepo.Repo, tt.TestRepo.CommitRange(), dummyWorkloadRegistry{}, l)
+			d, err := detect.NewDetect(tt.TestRepo.Repo, tt.TestRepo.CommitRange(), dummyWorkloadRegistry{}, clusterconf.DefaultLayout(), l)
 			require.NoError(t, err)
 
 			got, err := d.WorkloadChange()
@@ -722,6 +723,266 @@ func TestDiff(t *testing.T) {
 	}
 }
 
+func TestDiff_Layout(t *testing.T) {
+	layout := clusterconf.Layout{
+		Spec: clusterconf.LayoutSpec{
+			Source:   "deploy/base/{{.workload}}",
+			Promoted: "deploy/clusters/{{.environment}}/{{.cluster}}/{{.workload}}",
+		},
+	}
+
+	repo := testutils.RepoWith(t,
+		testutils.AddContent(
+			[]testutils.Content{
+				{
+					Path:    "deploy/base/workload1/kustomization.yaml",
+					Content: "some content",
+				},
+				{
+					Path:    "deploy/base/workload2/kustomization.yaml",
+					Content: "some content",
+				},
+			},
+			"initial commit",
+		),
+		testutils.AddContent(
+			[]testutils.Content{
+				{
+					Path:    "deploy/base/workload1/kustomization.yaml",
+					Content: "updated content",
+				},
+				{
+					Path:    "deploy/clusters/development/dev1/workload2/kustomization.yaml",
+					Content: "promoted content",
+				},
+				{
+					Path:    "deploy/clusters/development/dev1/kustomization.yaml",
+					Content: "cluster kustomization",
+				},
+				{
+					Path:    "flux/manifests/workload3/kustomization.yaml",
+					Content: "outside of the layout",
+				},
+			},
+			"update workloads",
+		),
+	)
+
+	d, err := detect.NewDetect(repo.Repo, repo.CommitRange(), dummyWorkloadRegistry{}, layout, logrus.NewEntry(logrus.New()))
+	require.NoError(t, err)
+
+	got, err := d.WorkloadChange()
+	require.NoError(t, err)
+	require.Equal(t, []detect.WorkloadChange{
+		{
+			Op: detect.OperationCopy,
+			W: detect.Workload{
+				SourceEnv: "manifests",
+				Name:      "workload1",
+			},
+		},
+		{
+			Op: detect.OperationCopy,
+			W: detect.Workload{
+				SourceEnv: "development",
+				Name:      "workload2",
+			},
+		},
+	}, got)
+}
+
+func TestDiff_Namespaces(t *testing.T) {
+	layout := clusterconf.DefaultLayout()
+	layout.Spec.WorkloadDepth = 2
+
+	repo := testutils.RepoWith(t,
+		testutils.AddContent(
+			[]testutils.Content{
+				{
+					Path:    "flux/manifests/payments/api/deployment.yaml",
+					Content: "some content",
+				},
+				{
+					Path:    "flux/manifests/payments/ledger/deployment.yaml",
+					Content: "some content",
+				},
+				{
+					Path:    "flux/manifests/tool/workload.yaml",
+					Content: "configType: Workload",
+				},
+				{
+					Path:    "flux/manifests/tool/deployment.yaml",
+					Content: "some content",
+				},
+			},
+			"initial commit",
+		),
+		testutils.AddContent(
+			[]testutils.Content{
+				{
+					Path:    "flux/manifests/payments/api/deployment.yaml",
+					Content: "updated content",
+				},
+				{
+					Path:    "flux/manifests/payments/README.md",
+					Content: "The workloads of the payments team",
+				},
+			},
+			"update payments/api",
+		),
+		testutils.DeleteContent(
+			[]string{
+				"flux/manifests/tool/workload.yaml",
+				"flux/manifests/tool/deployment.yaml",
+			},
+			"remove tool",
+		),
+	)
+
+	d, err := detect.NewDetect(repo.Repo, repo.CommitRange(), dummyWorkloadRegistry{}, layout, logrus.NewEntry(logrus.New()))
+	require.NoError(t, err)
+
+	got, err := d.WorkloadChange()
+	require.NoError(t, err)
+	require.Equal(t, []detect.WorkloadChange{
+		{
+			Op: detect.OperationCopy,
+			W: detect.Workload{
+				SourceEnv: "manifests",
+				Name:      "payments/api",
+			},
+		},
+		{
+			Op: detect.OperationRemove,
+			W: detect.Workload{
+				SourceEnv: "manifests",
+				Name:      "tool",
+			},
+		},
+	}, got)
+}
+
+func TestDiff_SemanticDiff(t *testing.T) {
+	repo := testutils.RepoWith(t,
+		testutils.AddContent(
+			[]testutils.Content{
+				{
+					Path:    "flux/manifests/workload1/deployment.yaml",
+					Content: "# the deployment\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: workload1\n  namespace: default\n",
+				},
+				{
+					Path:    "flux/manifests/workload2/deployment.yaml",
+					Content: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: workload2\n",
+				},
+				{
+					Path:    "flux/manifests/workload3/README.md",
+					Content: "workload3",
+				},
+			},
+			"initial commit",
+		),
+		testutils.AddContent(
+			[]testutils.Content{
+				{
+					Path:    "flux/manifests/workload1/deployment.yaml",
+					Content: "---\n# the workload1 deployment\nkind: Deployment\napiVersion: apps/v1\nmetadata:\n    namespace: default\n    name: workload1\n",
+				},
+				{
+					Path:    "flux/manifests/workload2/deployment.yaml",
+					Content: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: workload2\n  namespace: payments\n",
+				},
+				{
+					Path:    "flux/manifests/workload3/README.md",
+					Content: "workload3 documentation",
+				},
+			},
+			"cosmetic and semantic changes",
+		),
+	)
+
+	logger, hook := test.NewNullLogger()
+	d, err := detect.NewDetect(repo.Repo, repo.CommitRange(), dummyWorkloadRegistry{}, clusterconf.DefaultLayout(), logrus.NewEntry(logger))
+	require.NoError(t, err)
+	d.SemanticDiff = true
+
+	got, err := d.WorkloadChange()
+	require.NoError(t, err)
+	require.Equal(t, []detect.WorkloadChange{
+		{Op: detect.OperationCopy, W: detect.Workload{SourceEnv: "manifests", Name: "workload2"}},
+		{Op: detect.OperationCopy, W: detect.Workload{SourceEnv: "manifests", Name: "workload3"}},
+	}, got)
+
+	require.Len(t, hook.AllEntries(), 1)
+	entry := hook.LastEntry()
+	require.Equal(t, "Skipping cosmetic workload change", entry.Message)
+	require.Equal(t, "workload1", entry.Data["workload"])
+	require.Equal(t, "flux/manifests/workload1/deployment.yaml", entry.Data["files"])
+	require.Equal(t, detect.CosmeticChangeReason, entry.Data["reason"])
+
+	d.SemanticDiff = false
+	got, err = d.WorkloadChange()
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+}
+
+func TestDetect_PreviousWorkload(t *testing.T) {
+	repo := testutils.RepoWith(t,
+		testutils.AddContent(
+			[]testutils.Content{
+				{
+					Path: "flux/manifests/foo/workload.yaml",
+					Content: `version: "v0.1"
+configType: Workload
+metadata:
+  name: foo
+spec:
+  exclusions:
+  - key: cloud
+    operator: NotEqual
+    value: cloud1
+`,
+				},
+				{
+					Path:    "flux/manifests/bar/deployment.yaml",
+					Content: "some content",
+				},
+			},
+			"initial commit",
+		),
+		testutils.DeleteContent(
+			[]string{"flux/manifests/foo/workload.yaml"},
+			"remove the exclusions of foo",
+		),
+		testutils.AddContent(
+			[]testutils.Content{
+				{
+					Path:    "flux/manifests/baz/deployment.yaml",
+					Content: "some content",
+				},
+			},
+			"add baz",
+		),
+	)
+
+	d, err := detect.NewDetect(repo.Repo, repo.CommitRange(), dummyWorkloadRegistry{}, clusterconf.DefaultLayout(), logrus.NewEntry(logrus.New()))
+	require.NoError(t, err)
+
+	foo, existed, err := d.PreviousWorkload("foo", "/flux/manifests/foo")
+	require.NoError(t, err)
+	require.True(t, existed)
+	require.Equal(t, "foo", foo.Name())
+	require.Len(t, foo.Spec.Exclusions, 1)
+
+	bar, existed, err := d.PreviousWorkload("bar", "/flux/manifests/bar")
+	require.NoError(t, err)
+	require.True(t, existed)
+	require.Equal(t, clusterconf.NewWorkload("bar"), bar)
+
+	_, existed, err = d.PreviousWorkload("baz", "/flux/manifests/baz")
+	require.NoError(t, err)
+	require.False(t, existed)
+}
+
 func TestGetSourceCommits(t *testing.T) {
 	tests := map[string]struct {
 		TestRepo *testutils.TestRepo
@@ -837,7 +1098,7 @@ func TestGetSourceCommits(t *testing.T) {
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
 			l := logrus.NewEntry(logrus.New())
-			d, err := detect.NewDetect(tt.TestRepo.Repo, tt.TestRepo.CommitRange(), dummyWorkloadRegistry{}, l)
+			d, err := detect.NewDetect(tt.TestRepo.Repo, tt.TestRepo.CommitRange(), dummyWorkloadRegistry{}, clusterconf.DefaultLayout(), l)
 			require.NoError(t, err)
 			got, err := d.GetSourceCommits()
 
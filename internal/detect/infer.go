@@ -3,36 +3,36 @@ package detect
 import (
 	"errors"
 	"fmt"
+	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/form3tech/k8s-promoter/internal/clusterconf"
+	gitint "github.com/form3tech/k8s-promoter/internal/git"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/sirupsen/logrus"
 )
 
-const (
-	sourceManifestDirLevel = 4
-	promotedDirLevel       = 7
-
-	fluxDir           = "flux"
-	sourceManifestDir = "manifests"
-	promotedDir       = "promoted"
-)
-
 var ErrNotWorkloadManifest = fmt.Errorf("not a workload manifest")
 
 type Inferer struct {
 	repo         *git.Repository
+	fromPrefix   string
 	commitPrefix string
+	layout       clusterconf.Layout
+	trees        map[string]*object.Tree
 	logger       *logrus.Entry
 }
 
-func NewInferer(repo *git.Repository, commitPrefix string, log *logrus.Entry) *Inferer {
+func NewInferer(repo *git.Repository, commitRange *gitint.CommitRange, layout clusterconf.Layout, log *logrus.Entry) *Inferer {
 	return &Inferer{
 		repo:         repo,
-		commitPrefix: commitPrefix,
+		fromPrefix:   commitRange.FromPrefix,
+		commitPrefix: commitRange.ToPrefix,
+		layout:       layout,
+		trees:        map[string]*object.Tree{},
 		logger:       log.WithField("module", "Inferer"),
 	}
 }
@@ -105,7 +105,7 @@ func (w *Inferer) deletions(change *object.Change) ([]WorkloadChange, error) {
 		}
 
 		// We only deduce a deletion if the workload doesn't exist anymore in either
-		// flux/manifests as well as the source environment (if it is a promoted environment)
+		// the source manifests as well as the source environment (if it is a promoted environment)
 		var op Operation = OperationCopy
 		exists, err := w.workloadExists(workload)
 		if err != nil {
@@ -206,7 +206,7 @@ func isRename(c *object.Change) bool {
 }
 
 func (w *Inferer) workloadExists(workload Workload) (bool, error) {
-	exists, err := w.workloadExistsEnv(workload.Name, sourceManifestDir)
+	exists, err := w.sourceWorkloadExists(workload.Name)
 	if err != nil {
 		return false, err
 	}
@@ -218,23 +218,13 @@ func (w *Inferer) workloadExists(workload Workload) (bool, error) {
 	return true, nil
 }
 
-func (w *Inferer) workloadExistsEnv(workloadName, env string) (bool, error) {
-	to, err := w.repo.ResolveRevision(plumbing.Revision(w.commitPrefix))
-	if err != nil {
-		return false, fmt.Errorf("d.Repo.ResolveRevision: %w", err)
-	}
-
-	commit, err := w.repo.CommitObject(*to)
-	if err != nil {
-		return false, fmt.Errorf("d.Repo.CommitObject: %w", err)
-	}
-
-	t, err := commit.Tree()
+func (w *Inferer) sourceWorkloadExists(workloadName string) (bool, error) {
+	t, err := w.tree(w.commitPrefix)
 	if err != nil {
-		return false, fmt.Errorf("commit.Tree: %w", err)
+		return false, err
 	}
 
-	path := filepath.Join(fluxDir, env, workloadName)
+	path := strings.TrimPrefix(w.layout.SourcePath(workloadName), "/")
 	_, err = t.Tree(path)
 	if errors.Is(err, object.ErrDirectoryNotFound) {
 		return false, nil
@@ -246,49 +236,67 @@ func (w *Inferer) workloadExistsEnv(workloadName, env string) (bool, error) {
 	return true, nil
 }
 
-func (w *Inferer) workload(path string) (Workload, error) {
-	if strings.HasPrefix(path, filepath.Join(fluxDir, sourceManifestDir)) {
-		return w.inferSourceManifestWorkload(path)
+// tree returns the tree of the commit, resolved once.
+func (w *Inferer) tree(commitPrefix string) (*object.Tree, error) {
+	if t, ok := w.trees[commitPrefix]; ok {
+		return t, nil
 	}
 
-	if strings.HasPrefix(path, filepath.Join(fluxDir, promotedDir)) {
-		return w.inferPromotedWorkload(path)
+	hash, err := w.repo.ResolveRevision(plumbing.Revision(commitPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("d.Repo.ResolveRevision: %w", err)
 	}
 
-	return Workload{}, fmt.Errorf("%s: %w", path, ErrNotWorkloadManifest)
-}
+	commit, err := w.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("d.Repo.CommitObject: %w", err)
+	}
 
-// directory path convention is the following
-// flux/manifests/workload/asset.yaml.
-func (w *Inferer) inferSourceManifestWorkload(path string) (Workload, error) {
-	split := strings.Split(path, "/")
-	if len(split) < sourceManifestDirLevel {
-		return Workload{}, fmt.Errorf("path: %s: %w", path, ErrUnknownPathConvention)
+	t, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("commit.Tree: %w", err)
 	}
 
-	return Workload{
-		SourceEnv: split[1],
-		Name:      split[2],
-	}, nil
+	w.trees[commitPrefix] = t
+	return t, nil
+}
+
+// hasWorkloadFile reports whether the directory contains a workload.yaml at either end of the commit
+// range, so that the workloads removed by the range are found too.
+func (w *Inferer) hasWorkloadFile(dir string) bool {
+	for _, commitPrefix := range []string{w.fromPrefix, w.commitPrefix} {
+		t, err := w.tree(commitPrefix)
+		if err != nil {
+			w.logger.WithError(err).Warn("Could not look for workload.yaml")
+			continue
+		}
+
+		if _, err := t.File(path.Join(dir, clusterconf.WorkloadFile)); err == nil {
+			return true
+		}
+	}
+	return false
 }
 
-// directory path convention for the promoted workloads is the following
-// flux/promoted/environment/cluster/cloud/workload/asset.yaml
-// we ignore changes that don't fall within a workload, such as cluster level kustomizations as they are generated
+// workload infers the workload of the path according to the repository layout, e.g.
+// flux/manifests/workload/asset.yaml or flux/promoted/environment/cluster/cloud/workload/asset.yaml,
+// or flux/manifests/namespace/workload/asset.yaml for the workloads of namespaces.
+// We ignore changes that don't fall within a workload, such as cluster level kustomizations as they are generated
 // flux/promoted/development/dev1/cloud1/kustomization.yaml
 // if we change the format, we would have to no-op push a promotion until we have a way to signal such a workflow.
-func (w *Inferer) inferPromotedWorkload(path string) (Workload, error) {
-	split := strings.Split(path, "/")
-
-	if len(split) < promotedDirLevel {
-		return Workload{}, fmt.Errorf("path: %s: %w", path, ErrNotWorkloadManifest)
+func (w *Inferer) workload(path string) (Workload, error) {
+	location, ok := w.layout.Parse(path, w.hasWorkloadFile)
+	if ok {
+		return Workload{
+			SourceEnv: string(location.Environment),
+			Name:      location.Workload,
+		}, nil
 	}
 
-	env := split[2]
-	name := split[5]
+	// files of namespaces are ignored, but the source directory only contains workloads
+	if filepath.Join("/", filepath.Dir(path)) == w.layout.SourceDir() {
+		return Workload{}, fmt.Errorf("path: %s: %w", path, ErrUnknownPathConvention)
+	}
 
-	return Workload{
-		SourceEnv: env,
-		Name:      name,
-	}, nil
+	return Workload{}, fmt.Errorf("%s: %w", path, ErrNotWorkloadManifest)
 }
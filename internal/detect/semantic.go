@@ -0,0 +1,103 @@
+package detect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// CosmeticChangeReason is why changes which are dropped by the semantic comparison aren't promoted.
+const CosmeticChangeReason = "only comments, whitespace or key order changed"
+
+// isCosmetic reports whether the change modifies a YAML file without changing the objects it declares,
+// e.g. it only edits comments, whitespace or the order of keys. Files which can't be parsed are
+// never cosmetic.
+func isCosmetic(change *object.Change) bool {
+	if !isModification(change) || !isYAML(change.To.Name) {
+		return false
+	}
+
+	from, to, err := change.Files()
+	if err != nil || from == nil || to == nil {
+		return false
+	}
+
+	fromDocs, err := yamlDocuments(from)
+	if err != nil {
+		return false
+	}
+
+	toDocs, err := yamlDocuments(to)
+	if err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(fromDocs, toDocs)
+}
+
+// yamlDocuments decodes the non-empty documents of a multi-document YAML file.
+func yamlDocuments(f *object.File) ([]interface{}, error) {
+	reader, err := f.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var docs []interface{}
+	decoder := yaml.NewDecoder(reader)
+	for {
+		var doc interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+func isYAML(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// logCosmeticChanges logs the workloads which only had cosmetic changes, i.e. which aren't in changes.
+func (d *Detect) logCosmeticChanges(changes WorkloadChanges, cosmeticFiles map[Workload][]string) {
+	changed := map[Workload]bool{}
+	for _, change := range changes {
+		changed[change.W] = true
+	}
+
+	var dropped []Workload
+	for workload := range cosmeticFiles {
+		if !changed[workload] {
+			dropped = append(dropped, workload)
+		}
+	}
+	sort.Slice(dropped, func(i, j int) bool {
+		if dropped[i].SourceEnv != dropped[j].SourceEnv {
+			return dropped[i].SourceEnv < dropped[j].SourceEnv
+		}
+		return dropped[i].Name < dropped[j].Name
+	})
+
+	for _, workload := range dropped {
+		d.logger.WithFields(logrus.Fields{
+			"workload":   workload.Name,
+			"source_env": workload.SourceEnv,
+			"files":      strings.Join(cosmeticFiles[workload], ", "),
+			"reason":     CosmeticChangeReason,
+		}).Info("Skipping cosmetic workload change")
+	}
+}
@@ -15,6 +15,9 @@ const (
 type WorkloadChange struct {
 	Op Operation
 	W  Workload
+	// Targeting tells that the change follows a change of the clusters the workload is allowed in, rather
+	// than of its manifests, e.g. the workload is removed from a cluster which was just excluded.
+	Targeting bool
 }
 
 type (
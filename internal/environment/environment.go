@@ -2,6 +2,7 @@ package environment
 
 import (
 	"fmt"
+	"strings"
 )
 
 const (
@@ -15,26 +16,121 @@ const (
 	Production  Env = "production"
 )
 
-var ErrUnknownEnvironment = fmt.Errorf("unknown environment")
+var (
+	ErrUnknownEnvironment = fmt.Errorf("unknown environment")
+	ErrInvalidPipeline    = fmt.Errorf("invalid pipeline")
+)
 
 type Env string
 
-func (e Env) Validate() error {
-	if e != Development && e != Test && e != Production {
-		return fmt.Errorf("env '%s' is not one of %s, %s, %s", e, Development, Test, Production)
+// Stage is a single step of a pipeline: workloads in Target are promoted from Source.
+type Stage struct {
+	Source Env
+	Target Env
+}
+
+// Pipeline describes the order in which workloads are promoted between environments.
+// Every environment has exactly one source, which is either SourceManifest or another
+// environment of the pipeline.
+type Pipeline struct {
+	sources map[Env]Env
+	order   []Env
+}
+
+// DefaultPipeline returns the manifests -> development -> test -> production chain.
+func DefaultPipeline() Pipeline {
+	p, err := NewPipeline(Chain(Development, Test, Production)...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Chain returns the stages of an ordered list of environments, where each environment
+// is promoted from the one preceding it and the first one from SourceManifest.
+func Chain(envs ...Env) []Stage {
+	var stages []Stage
+	source := SourceManifest
+	for _, env := range envs {
+		stages = append(stages, Stage{Source: source, Target: env})
+		source = env
+	}
+	return stages
+}
+
+func NewPipeline(stages ...Stage) (Pipeline, error) {
+	p := Pipeline{sources: make(map[Env]Env, len(stages))}
+	if len(stages) == 0 {
+		return Pipeline{}, fmt.Errorf("%w: no environments declared", ErrInvalidPipeline)
+	}
+
+	for _, stage := range stages {
+		if stage.Target == "" || stage.Target == SourceManifest || stage.Target == None {
+			return Pipeline{}, fmt.Errorf("%w: '%s' is not a valid environment name", ErrInvalidPipeline, stage.Target)
+		}
+		if _, ok := p.sources[stage.Target]; ok {
+			return Pipeline{}, fmt.Errorf("%w: environment '%s' declared more than once", ErrInvalidPipeline, stage.Target)
+		}
+		p.sources[stage.Target] = stage.Source
+		p.order = append(p.order, stage.Target)
+	}
+
+	for _, env := range p.order {
+		if err := p.checkPathToManifests(env); err != nil {
+			return Pipeline{}, err
+		}
+	}
+
+	return p, nil
+}
+
+// checkPathToManifests follows the sources of env until it reaches SourceManifest.
+func (p Pipeline) checkPathToManifests(env Env) error {
+	seen := map[Env]bool{}
+	for current := env; current != SourceManifest; current = p.sources[current] {
+		if seen[current] {
+			return fmt.Errorf("%w: environment '%s' is part of a cycle", ErrInvalidPipeline, env)
+		}
+		seen[current] = true
+
+		if _, ok := p.sources[current]; !ok {
+			return fmt.Errorf("%w: environment '%s' has unknown source '%s'", ErrInvalidPipeline, env, current)
+		}
 	}
 	return nil
 }
 
-func (e Env) ManifestSource() (Env, error) {
-	switch e {
-	case Production:
-		return Test, nil
-	case Test:
-		return Development, nil
-	case Development:
-		return SourceManifest, nil
-	default:
+// Environments returns the environments of the pipeline in declaration order.
+func (p Pipeline) Environments() []Env {
+	return append([]Env(nil), p.order...)
+}
+
+func (p Pipeline) Validate(e Env) error {
+	if _, ok := p.sources[e]; !ok {
+		return fmt.Errorf("env '%s' is not one of %s", e, p)
+	}
+	return nil
+}
+
+// ManifestSource returns the environment workloads are promoted from when promoting to e.
+func (p Pipeline) ManifestSource(e Env) (Env, error) {
+	source, ok := p.sources[e]
+	if !ok {
 		return "", fmt.Errorf("%s: %w", e, ErrUnknownEnvironment)
 	}
+	return source, nil
+}
+
+// IsFirst reports whether e is promoted straight from SourceManifest.
+func (p Pipeline) IsFirst(e Env) bool {
+	source, err := p.ManifestSource(e)
+	return err == nil && source == SourceManifest
+}
+
+func (p Pipeline) String() string {
+	names := make([]string, 0, len(p.order))
+	for _, env := range p.order {
+		names = append(names, string(env))
+	}
+	return strings.Join(names, ", ")
 }
@@ -0,0 +1,117 @@
+package environment_test
+
+import (
+	"testing"
+
+	"github.com/form3tech/k8s-promoter/internal/environment"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline_ManifestSource(t *testing.T) {
+	tests := map[string]struct {
+		stages   []environment.Stage
+		target   environment.Env
+		expected environment.Env
+	}{
+		"default pipeline development": {
+			stages:   environment.Chain(environment.Development, environment.Test, environment.Production),
+			target:   environment.Development,
+			expected: environment.SourceManifest,
+		},
+		"default pipeline production": {
+			stages:   environment.Chain(environment.Development, environment.Test, environment.Production),
+			target:   environment.Production,
+			expected: environment.Test,
+		},
+		"custom chain with staging": {
+			stages:   environment.Chain("sandbox", environment.Development, "staging", environment.Production),
+			target:   environment.Production,
+			expected: "staging",
+		},
+		"explicit stages": {
+			stages: []environment.Stage{
+				{Source: environment.SourceManifest, Target: environment.Development},
+				{Source: environment.Development, Target: "sandbox"},
+				{Source: environment.Development, Target: environment.Test},
+			},
+			target:   "sandbox",
+			expected: environment.Development,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := environment.NewPipeline(tt.stages...)
+			require.NoError(t, err)
+
+			got, err := p.ManifestSource(tt.target)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestPipeline_Validate(t *testing.T) {
+	p := environment.DefaultPipeline()
+
+	require.NoError(t, p.Validate(environment.Test))
+	require.EqualError(t, p.Validate("staging"), "env 'staging' is not one of development, test, production")
+
+	_, err := p.ManifestSource("staging")
+	require.ErrorIs(t, err, environment.ErrUnknownEnvironment)
+}
+
+func TestPipeline_IsFirst(t *testing.T) {
+	p, err := environment.NewPipeline(environment.Chain("sandbox", environment.Development)...)
+	require.NoError(t, err)
+
+	require.True(t, p.IsFirst("sandbox"))
+	require.False(t, p.IsFirst(environment.Development))
+	require.False(t, p.IsFirst(environment.Production))
+}
+
+func TestNewPipeline_Errors(t *testing.T) {
+	tests := map[string]struct {
+		stages []environment.Stage
+		err    string
+	}{
+		"no stages": {
+			stages: nil,
+			err:    "invalid pipeline: no environments declared",
+		},
+		"duplicate environment": {
+			stages: []environment.Stage{
+				{Source: environment.SourceManifest, Target: environment.Development},
+				{Source: environment.SourceManifest, Target: environment.Development},
+			},
+			err: "invalid pipeline: environment 'development' declared more than once",
+		},
+		"unknown source": {
+			stages: []environment.Stage{
+				{Source: "staging", Target: environment.Production},
+			},
+			err: "invalid pipeline: environment 'production' has unknown source 'staging'",
+		},
+		"cycle": {
+			stages: []environment.Stage{
+				{Source: environment.Test, Target: environment.Development},
+				{Source: environment.Development, Target: environment.Test},
+			},
+			err: "invalid pipeline: environment 'development' is part of a cycle",
+		},
+		"manifests as target": {
+			stages: []environment.Stage{
+				{Source: environment.SourceManifest, Target: environment.SourceManifest},
+			},
+			err: "invalid pipeline: 'manifests' is not a valid environment name",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := environment.NewPipeline(tt.stages...)
+			require.ErrorIs(t, err, environment.ErrInvalidPipeline)
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package filesystem
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -11,6 +12,7 @@ import (
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/util"
 	"golang.org/x/mod/sumdb/dirhash"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -75,6 +77,14 @@ func Replace(fs billy.Filesystem, srcDir, targetDir string) error {
 	})
 }
 
+// Move moves the files of srcDir to targetDir, replacing its content, and removes srcDir.
+func Move(fs billy.Filesystem, srcDir, targetDir string) error {
+	if err := Replace(fs, srcDir, targetDir); err != nil {
+		return err
+	}
+	return util.RemoveAll(fs, srcDir)
+}
+
 type fileWalker func(filePath string) error
 
 func WalkFiles(fs billy.Filesystem, base string, walker fileWalker) error {
@@ -118,6 +128,64 @@ func DirHash(fs billy.Filesystem, dir string) (string, error) {
 	})
 }
 
+// SemanticDirHash hashes the files in `dir` like DirHash, except that YAML files are hashed by the documents
+// they declare, so that comments, whitespace or key order don't change the hash. YAML files which can't be
+// parsed are hashed by their content.
+func SemanticDirHash(fs billy.Filesystem, dir string) (string, error) {
+	files, err := recursiveFilesInDir(fs, dir)
+	if err != nil {
+		return "", err
+	}
+
+	var relativeFilePaths []string
+	for _, file := range files {
+		relativeFilePaths = append(relativeFilePaths, strings.TrimPrefix(file, dir))
+	}
+
+	return dirhash.Hash1(relativeFilePaths, func(s string) (io.ReadCloser, error) {
+		content, err := util.ReadFile(fs, filepath.Join(dir, s))
+		if err != nil {
+			return nil, err
+		}
+
+		if ext := filepath.Ext(s); ext == ".yaml" || ext == ".yml" {
+			if normalised, err := normaliseYAML(content); err == nil {
+				content = normalised
+			}
+		}
+		return io.NopCloser(bytes.NewReader(content)), nil
+	})
+}
+
+// normaliseYAML re-encodes the non-empty documents of a multi-document YAML file, dropping comments and
+// formatting, with the keys of the mappings sorted.
+func normaliseYAML(content []byte) ([]byte, error) {
+	var normalised bytes.Buffer
+	encoder := yaml.NewEncoder(&normalised)
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if doc == nil {
+			continue
+		}
+		if err := encoder.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return normalised.Bytes(), nil
+}
+
 // recursiveFilesInDir returns all files in the file tree under dir.
 func recursiveFilesInDir(fs billy.Filesystem, dir string) ([]string, error) {
 	var files []string
@@ -2,6 +2,7 @@ package filesystem_test
 
 import (
 	"errors"
+	"os"
 	"testing"
 
 	"github.com/form3tech/k8s-promoter/internal/filesystem"
@@ -157,3 +158,47 @@ func TestCopyFilesystem(t *testing.T) {
 		testutils.AssertFilesystemsAreEqual(t, expectedFS, targetFS)
 	})
 }
+
+func Test_Move(t *testing.T) {
+	fs := memfs.New()
+
+	testutils.WriteFile(t, fs, "/src/file", "content")
+	testutils.WriteFile(t, fs, "/src/subdir/file", "sub-content")
+	testutils.WriteFile(t, fs, "/target/old-file", "old-content")
+
+	err := filesystem.Move(fs, "/src/", "/target/")
+	require.NoError(t, err)
+
+	testutils.FileHasContents(t, fs, "/target/file", "content")
+	testutils.FileHasContents(t, fs, "/target/subdir/file", "sub-content")
+	_, err = fs.Stat("/target/old-file")
+	assert.True(t, os.IsNotExist(err))
+	_, err = fs.Stat("/src")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_SemanticDirHash(t *testing.T) {
+	fs := memfs.New()
+	testutils.WriteFile(t, fs, "/a/deployment.yaml", "kind: Deployment\nmetadata:\n  name: foo\n")
+	testutils.WriteFile(t, fs, "/a/file", "content")
+	testutils.WriteFile(t, fs, "/b/deployment.yaml", "# the foo deployment\nmetadata: {name: foo}\nkind: Deployment\n")
+	testutils.WriteFile(t, fs, "/b/file", "content")
+	testutils.WriteFile(t, fs, "/c/deployment.yaml", "kind: Deployment\nmetadata:\n  name: bar\n")
+	testutils.WriteFile(t, fs, "/c/file", "content")
+
+	a, err := filesystem.SemanticDirHash(fs, "/a")
+	require.NoError(t, err)
+	b, err := filesystem.SemanticDirHash(fs, "/b")
+	require.NoError(t, err)
+	c, err := filesystem.SemanticDirHash(fs, "/c")
+	require.NoError(t, err)
+
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+
+	exactA, err := filesystem.DirHash(fs, "/a")
+	require.NoError(t, err)
+	exactB, err := filesystem.DirHash(fs, "/b")
+	require.NoError(t, err)
+	assert.NotEqual(t, exactA, exactB)
+}
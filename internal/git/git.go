@@ -19,7 +19,7 @@ type CloneArgs struct {
 	BaseURL string
 	Owner   string
 	Repo    string
-	Branch  string
+	Branch  string // The default branch of the repository is cloned when empty
 	Ref     string
 }
 
@@ -34,12 +34,14 @@ func Clone(ctx context.Context, args *CloneArgs) (*git.Repository, error) {
 
 	storage := gitfilesystem.NewStorage(memfs.New(), cache.NewObjectLRUDefault())
 	opts := &git.CloneOptions{
-		Auth:          args.Auth,
-		URL:           args.RepoURL(),
-		RemoteName:    "origin",
-		ReferenceName: plumbing.NewBranchReferenceName(args.Branch),
-		SingleBranch:  false,
-		Depth:         0, // Deep clone the repository. See https://github.com/form3tech/k8s-promoter/issues/3
+		Auth:         args.Auth,
+		URL:          args.RepoURL(),
+		RemoteName:   "origin",
+		SingleBranch: false,
+		Depth:        0, // Deep clone the repository. See https://github.com/form3tech/k8s-promoter/issues/3
+	}
+	if args.Branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(args.Branch)
 	}
 	repo, err := git.CloneContext(ctx, storage, memfs.New(), opts)
 	if err != nil {
@@ -51,8 +53,7 @@ func Clone(ctx context.Context, args *CloneArgs) (*git.Repository, error) {
 		return nil, fmt.Errorf("repo.Worktree: %w", err)
 	}
 
-	revision := plumbing.Revision(args.Ref)
-	hash, err := repo.ResolveRevision(revision)
+	hash, err := resolveRef(repo, args.Ref)
 	if err != nil {
 		return nil, fmt.Errorf("repo.ResolveRevision: %w", err)
 	}
@@ -69,3 +70,17 @@ func Clone(ctx context.Context, args *CloneArgs) (*git.Repository, error) {
 
 	return repo, nil
 }
+
+// resolveRef resolves ref as a revision, falling back to the remote branch of that name, as only
+// the branch checked out by the clone exists locally.
+func resolveRef(repo *git.Repository, ref string) (*plumbing.Hash, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err == nil {
+		return hash, nil
+	}
+
+	if remoteHash, remoteErr := repo.ResolveRevision(plumbing.Revision("origin/" + ref)); remoteErr == nil {
+		return remoteHash, nil
+	}
+	return nil, err
+}
@@ -89,6 +89,33 @@ func Test_CloneWorksWhenAuthIsValid(t *testing.T) {
 	require.Equal(t, string(bytes), "content")
 }
 
+func Test_CloneDefaultBranchWhenBranchIsEmpty(t *testing.T) {
+	tRepo := setupTestRepo(t)
+	owner := "form3tech"
+	repoName := "k8s-promoter"
+
+	auth := &http.BasicAuth{
+		Username: "username",
+		Password: "password",
+	}
+
+	ts := setupFakeGit(t, tRepo, owner, repoName, auth)
+
+	repo, err := gitint.Clone(context.Background(), &gitint.CloneArgs{
+		Auth:    auth,
+		BaseURL: ts.URL,
+		Owner:   owner,
+		Repo:    repoName,
+		Ref:     "master",
+	})
+
+	require.NoError(t, err)
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+	require.Equal(t, tRepo.c[0], head.Hash())
+}
+
 func Test_ReturnsErrorWhenURLDoesNotExist(t *testing.T) {
 	tRepo := setupTestRepo(t)
 	owner := "form3tech"
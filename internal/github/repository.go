@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
@@ -14,6 +16,7 @@ import (
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/google/go-github/v33/github"
 	"github.com/sirupsen/logrus"
@@ -64,6 +67,14 @@ type PromotionPullRequest struct {
 	Title         string
 	Description   string
 	CommitMessage string
+
+	// Labels are added to the pull request besides the label of all automated promotions.
+	Labels []string
+
+	// Reviewers are requested to review the pull request, and assigned to it along with the assignees.
+	Reviewers []string
+	// TeamReviewers are the slugs of the teams requested to review the pull request.
+	TeamReviewers []string
 }
 
 func WithGitAuth(auth *githttp.BasicAuth) RepositoryOption {
@@ -174,6 +185,11 @@ func (r *ManifestRepository) NewPromoteBranch() (string, error) {
 		return "", fmt.Errorf("checkout new branch: %w", err)
 	}
 
+	// a promotion which failed half way may have left new files behind
+	if err := wt.Clean(&git.CleanOptions{Dir: true}); err != nil {
+		return "", fmt.Errorf("clean worktree: %w", err)
+	}
+
 	return branchName, nil
 }
 
@@ -213,6 +229,104 @@ func (r *ManifestRepository) Commit(msg string) error {
 	return nil
 }
 
+// LastChanged returns the time of the most recent commit of the target ref which changed anything under dir.
+// The zero time is returned when dir was never changed.
+func (r *ManifestRepository) LastChanged(dir string) (time.Time, error) {
+	from, err := r.repo.ResolveRevision(plumbing.Revision(r.githubRepositoryConfig.TargetRef))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("resolve revision: %w", err)
+	}
+
+	prefix := strings.Trim(dir, "/") + "/"
+	commits, err := r.repo.Log(&git.LogOptions{
+		From: *from,
+		PathFilter: func(path string) bool {
+			return strings.HasPrefix(path, prefix)
+		},
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("repo.Log: %w", err)
+	}
+	defer commits.Close()
+
+	commit, err := commits.Next()
+	if errors.Is(err, io.EOF) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("commits.Next: %w", err)
+	}
+
+	return commit.Committer.When, nil
+}
+
+// HadContentOf reports whether dir, in the target ref or an earlier commit of it, had the content sourceDir
+// has in the target ref. In other words, whether dir is at the version of sourceDir or at a newer one.
+func (r *ManifestRepository) HadContentOf(dir, sourceDir string) (bool, error) {
+	from, err := r.repo.ResolveRevision(plumbing.Revision(r.githubRepositoryConfig.TargetRef))
+	if err != nil {
+		return false, fmt.Errorf("resolve revision: %w", err)
+	}
+
+	head, err := r.repo.CommitObject(*from)
+	if err != nil {
+		return false, fmt.Errorf("repo.CommitObject: %w", err)
+	}
+
+	sourceHash, ok, err := dirTreeHash(head, sourceDir)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	prefix := strings.Trim(dir, "/") + "/"
+	commits, err := r.repo.Log(&git.LogOptions{
+		From: *from,
+		PathFilter: func(path string) bool {
+			return strings.HasPrefix(path, prefix)
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("repo.Log: %w", err)
+	}
+	defer commits.Close()
+
+	found := false
+	err = commits.ForEach(func(commit *object.Commit) error {
+		hash, ok, err := dirTreeHash(commit, dir)
+		if err != nil {
+			return err
+		}
+		if ok && hash == sourceHash {
+			found = true
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("commits.ForEach: %w", err)
+	}
+
+	return found, nil
+}
+
+// dirTreeHash returns the hash of the tree of dir in the commit, identical for directories with identical content.
+func dirTreeHash(commit *object.Commit, dir string) (plumbing.Hash, bool, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, false, fmt.Errorf("commit.Tree: %w", err)
+	}
+
+	dirTree, err := tree.Tree(strings.Trim(dir, "/"))
+	if errors.Is(err, object.ErrDirectoryNotFound) {
+		return plumbing.ZeroHash, false, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, false, fmt.Errorf("tree.Tree: %s: %w", dir, err)
+	}
+
+	return dirTree.Hash, true, nil
+}
+
 func (r *ManifestRepository) GetCommits(ctx context.Context, base string, head string) ([]*Commit, error) {
 	commits := make([]*Commit, 0)
 
@@ -252,12 +366,20 @@ func (r *ManifestRepository) GetCommits(ctx context.Context, base string, head s
 }
 
 func (r *ManifestRepository) GetPullRequestAssignees(ctx context.Context, sourceCommits []*Commit) ([]string, error) {
+	var users []string
+	for _, sourceCommit := range sourceCommits {
+		users = append(users, sourceCommit.AuthorLogin, sourceCommit.CommitterLogin)
+	}
+	return r.Assignees(ctx, users)
+}
+
+// Assignees returns the users which can be assigned to the pull requests, capped at the GitHub limit.
+func (r *ManifestRepository) Assignees(ctx context.Context, users []string) ([]string, error) {
 	assignees := make([]string, 0)
 
 	assigneeMap := map[string]bool{}
-	for _, sourceCommit := range sourceCommits {
-		assigneeMap[sourceCommit.AuthorLogin] = true
-		assigneeMap[sourceCommit.CommitterLogin] = true
+	for _, user := range users {
+		assigneeMap[user] = true
 	}
 	for assignee := range assigneeMap {
 		ok, err := r.isAssignee(ctx, assignee)
@@ -305,7 +427,13 @@ func (r *ManifestRepository) RaisePromotion(ctx context.Context, branchName stri
 		return fmt.Errorf("push to origin: %w", err)
 	}
 
-	_, err = r.raisePullRequest(ctx, branchName, pr, assingees)
+	reviewers, err := r.reviewers(ctx, pr.Reviewers)
+	if err != nil {
+		return err
+	}
+	pr.TeamReviewers = r.teamReviewers(ctx, pr.TeamReviewers)
+
+	_, err = r.raisePullRequest(ctx, branchName, pr, mergeAssignees(r.logger, assingees, reviewers), reviewers)
 	if err != nil {
 		return err
 	}
@@ -313,7 +441,72 @@ func (r *ManifestRepository) RaisePromotion(ctx context.Context, branchName stri
 	return nil
 }
 
-func (r *ManifestRepository) raisePullRequest(ctx context.Context, branchName string, promotionPR PromotionPullRequest, assignees []string) (*github.PullRequest, error) {
+// reviewers returns the users which can review the pull requests: like assignees, but excluding the user raising them.
+func (r *ManifestRepository) reviewers(ctx context.Context, users []string) ([]string, error) {
+	var reviewers []string
+	for _, user := range users {
+		if r.auth != nil && user == r.auth.Username {
+			continue
+		}
+
+		ok, err := r.isAssignee(ctx, user)
+		if err != nil {
+			return nil, fmt.Errorf("isAssignee: %w", err)
+		}
+		if ok {
+			reviewers = append(reviewers, user)
+		}
+	}
+	return reviewers, nil
+}
+
+// teamReviewers returns the teams which can review the pull requests, i.e. which have access to the repository,
+// capped at the GitHub limit. The teams which can't be checked, e.g. unknown ones, are left out rather than
+// failing the promotion.
+func (r *ManifestRepository) teamReviewers(ctx context.Context, teams []string) []string {
+	var reviewers []string
+	for _, team := range teams {
+		r.sleep()
+		_, _, err := r.client.Teams.IsTeamRepoBySlug(ctx, r.githubRepositoryConfig.Owner, team, r.githubRepositoryConfig.Owner, r.githubRepositoryConfig.Repository)
+		if err != nil {
+			r.logger.WithError(err).WithField("team", team).Warn("Not requesting a review from a team without access to the repository")
+			continue
+		}
+
+		if len(reviewers) == maxAssignees {
+			r.logger.Warnf("capping PR team reviewers at %d due to GitHub limits", maxAssignees)
+			break
+		}
+		reviewers = append(reviewers, team)
+	}
+	return reviewers
+}
+
+// mergeAssignees adds the reviewers which aren't assigned yet to the assignees, capping them at the GitHub limit.
+func mergeAssignees(logger *logrus.Entry, assignees []string, reviewers []string) []string {
+	merged := append([]string{}, assignees...)
+	assigned := map[string]bool{}
+	for _, assignee := range assignees {
+		assigned[assignee] = true
+	}
+
+	for _, reviewer := range reviewers {
+		if assigned[reviewer] {
+			continue
+		}
+		if len(merged) >= maxAssignees {
+			logger.Warnf("capping PR assignees at %d due to GitHub limits", maxAssignees)
+			break
+		}
+		merged = append(merged, reviewer)
+		assigned[reviewer] = true
+	}
+
+	sort.Strings(merged)
+	return merged
+}
+
+func (r *ManifestRepository) raisePullRequest(ctx context.Context, branchName string, promotionPR PromotionPullRequest, assignees []string, reviewers []string) (*github.PullRequest, error) {
 	logger := r.logger.
 		WithFields(logrus.Fields{
 			"branch": branchName,
@@ -342,7 +535,7 @@ func (r *ManifestRepository) raisePullRequest(ctx context.Context, branchName st
 		Infof("Pull request %d raised", pr.GetNumber())
 
 	r.sleep()
-	labels := []string{prLabel}
+	labels := append([]string{prLabel}, promotionPR.Labels...)
 	_, _, err = r.client.Issues.AddLabelsToIssue(ctx, r.githubRepositoryConfig.Owner, r.githubRepositoryConfig.Repository, pr.GetNumber(), labels)
 	if err != nil {
 		return nil, fmt.Errorf("failed to add labels to PR: %w", err)
@@ -354,6 +547,19 @@ func (r *ManifestRepository) raisePullRequest(ctx context.Context, branchName st
 		return nil, fmt.Errorf("failed to add assignees to PR: %w", err)
 	}
 
+	if len(reviewers) == 0 && len(promotionPR.TeamReviewers) == 0 {
+		return pr, nil
+	}
+
+	r.sleep()
+	_, _, err = r.client.PullRequests.RequestReviewers(ctx, r.githubRepositoryConfig.Owner, r.githubRepositoryConfig.Repository, pr.GetNumber(), github.ReviewersRequest{
+		Reviewers:     reviewers,
+		TeamReviewers: promotionPR.TeamReviewers,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request reviewers of PR: %w", err)
+	}
+
 	return pr, nil
 }
 
@@ -1,6 +1,7 @@
 package github
 
 import (
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/object"
 	gitfilesystem "github.com/go-git/go-git/v5/storage/filesystem"
 	"github.com/google/go-github/v33/github"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 )
 
@@ -72,6 +74,83 @@ func Test_Commits_Are_From_Correct_User(t *testing.T) {
 	require.Equal(t, testCommitterEmail, testCommit.Committer.Email)
 }
 
+func Test_LastChanged(t *testing.T) {
+	mr := setupManifestRepository(t)
+	tree, err := mr.repo.Worktree()
+	require.NoError(t, err)
+
+	commitAt := func(path string, when time.Time) plumbing.Hash {
+		testutils.WriteFile(t, tree.Filesystem, path, path)
+		require.NoError(t, tree.AddGlob("*"))
+
+		h, err := tree.Commit("commit", &git.CommitOptions{
+			All:    true,
+			Author: &object.Signature{When: when},
+		})
+		require.NoError(t, err)
+		return h
+	}
+
+	fooChanged := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	commitAt("/flux/promoted/test/test1/foo/deployment.yaml", fooChanged)
+	mr.githubRepositoryConfig.TargetRef = commitAt("/flux/promoted/test/test1/foobar/deployment.yaml", fooChanged.Add(time.Hour)).String()
+
+	when, err := mr.LastChanged("/flux/promoted/test/test1/foo")
+	require.NoError(t, err)
+	require.True(t, fooChanged.Equal(when))
+
+	when, err = mr.LastChanged("/flux/promoted/test/test2/foo")
+	require.NoError(t, err)
+	require.True(t, when.IsZero())
+}
+
+func Test_HadContentOf(t *testing.T) {
+	mr := setupManifestRepository(t)
+	tree, err := mr.repo.Worktree()
+	require.NoError(t, err)
+
+	commitVersion := func(dir, version string) plumbing.Hash {
+		testutils.WriteFile(t, tree.Filesystem, dir+"/deployment.yaml", version)
+		require.NoError(t, tree.AddGlob("*"))
+
+		h, err := tree.Commit("commit", &git.CommitOptions{All: true, Author: &object.Signature{When: time.Now()}})
+		require.NoError(t, err)
+		return h
+	}
+
+	source := "/flux/promoted/development/dev1/foo"
+	behind := "/flux/promoted/test/test1/foo"
+	same := "/flux/promoted/test/test2/foo"
+	ahead := "/flux/promoted/test/test3/foo"
+
+	commitVersion(behind, "v1")
+	commitVersion(ahead, "v2")
+	commitVersion(ahead, "v3")
+	commitVersion(same, "v2")
+	mr.githubRepositoryConfig.TargetRef = commitVersion(source, "v2").String()
+
+	for dir, want := range map[string]bool{behind: false, same: true, ahead: true, "/flux/promoted/test/test4/foo": false} {
+		got, err := mr.HadContentOf(dir, source)
+		require.NoError(t, err)
+		require.Equal(t, want, got, dir)
+	}
+}
+
+func Test_mergeAssignees(t *testing.T) {
+	logger := logrus.NewEntry(logrus.New())
+
+	merged := mergeAssignees(logger, []string{"user-b", "user-a"}, []string{"user-a", "user-c"})
+	require.Equal(t, []string{"user-a", "user-b", "user-c"}, merged)
+
+	var assignees, reviewers []string
+	for i := 0; i < maxAssignees; i++ {
+		assignees = append(assignees, fmt.Sprintf("author-%02d", i))
+		reviewers = append(reviewers, fmt.Sprintf("owner-%02d", i))
+	}
+	require.Equal(t, assignees, mergeAssignees(logger, assignees, reviewers))
+	require.Len(t, mergeAssignees(logger, assignees[:8], reviewers), maxAssignees)
+}
+
 func getSignKey(t *testing.T) *openpgp.Entity {
 	in, err := os.Open("./testdata/key.gpg")
 	require.NoError(t, err)
@@ -7,7 +7,6 @@ import (
 	"text/template"
 
 	"github.com/form3tech/k8s-promoter/internal/clusterconf"
-	"github.com/form3tech/k8s-promoter/internal/filesystem"
 	"github.com/go-git/go-billy/v5"
 	"github.com/sirupsen/logrus"
 )
@@ -24,17 +23,21 @@ const (
 )
 
 type Kust struct {
+	layout clusterconf.Layout
 	logger *logrus.Entry
 }
 
-func NewKust(log *logrus.Entry) *Kust {
+func NewKust(layout clusterconf.Layout, log *logrus.Entry) *Kust {
 	return &Kust{
+		layout: layout,
 		logger: log,
 	}
 }
 
+// Write lists the workloads of the cluster as the resources of its kustomization.yaml, the workloads of
+// namespaces by their path, e.g. ./payments/api.
 func (k *Kust) Write(fs billy.Filesystem, cluster clusterconf.Cluster) error {
-	dirNames, err := filesystem.DirsInDir(fs, cluster.ManifestFolder())
+	dirNames, err := k.layout.Workloads(fs, cluster.ManifestFolder())
 	if err != nil {
 		return fmt.Errorf("list workload directories: %w", err)
 	}
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/form3tech/k8s-promoter/internal/clusterconf"
+	"github.com/form3tech/k8s-promoter/internal/detect"
 	"github.com/form3tech/k8s-promoter/internal/environment"
 	"github.com/form3tech/k8s-promoter/internal/github"
 	promotion "github.com/form3tech/k8s-promoter/internal/promotion"
@@ -18,6 +20,12 @@ import (
 const (
 	PRTemplatePath = ".github/PULL_REQUEST_TEMPLATE/master.md"
 
+	// DecommissionLabel labels the pull requests removing decommissioned clusters.
+	DecommissionLabel = "k8s-promoter/cluster-decommission"
+
+	// ResyncLabel labels the pull requests re-syncing drifted clusters with their source.
+	ResyncLabel = "k8s-promoter/drift-resync"
+
 	PromotionsSectionTemplate = `{{- template "origin" . -}}
 {{- template "description" .Description -}}
 
@@ -27,12 +35,49 @@ const (
 This promotes all workloads to newly detected cluster(s).{{ "\n" }}
 :warning: **Please update config files as needed** :warning:
 {{ "\n\n" }}
+{{- else if .DecommissionPromotion -}}
+This removes the manifest and config folders of cluster(s) which are no longer in the clusters configuration.{{ "\n" }}
+:warning: **Please make sure the cluster(s) are decommissioned before merging** :warning:
+{{ "\n\n" }}
+{{- else if .RelocationPromotion -}}
+This moves the folders of cluster(s) whose manifestFolder changed in the clusters configuration, the workloads are unchanged:{{ "\n" }}
+{{- range .RelocationView -}}* {{ . -}}{{ "\n" }}{{- end -}}
+{{ "\n" }}
+{{- else if .ResyncPromotion -}}
+This re-syncs the workloads of cluster(s) which drifted from their source environment, e.g. after manual edits.{{ "\n" }}
+:warning: **Please check the drifted changes aren't needed before merging** :warning:
+{{ "\n\n" }}
 {{- else -}}
 {{- template "source-list" .SourceManifestListView -}}
 {{- end -}}
+{{- template "freeze-override" .FreezeOverrideView -}}
+{{- template "config-origin" .ConfigOrigin -}}
+{{- template "skipped" .SkippedView -}}
 {{- template "table" .TableView -}}
 {{- end -}}
 
+{{- define "freeze-override" -}}
+{{- if .Freezes -}}
+:warning: **This promotion overrides the following change freeze(s)** :warning:{{ "\n" }}
+{{- range .Freezes -}}* {{ . -}}{{ "\n" }}{{- end -}}
+{{ "\n" }}Reason: {{ .Reason -}}{{ "\n\n" }}
+{{- end -}}
+{{- end -}}
+
+{{- define "config-origin" -}}
+{{- if . -}}
+Clusters configuration: {{ . -}}{{ "\n\n" }}
+{{- end -}}
+{{- end -}}
+
+{{- define "skipped" -}}
+{{- if . -}}
+The following workload(s) with changes are skipped by their promotion policy:{{ "\n" }}
+{{- range . -}}* {{ . -}}{{ "\n" }}{{- end -}}
+{{ "\n" }}
+{{- end -}}
+{{- end -}}
+
 {{- define "source-list" -}}
 {{- if len . | empty -}}
 This promotion is based on unknown source manifest changes.{{ "\n\n" }}
@@ -65,6 +110,13 @@ Promotions:{{ "\n" }}
 // PullRequestBuilder is responsible for building description, title and commit message for promotion pull request.
 type PullRequestBuilder struct {
 	env                 environment.Env
+	pipeline            environment.Pipeline
+	freezeOverride      freezeOverrideView
+	configOrigin        string
+	relocations         []string
+	paused              promotion.Results
+	pauses              map[string]string
+	skipped             []string
 	logger              *logrus.Entry
 	pullRequestTemplate []byte
 	promotionsTemplate  *template.Template
@@ -75,6 +127,18 @@ type descriptionView struct {
 	Description            string
 	TableView              tableView
 	NewClusterPromotion    bool
+	DecommissionPromotion  bool
+	RelocationPromotion    bool
+	ResyncPromotion        bool
+	RelocationView         []string
+	FreezeOverrideView     freezeOverrideView
+	ConfigOrigin           string
+	SkippedView            []string
+}
+
+type freezeOverrideView struct {
+	Reason  string
+	Freezes []string
 }
 
 type sourceManifestListView []string
@@ -85,7 +149,7 @@ func (v tableView) NotEmpty() bool {
 	return len(v) > 0
 }
 
-func NewPullRequestBuilder(fs billy.Filesystem, log *logrus.Entry, env environment.Env) (*PullRequestBuilder, error) {
+func NewPullRequestBuilder(fs billy.Filesystem, log *logrus.Entry, pipeline environment.Pipeline, env environment.Env) (*PullRequestBuilder, error) {
 	f, err := fs.Open(PRTemplatePath)
 
 	if os.IsNotExist(err) {
@@ -118,18 +182,88 @@ func NewPullRequestBuilder(fs billy.Filesystem, log *logrus.Entry, env environme
 
 	return &PullRequestBuilder{
 		env:                 env,
+		pipeline:            pipeline,
 		pullRequestTemplate: pullRequestTemplate,
 		logger:              log.WithField("module", "PullRequestBuilder"),
 		promotionsTemplate:  promotionsTemplate,
 	}, nil
 }
 
+// ForEnv returns a copy of the builder building pull requests for the given target environment.
+func (p *PullRequestBuilder) ForEnv(env environment.Env) *PullRequestBuilder {
+	builder := *p
+	builder.env = env
+	return &builder
+}
+
+// WithFreezeOverride returns a copy of the builder recording in the description that the given
+// change freezes were overridden for the reason.
+func (p *PullRequestBuilder) WithFreezeOverride(reason string, freezes []string) *PullRequestBuilder {
+	builder := *p
+	builder.freezeOverride = freezeOverrideView{Reason: reason, Freezes: freezes}
+	return &builder
+}
+
+// WithConfigOrigin returns a copy of the builder recording in the description where the clusters
+// configuration was read from.
+func (p *PullRequestBuilder) WithConfigOrigin(origin string) *PullRequestBuilder {
+	builder := *p
+	builder.configOrigin = origin
+	return &builder
+}
+
+// WithRelocations returns a copy of the builder listing in the description the folders the relocated
+// clusters are moved from and to.
+func (p *PullRequestBuilder) WithRelocations(relocations []clusterconf.Relocation) *PullRequestBuilder {
+	builder := *p
+	builder.relocations = nil
+	for _, r := range relocations {
+		builder.relocations = append(builder.relocations, fmt.Sprintf("%s: `%s` to `%s`", r.Cluster.Name(), r.From.ManifestFolder(), r.Cluster.ManifestFolder()))
+		if r.From.ConfigFolder() != "" && r.Cluster.ConfigFolder() != "" {
+			builder.relocations = append(builder.relocations, fmt.Sprintf("%s: `%s` to `%s`", r.Cluster.Name(), r.From.ConfigFolder(), r.Cluster.ConfigFolder()))
+		}
+	}
+	return &builder
+}
+
+// WithPausedClusters returns a copy of the builder showing in the table the changes which weren't
+// performed because their cluster, one of clusters, is paused.
+func (p *PullRequestBuilder) WithPausedClusters(paused promotion.Results, clusters clusterconf.Clusters) *PullRequestBuilder {
+	builder := *p
+	builder.paused = paused
+	builder.pauses = map[string]string{}
+	for _, cluster := range clusters {
+		if _, ok := paused[cluster.Name()]; ok && cluster.Spec.Paused != nil {
+			builder.pauses[cluster.Name()] = cluster.Spec.Paused.String()
+		}
+	}
+	return &builder
+}
+
+// WithSkippedWorkloads returns a copy of the builder listing in the description the workloads which
+// weren't promoted due to their promotion policy.
+func (p *PullRequestBuilder) WithSkippedWorkloads(skipped []promotion.Skipped) *PullRequestBuilder {
+	builder := *p
+	builder.skipped = nil
+	for _, s := range skipped {
+		builder.skipped = append(builder.skipped, s.String())
+	}
+	return &builder
+}
+
 func (p *PullRequestBuilder) Build(promotions promotion.Results, commits []*github.Commit, kind promotion.Kind) github.PromotionPullRequest {
-	return github.PromotionPullRequest{
-		CommitMessage: p.buildCommitMessage(promotions, commits),
+	pr := github.PromotionPullRequest{
+		CommitMessage: p.buildCommitMessage(promotions, commits, kind),
 		Description:   p.buildDescription(commits, promotions, kind),
-		Title:         p.buildTitle(promotions),
+		Title:         p.buildTitle(promotions, kind),
+	}
+	if kind == promotion.Decommission {
+		pr.Labels = []string{DecommissionLabel}
+	}
+	if kind == promotion.Resync {
+		pr.Labels = []string{ResyncLabel}
 	}
+	return pr
 }
 
 func (b *PullRequestBuilder) buildDescription(sourceCommits []*github.Commit, promotions promotion.Results, promotionType promotion.Kind) string {
@@ -140,8 +274,15 @@ func (b *PullRequestBuilder) buildDescription(sourceCommits []*github.Commit, pr
 		descriptionView{
 			SourceManifestListView: buildSourceManifestListView(sourceCommits),
 			Description:            string(b.pullRequestTemplate),
-			TableView:              buildTableView(promotions, promotionType),
+			TableView:              buildTableView(promotions, b.paused, b.pauses, promotionType),
 			NewClusterPromotion:    promotionType == promotion.NewCluster,
+			DecommissionPromotion:  promotionType == promotion.Decommission,
+			RelocationPromotion:    promotionType == promotion.Relocation,
+			ResyncPromotion:        promotionType == promotion.Resync,
+			RelocationView:         b.relocations,
+			FreezeOverrideView:     b.freezeOverride,
+			ConfigOrigin:           b.configOrigin,
+			SkippedView:            b.skipped,
 		},
 	)
 	if err != nil {
@@ -151,8 +292,8 @@ func (b *PullRequestBuilder) buildDescription(sourceCommits []*github.Commit, pr
 	return buf.String()
 }
 
-func (p *PullRequestBuilder) buildCommitMessage(promotions promotion.Results, sourceCommits []*github.Commit) string {
-	prTitle := p.buildTitle(promotions)
+func (p *PullRequestBuilder) buildCommitMessage(promotions promotion.Results, sourceCommits []*github.Commit, kind promotion.Kind) string {
+	prTitle := p.buildTitle(promotions, kind)
 
 	commitMsg := prTitle
 	if len(sourceCommits) > 0 {
@@ -169,10 +310,22 @@ func (p *PullRequestBuilder) buildCommitMessage(promotions promotion.Results, so
 	return commitMsg
 }
 
-func (p *PullRequestBuilder) buildTitle(promotions promotion.Results) string {
+func (p *PullRequestBuilder) buildTitle(promotions promotion.Results, kind promotion.Kind) string {
+	if kind == promotion.Decommission {
+		return fmt.Sprintf("Decommission %s from %s", strings.Join(promotions.ClusterNames(), ", "), p.env)
+	}
+	if kind == promotion.Relocation {
+		return fmt.Sprintf("Relocate %s in %s", strings.Join(promotions.ClusterNames(), ", "), p.env)
+	}
+
+	if kind == promotion.Resync {
+		return fmt.Sprintf("Re-sync %s in %s (%s)", strings.Join(promotions.WorkloadNames(), ", "), p.env, strings.Join(promotions.ClusterNames(), ", "))
+	}
+
 	title := fmt.Sprintf("Promote %s to %s", strings.Join(promotions.WorkloadNames(), ", "), p.env)
 
-	if p.env != environment.Development {
+	// clusters of the first environment are grouped in a single PR, so there is no point listing them
+	if !p.pipeline.IsFirst(p.env) {
 		title += fmt.Sprintf(" (%s)", strings.Join(promotions.ClusterNames(), ", "))
 	}
 
@@ -191,36 +344,63 @@ func buildSourceManifestListView(commits []*github.Commit) sourceManifestListVie
 	return list
 }
 
-func buildTableView(promotions promotion.Results, kind promotion.Kind) tableView {
+func buildTableView(promotions, paused promotion.Results, pauses map[string]string, kind promotion.Kind) tableView {
 	var table tableView
 	if len(promotions) == 0 {
 		return table
 	}
 
+	all := promotion.Results{}
+	for _, results := range []promotion.Results{promotions, paused} {
+		for cluster, workloads := range results {
+			all[cluster] = workloads
+		}
+	}
+
 	var (
 		headers = []string{""}
 		divider = []string{"-"}
 	)
-	for _, name := range promotions.WorkloadNames() {
+	for _, name := range all.WorkloadNames() {
 		headers = append(headers, name)
 		divider = append(divider, "-")
 	}
 	table = append(table, headers)
 	table = append(table, divider)
 
-	for _, clusterName := range promotions.ClusterNames() {
+	for _, clusterName := range all.ClusterNames() {
 		var row []string
 		clusterCell := clusterName
+		_, isPaused := paused[clusterName]
 		if kind == promotion.NewCluster {
 			clusterCell += " (new)"
 		}
+		if kind == promotion.Decommission {
+			clusterCell += " (removed)"
+		}
+		if kind == promotion.Relocation {
+			clusterCell += " (moved)"
+		}
+		if kind == promotion.Resync {
+			clusterCell += " (drifted)"
+		}
+		if isPaused {
+			clusterCell += fmt.Sprintf(" (%s)", pauses[clusterName])
+		}
 		row = append(row, clusterCell)
 
-		for _, workloadName := range promotions.WorkloadNames() {
-			_, exists := promotions[clusterName][workloadName]
-			if !exists {
+		for _, workloadName := range all.WorkloadNames() {
+			change, exists := all[clusterName][workloadName]
+			switch {
+			case !exists:
 				row = append(row, "-")
-			} else {
+			case isPaused:
+				row = append(row, ":pause_button:")
+			case change.Targeting && change.Op == detect.OperationRemove:
+				row = append(row, ":heavy_minus_sign: (targeting change)")
+			case change.Targeting:
+				row = append(row, ":heavy_check_mark: (targeting change)")
+			default:
 				row = append(row, ":heavy_check_mark:")
 			}
 		}
@@ -3,6 +3,7 @@ package promoter_test
 import (
 	"testing"
 
+	"github.com/form3tech/k8s-promoter/internal/clusterconf"
 	"github.com/form3tech/k8s-promoter/internal/detect"
 	"github.com/form3tech/k8s-promoter/internal/environment"
 	"github.com/form3tech/k8s-promoter/internal/github"
@@ -16,10 +17,17 @@ import (
 
 func TestPRBuilder_Description(t *testing.T) {
 	tests := map[string]struct {
-		commits       []*github.Commit
-		promotions    promotion.Results
-		promotionType promotion.Kind
-		want          string
+		commits        []*github.Commit
+		promotions     promotion.Results
+		promotionType  promotion.Kind
+		freezeOverride string
+		freezes        []string
+		configOrigin   string
+		relocations    []clusterconf.Relocation
+		paused         promotion.Results
+		clusters       clusterconf.Clusters
+		skipped        []promotion.Skipped
+		want           string
 	}{
 		"empty source commits and promotion results": {
 			commits:       nil,
@@ -132,6 +140,268 @@ Promotions:
 |dev4 (new)|:heavy_check_mark:|:heavy_check_mark:|
 ### Description
 
+template`,
+		},
+		"overridden change freezes": {
+			commits: []*github.Commit{
+				{
+					Hash:           "b9cfd3a",
+					AuthorLogin:    "login-1",
+					CommitterLogin: "login-1",
+				},
+			},
+			promotions: promotion.Results{
+				"prod1": {
+					"foo": detect.WorkloadChange{
+						W: detect.Workload{Name: "foo"},
+					},
+				},
+			},
+			promotionType:  promotion.ManifestUpdate,
+			freezeOverride: "INC-123 hotfix",
+			freezes:        []string{"quarter-end (End of quarter reporting)", "weekend"},
+			want: `### Origin
+
+This promotion is based on the following source manifest changes(s):
+* b9cfd3a - @login-1
+
+:warning: **This promotion overrides the following change freeze(s)** :warning:
+* quarter-end (End of quarter reporting)
+* weekend
+
+Reason: INC-123 hotfix
+
+Promotions:
+||foo|
+|-|-|
+|prod1|:heavy_check_mark:|
+### Description
+
+template`,
+		},
+		"clusters configuration origin": {
+			commits: []*github.Commit{
+				{
+					Hash:           "b9cfd3a",
+					AuthorLogin:    "login-1",
+					CommitterLogin: "login-1",
+				},
+			},
+			promotions: promotion.Results{
+				"dev1": {
+					"foo": detect.WorkloadChange{
+						W: detect.Workload{Name: "foo"},
+					},
+				},
+			},
+			promotionType: promotion.ManifestUpdate,
+			configOrigin:  "`clusters.yaml` in form3tech/config at master (c0ffee0)",
+			want: `### Origin
+
+This promotion is based on the following source manifest changes(s):
+* b9cfd3a - @login-1
+
+Clusters configuration: ` + "`clusters.yaml`" + ` in form3tech/config at master (c0ffee0)
+
+Promotions:
+||foo|
+|-|-|
+|dev1|:heavy_check_mark:|
+### Description
+
+template`,
+		},
+		"decommissioned cluster": {
+			commits: []*github.Commit{},
+			promotions: promotion.Results{
+				"dev1/cloud1": {
+					"foo": detect.WorkloadChange{
+						W:  detect.Workload{Name: "foo"},
+						Op: detect.OperationRemove,
+					},
+				},
+			},
+			promotionType: promotion.Decommission,
+			want: `### Origin
+
+This removes the manifest and config folders of cluster(s) which are no longer in the clusters configuration.
+
+:warning: **Please make sure the cluster(s) are decommissioned before merging** :warning:
+
+
+Promotions:
+||foo|
+|-|-|
+|dev1/cloud1 (removed)|:heavy_check_mark:|
+### Description
+
+template`,
+		},
+		"relocated cluster": {
+			commits: []*github.Commit{},
+			promotions: promotion.Results{
+				"dev1": {
+					"foo": detect.WorkloadChange{
+						W: detect.Workload{Name: "foo"},
+					},
+				},
+			},
+			promotionType: promotion.Relocation,
+			relocations: []clusterconf.Relocation{
+				{
+					Cluster: clusterconf.Cluster{
+						Metadata: clusterconf.ClusterMetadata{Name: "dev1"},
+						Spec:     clusterconf.ClusterSpec{ManifestFolder: "/promoted/development/cloud1/dev1"},
+					},
+					From: clusterconf.Cluster{
+						Metadata: clusterconf.ClusterMetadata{Name: "dev1"},
+						Spec:     clusterconf.ClusterSpec{ManifestFolder: "/promoted/development/dev1"},
+					},
+				},
+			},
+			want: `### Origin
+
+This moves the folders of cluster(s) whose manifestFolder changed in the clusters configuration, the workloads are unchanged:
+* dev1: ` + "`/promoted/development/dev1` to `/promoted/development/cloud1/dev1`" + `
+
+Promotions:
+||foo|
+|-|-|
+|dev1 (moved)|:heavy_check_mark:|
+### Description
+
+template`,
+		},
+		"paused cluster": {
+			commits: []*github.Commit{},
+			promotions: promotion.Results{
+				"dev1": {
+					"foo": detect.WorkloadChange{
+						W: detect.Workload{Name: "foo"},
+					},
+				},
+			},
+			paused: promotion.Results{
+				"dev2": {
+					"foo": detect.WorkloadChange{
+						W: detect.Workload{Name: "foo"},
+					},
+					"bar": detect.WorkloadChange{
+						W: detect.Workload{Name: "bar"},
+					},
+				},
+			},
+			clusters: clusterconf.Clusters{
+				{Metadata: clusterconf.ClusterMetadata{Name: "dev1"}},
+				{
+					Metadata: clusterconf.ClusterMetadata{Name: "dev2"},
+					Spec:     clusterconf.ClusterSpec{Paused: &clusterconf.Pause{Reason: "rebuild"}},
+				},
+			},
+			promotionType: promotion.ManifestUpdate,
+			want: `### Origin
+
+This promotion is based on unknown source manifest changes.
+
+Promotions:
+||bar|foo|
+|-|-|-|
+|dev1|-|:heavy_check_mark:|
+|dev2 (paused: rebuild)|:pause_button:|:pause_button:|
+### Description
+
+template`,
+		},
+		"skipped workloads": {
+			commits: []*github.Commit{},
+			promotions: promotion.Results{
+				"dev1": {
+					"foo": detect.WorkloadChange{
+						W: detect.Workload{Name: "foo"},
+					},
+				},
+			},
+			skipped:       []promotion.Skipped{{Workload: "bar", Reason: "promoted to development manually only"}},
+			promotionType: promotion.ManifestUpdate,
+			want: `### Origin
+
+This promotion is based on unknown source manifest changes.
+
+The following workload(s) with changes are skipped by their promotion policy:
+* bar: promoted to development manually only
+
+Promotions:
+||foo|
+|-|-|
+|dev1|:heavy_check_mark:|
+### Description
+
+template`,
+		},
+		"drift resync": {
+			commits: []*github.Commit{},
+			promotions: promotion.Results{
+				"dev1": {
+					"foo": detect.WorkloadChange{
+						W:  detect.Workload{Name: "foo"},
+						Op: detect.OperationCopy,
+					},
+				},
+			},
+			promotionType: promotion.Resync,
+			want: `### Origin
+
+This re-syncs the workloads of cluster(s) which drifted from their source environment, e.g. after manual edits.
+
+:warning: **Please check the drifted changes aren't needed before merging** :warning:
+
+
+Promotions:
+||foo|
+|-|-|
+|dev1 (drifted)|:heavy_check_mark:|
+### Description
+
+template`,
+		},
+		"targeting changes": {
+			commits: []*github.Commit{},
+			promotions: promotion.Results{
+				"dev1": {
+					"foo": detect.WorkloadChange{
+						W:  detect.Workload{Name: "foo"},
+						Op: detect.OperationCopy,
+					},
+					"bar": detect.WorkloadChange{
+						W:         detect.Workload{Name: "bar"},
+						Op:        detect.OperationCopy,
+						Targeting: true,
+					},
+				},
+				"dev2": {
+					"foo": detect.WorkloadChange{
+						W:  detect.Workload{Name: "foo"},
+						Op: detect.OperationCopy,
+					},
+					"bar": detect.WorkloadChange{
+						W:         detect.Workload{Name: "bar"},
+						Op:        detect.OperationRemove,
+						Targeting: true,
+					},
+				},
+			},
+			promotionType: promotion.ManifestUpdate,
+			want: `### Origin
+
+This promotion is based on unknown source manifest changes.
+
+Promotions:
+||bar|foo|
+|-|-|-|
+|dev1|:heavy_check_mark: (targeting change)|:heavy_check_mark:|
+|dev2|:heavy_minus_sign: (targeting change)|:heavy_check_mark:|
+### Description
+
 template`,
 		},
 	}
@@ -142,11 +412,11 @@ template`,
 			testutils.WriteFile(t, fs, promoter.PRTemplatePath, "template")
 			l := logrus.NewEntry(logrus.New())
 
-			builder, err := promoter.NewPullRequestBuilder(fs, l, environment.Development)
+			builder, err := promoter.NewPullRequestBuilder(fs, l, environment.DefaultPipeline(), environment.Development)
 			require.NoError(t, err)
 
 			// when
-			got := builder.Build(tt.promotions, tt.commits, tt.promotionType)
+			got := builder.WithFreezeOverride(tt.freezeOverride, tt.freezes).WithConfigOrigin(tt.configOrigin).WithRelocations(tt.relocations).WithPausedClusters(tt.paused, tt.clusters).WithSkippedWorkloads(tt.skipped).Build(tt.promotions, tt.commits, tt.promotionType)
 
 			// then
 			require.Equal(t, tt.want, got.Description)
@@ -157,7 +427,9 @@ template`,
 func Test_PRBuilder_Title(t *testing.T) {
 	tests := map[string]struct {
 		results   promotion.Results
+		pipeline  []environment.Stage
 		targetEnv environment.Env
+		kind      promotion.Kind
 		want      string
 	}{
 		"single workload promoted to dev": {
@@ -222,6 +494,48 @@ func Test_PRBuilder_Title(t *testing.T) {
 			targetEnv: environment.Test,
 			want:      "Promote bar, foo to test (dev1)",
 		},
+		"single workload promoted to the first environment of a custom pipeline": {
+			results: promotion.Results{
+				"sandbox1": {
+					"foo": detect.WorkloadChange{
+						W: detect.Workload{
+							Name: "foo",
+						},
+					},
+				},
+			},
+			pipeline:  environment.Chain("sandbox", environment.Development),
+			targetEnv: "sandbox",
+			want:      "Promote foo to sandbox",
+		},
+		"single workload promoted to development from a custom pipeline": {
+			results: promotion.Results{
+				"dev1": {
+					"foo": detect.WorkloadChange{
+						W: detect.Workload{
+							Name: "foo",
+						},
+					},
+				},
+			},
+			pipeline:  environment.Chain("sandbox", environment.Development),
+			targetEnv: environment.Development,
+			want:      "Promote foo to development (dev1)",
+		},
+		"drifted workload re-synced in dev": {
+			results: promotion.Results{
+				"dev1": {
+					"foo": detect.WorkloadChange{
+						W: detect.Workload{
+							Name: "foo",
+						},
+					},
+				},
+			},
+			targetEnv: environment.Development,
+			kind:      promotion.Resync,
+			want:      "Re-sync foo in development (dev1)",
+		},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -229,10 +543,22 @@ func Test_PRBuilder_Title(t *testing.T) {
 			testutils.WriteFile(t, fs, promoter.PRTemplatePath, "template")
 			l := logrus.NewEntry(logrus.New())
 
-			builder, err := promoter.NewPullRequestBuilder(fs, l, tt.targetEnv)
+			pipeline := environment.DefaultPipeline()
+			if tt.pipeline != nil {
+				var err error
+				pipeline, err = environment.NewPipeline(tt.pipeline...)
+				require.NoError(t, err)
+			}
+
+			builder, err := promoter.NewPullRequestBuilder(fs, l, pipeline, tt.targetEnv)
 			require.NoError(t, err)
 
-			got := builder.Build(tt.results, []*github.Commit{}, promotion.ManifestUpdate)
+			kind := tt.kind
+			if kind == "" {
+				kind = promotion.ManifestUpdate
+			}
+
+			got := builder.Build(tt.results, []*github.Commit{}, kind)
 			require.Equal(t, tt.want, got.Title)
 		})
 	}
@@ -0,0 +1,376 @@
+package promoter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/form3tech/k8s-promoter/internal/clusterconf"
+	"github.com/form3tech/k8s-promoter/internal/filesystem"
+	"github.com/form3tech/k8s-promoter/internal/git"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gh "github.com/google/go-github/v33/github"
+)
+
+// ConfigSource tells where the clusters configuration file is read from.
+type ConfigSource string
+
+const (
+	// ConfigSourceGitHub reads the file from the config repository with the GitHub Contents API.
+	ConfigSourceGitHub ConfigSource = "github"
+	// ConfigSourceGit reads the file from a git clone of the config repository.
+	ConfigSourceGit ConfigSource = "git"
+	// ConfigSourceManifestRepo reads the file from the cloned manifest repository.
+	ConfigSourceManifestRepo ConfigSource = "manifest-repo"
+	// ConfigSourceLocal reads the file from the local filesystem.
+	ConfigSourceLocal ConfigSource = "local"
+
+	DefaultConfigRef = "master"
+)
+
+var ConfigSources = []ConfigSource{ConfigSourceGitHub, ConfigSourceGit, ConfigSourceManifestRepo, ConfigSourceLocal}
+
+// ConfigOrigin records where the clusters configuration was read from, so that it can be
+// referenced in the pull requests.
+type ConfigOrigin struct {
+	Source     ConfigSource
+	Repository string
+	Path       string
+	Ref        string
+	Commit     string
+}
+
+func (o ConfigOrigin) String() string {
+	if o.Source == ConfigSourceLocal {
+		return fmt.Sprintf("`%s` (local file)", o.Path)
+	}
+
+	origin := fmt.Sprintf("`%s` in %s at %s", o.Path, o.Repository, o.Ref)
+	if o.Commit != "" && o.Commit != o.Ref {
+		origin += fmt.Sprintf(" (%s)", o.Commit)
+	}
+	return origin
+}
+
+func (a *Args) configSource() ConfigSource {
+	if a.ConfigSource == "" {
+		return ConfigSourceGitHub
+	}
+	return a.ConfigSource
+}
+
+func (a *Args) configRef() string {
+	if a.ConfigRef == "" {
+		return DefaultConfigRef
+	}
+	return a.ConfigRef
+}
+
+// fetchClustersConfig reads and parses the clusters configuration files from the source selected in
+// args. manifestRepo and fs are the cloned manifest repository and its working tree.
+func fetchClustersConfig(ctx context.Context, ghClient *gh.Client, manifestRepo *gogit.Repository, fs billy.Filesystem, args *Args) (clusterconf.Config, ConfigOrigin, error) {
+	var (
+		files  []clusterconf.ConfigFile
+		origin ConfigOrigin
+		err    error
+	)
+
+	switch source := args.configSource(); source {
+	case ConfigSourceGitHub:
+		files, origin, err = fetchGitHubConfig(ctx, ghClient, args)
+	case ConfigSourceGit:
+		files, origin, err = fetchGitConfig(ctx, args)
+	case ConfigSourceManifestRepo:
+		files, origin, err = readManifestRepoConfig(manifestRepo, fs, args)
+	case ConfigSourceLocal:
+		files, origin, err = readLocalConfig(args)
+	default:
+		err = fmt.Errorf("unknown config source '%s'", source)
+	}
+	if err != nil {
+		return clusterconf.Config{}, ConfigOrigin{}, err
+	}
+
+	clustersConfig, err := clusterconf.ParseConfigFiles(files...)
+	if err != nil {
+		return clusterconf.Config{}, ConfigOrigin{}, fmt.Errorf("parse clusters: %w", err)
+	}
+
+	return clustersConfig, origin, nil
+}
+
+// fetchPreviousClusters reads the clusters of the configuration at the previous ref, see Args.PreviousConfigRef.
+// It returns no clusters when there is no previous ref, or when the configuration didn't exist at the previous
+// ref of the manifest repository.
+func fetchPreviousClusters(ctx context.Context, ghClient *gh.Client, manifestRepo *gogit.Repository, args *Args) (clusterconf.Clusters, error) {
+	previous := *args
+	previous.ConfigRef = args.PreviousConfigRef
+
+	var (
+		files []clusterconf.ConfigFile
+		err   error
+	)
+
+	switch source := args.configSource(); {
+	case source == ConfigSourceManifestRepo:
+		ref := args.PreviousConfigRef
+		if ref == "" && args.CommitRange != nil {
+			ref = args.CommitRange.FromPrefix
+		}
+		if ref == "" {
+			return nil, nil
+		}
+		files, err = readTreeConfigFiles(manifestRepo, ref, args.ConfigPath)
+	case args.PreviousConfigRef == "":
+		return nil, nil
+	case source == ConfigSourceGitHub:
+		files, _, err = fetchGitHubConfig(ctx, ghClient, &previous)
+	case source == ConfigSourceGit:
+		files, _, err = fetchGitConfig(ctx, &previous)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading previous clusters config: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	config, err := clusterconf.ParseConfigFiles(files...)
+	if err != nil {
+		return nil, fmt.Errorf("parse previous clusters: %w", err)
+	}
+	return config.Clusters, nil
+}
+
+func fetchGitHubConfig(ctx context.Context, ghClient *gh.Client, args *Args) ([]clusterconf.ConfigFile, ConfigOrigin, error) {
+	ref := args.configRef()
+	owner, repo := args.CloneArgs.Owner, args.ConfigRepository
+
+	// all files are read at the commit the ref points at, in case the ref moves in the meantime
+	commit, _, err := ghClient.Repositories.GetCommitSHA1(ctx, owner, repo, ref, "")
+	if err != nil {
+		return nil, ConfigOrigin{}, fmt.Errorf("resolving config ref %s: %w", ref, err)
+	}
+
+	paths := []string{args.ConfigPath}
+	if clusterconf.IsConfigPattern(args.ConfigPath) || isGitHubDir(ctx, ghClient, owner, repo, args.ConfigPath, commit) {
+		paths, err = matchGitHubConfigFiles(ctx, ghClient, owner, repo, args.ConfigPath, commit)
+		if err != nil {
+			return nil, ConfigOrigin{}, err
+		}
+	}
+
+	var files []clusterconf.ConfigFile
+	for _, path := range paths {
+		config, _, _, err := ghClient.Repositories.GetContents(ctx, owner, repo, path, &gh.RepositoryContentGetOptions{
+			Ref: commit,
+		})
+		if err != nil {
+			return nil, ConfigOrigin{}, fmt.Errorf("fetching config: %w", err)
+		}
+
+		content, err := config.GetContent()
+		if err != nil {
+			return nil, ConfigOrigin{}, fmt.Errorf("decoding config: %w", err)
+		}
+		files = append(files, clusterconf.ConfigFile{Name: path, Content: strings.NewReader(content)})
+	}
+
+	return files, ConfigOrigin{
+		Source:     ConfigSourceGitHub,
+		Repository: fmt.Sprintf("%s/%s", owner, repo),
+		Path:       args.ConfigPath,
+		Ref:        ref,
+		Commit:     commit,
+	}, nil
+}
+
+// isGitHubDir reports whether path is a directory of the repository. Other errors are reported when the
+// file is fetched.
+func isGitHubDir(ctx context.Context, ghClient *gh.Client, owner, repo, path, ref string) bool {
+	_, dir, _, err := ghClient.Repositories.GetContents(ctx, owner, repo, path, &gh.RepositoryContentGetOptions{
+		Ref: ref,
+	})
+	return err == nil && dir != nil
+}
+
+func matchGitHubConfigFiles(ctx context.Context, ghClient *gh.Client, owner, repo, configPath, ref string) ([]string, error) {
+	tree, _, err := ghClient.Git.GetTree(ctx, owner, repo, ref, true)
+	if err != nil {
+		return nil, fmt.Errorf("listing config files: %w", err)
+	}
+	if tree.GetTruncated() {
+		return nil, fmt.Errorf("listing config files: tree of %s/%s is too large", owner, repo)
+	}
+
+	var files []string
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" {
+			files = append(files, entry.GetPath())
+		}
+	}
+
+	matches := clusterconf.MatchConfigFiles(configPath, files)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no config files match %s", configPath)
+	}
+	return matches, nil
+}
+
+func fetchGitConfig(ctx context.Context, args *Args) ([]clusterconf.ConfigFile, ConfigOrigin, error) {
+	ref := args.configRef()
+
+	repo, err := git.Clone(ctx, &git.CloneArgs{
+		Auth:    args.CloneArgs.Auth,
+		BaseURL: args.CloneArgs.BaseURL,
+		Owner:   args.CloneArgs.Owner,
+		Repo:    args.ConfigRepository,
+		Ref:     ref,
+	})
+	if err != nil {
+		return nil, ConfigOrigin{}, fmt.Errorf("cloning config repository: %w", err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, ConfigOrigin{}, fmt.Errorf("repo.Worktree: %w", err)
+	}
+
+	origin, err := repositoryConfigOrigin(ConfigSourceGit, repo, args.ConfigRepository, ref, args)
+	if err != nil {
+		return nil, ConfigOrigin{}, err
+	}
+
+	files, err := readConfigFiles(wt.Filesystem, args.ConfigPath)
+	return files, origin, err
+}
+
+func readManifestRepoConfig(repo *gogit.Repository, fs billy.Filesystem, args *Args) ([]clusterconf.ConfigFile, ConfigOrigin, error) {
+	origin, err := repositoryConfigOrigin(ConfigSourceManifestRepo, repo, args.CloneArgs.Repo, args.CloneArgs.Ref, args)
+	if err != nil {
+		return nil, ConfigOrigin{}, err
+	}
+
+	files, err := readConfigFiles(fs, args.ConfigPath)
+	return files, origin, err
+}
+
+func readLocalConfig(args *Args) ([]clusterconf.ConfigFile, ConfigOrigin, error) {
+	configPath, err := filepath.Abs(args.ConfigPath)
+	if err != nil {
+		return nil, ConfigOrigin{}, fmt.Errorf("reading config: %w", err)
+	}
+
+	files, err := readConfigFiles(osfs.New("/"), filepath.ToSlash(configPath))
+	if err != nil {
+		return nil, ConfigOrigin{}, err
+	}
+
+	return files, ConfigOrigin{Source: ConfigSourceLocal, Path: args.ConfigPath}, nil
+}
+
+func repositoryConfigOrigin(source ConfigSource, repo *gogit.Repository, repository, ref string, args *Args) (ConfigOrigin, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return ConfigOrigin{}, fmt.Errorf("repo.Head: %w", err)
+	}
+
+	return ConfigOrigin{
+		Source:     source,
+		Repository: fmt.Sprintf("%s/%s", args.CloneArgs.Owner, repository),
+		Path:       args.ConfigPath,
+		Ref:        ref,
+		Commit:     head.Hash().String(),
+	}, nil
+}
+
+// readConfigFiles reads the files selected by configPath, a file, a directory or a glob pattern, from fs.
+func readConfigFiles(fs billy.Filesystem, configPath string) ([]clusterconf.ConfigFile, error) {
+	paths := []string{configPath}
+
+	base := clusterconf.ConfigPathBase(configPath)
+	if base == "" {
+		base = "/"
+	}
+	info, err := fs.Stat(base)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	if info.IsDir() {
+		var files []string
+		err := filesystem.WalkFiles(fs, base, func(filePath string) error {
+			files = append(files, filePath)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing config files: %w", err)
+		}
+
+		paths = clusterconf.MatchConfigFiles(configPath, files)
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no config files match %s", configPath)
+		}
+	}
+
+	var files []clusterconf.ConfigFile
+	for _, path := range paths {
+		content, err := util.ReadFile(fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config: %w", err)
+		}
+		files = append(files, clusterconf.ConfigFile{Name: path, Content: bytes.NewReader(content)})
+	}
+	return files, nil
+}
+
+// readTreeConfigFiles reads the files selected by configPath from the tree of repo at ref.
+func readTreeConfigFiles(repo *gogit.Repository, ref, configPath string) ([]clusterconf.ConfigFile, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", ref, err)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("repo.CommitObject: %w", err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("commit.Tree: %w", err)
+	}
+
+	var paths []string
+	err = tree.Files().ForEach(func(f *object.File) error {
+		paths = append(paths, f.Name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing config files: %w", err)
+	}
+
+	var files []clusterconf.ConfigFile
+	for _, path := range clusterconf.MatchConfigFiles(strings.TrimPrefix(configPath, "/"), paths) {
+		f, err := tree.File(path)
+		if err != nil {
+			return nil, fmt.Errorf("tree.File: %s: %w", path, err)
+		}
+
+		content, err := f.Contents()
+		if err != nil {
+			return nil, fmt.Errorf("reading config: %w", err)
+		}
+		files = append(files, clusterconf.ConfigFile{Name: path, Content: strings.NewReader(content)})
+	}
+	return files, nil
+}
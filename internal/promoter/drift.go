@@ -0,0 +1,308 @@
+package promoter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/form3tech/k8s-promoter/internal/clusterconf"
+	"github.com/form3tech/k8s-promoter/internal/detect"
+	"github.com/form3tech/k8s-promoter/internal/environment"
+	"github.com/form3tech/k8s-promoter/internal/promotion"
+	"github.com/go-git/go-billy/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// Drift is a workload whose directory in a cluster differs from its source, e.g. after a manual edit.
+type Drift struct {
+	Cluster   string
+	Workload  string
+	SourceDir string
+	TargetDir string
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf("%s: %s differs from %s", d.Cluster, d.TargetDir, d.SourceDir)
+}
+
+// DriftReport describes the drifted workloads of a target environment, the pull requests re-syncing them, and the
+// drifted workloads whose resync was postponed.
+type DriftReport struct {
+	Env          environment.Env
+	Drifts       []Drift
+	PullRequests []string
+	Postponed    []PostponedWorkload
+}
+
+// Log writes the report to the logger, one entry per drifted workload and raised pull request.
+func (r DriftReport) Log(log *logrus.Entry) {
+	log = log.WithField("target_env", r.Env)
+	for _, d := range r.Drifts {
+		log.WithFields(logrus.Fields{
+			"cluster":   d.Cluster,
+			"workload":  d.Workload,
+			"sourceDir": d.SourceDir,
+			"targetDir": d.TargetDir,
+		}).Warn("Found drifted workload")
+	}
+	for _, title := range r.PullRequests {
+		log.WithField("title", title).Info("Raised pull request")
+	}
+	for _, w := range r.Postponed {
+		log.WithFields(logrus.Fields{
+			"workload": w.Workload,
+			"reason":   w.Reason,
+			"until":    w.Until.Format(time.RFC3339),
+		}).Info("Postponed workload")
+	}
+	log.WithFields(logrus.Fields{
+		"drifts":        len(r.Drifts),
+		"pull_requests": len(r.PullRequests),
+		"postponed":     len(r.Postponed),
+	}).Info("Drift detection finished")
+}
+
+// Drift compares the workloads of each cluster of the target environment with their source: the previous
+// environment, or the source manifests for the first environment. Paused clusters are expected to be behind,
+// so they are left out, as are the workloads at a former version of their source. With resyncing enabled, a
+// pull request copying the source over the drifted workloads is raised, unless a change freeze is in force.
+func (p *Promoter) Drift(ctx context.Context, env string) (DriftReport, error) {
+	targetEnv := environment.Env(env)
+	report := DriftReport{Env: targetEnv}
+	if err := p.config.Pipeline.Validate(targetEnv); err != nil {
+		return report, ErrInvalidEnvironment
+	}
+
+	fs, err := p.manifestRepo.WorkingTreeFS()
+	if err != nil {
+		return report, err
+	}
+
+	now := time.Now()
+	clusters := p.config.Clusters.Filter(clusterconf.ByEnvironment(targetEnv))
+	for _, cluster := range clusters {
+		if cluster.IsPaused(now) {
+			p.logger.WithFields(logrus.Fields{
+				"cluster": cluster.Name(),
+				"pause":   cluster.Spec.Paused.String(),
+			}).Info("Skipping paused cluster")
+			continue
+		}
+
+		drifts, err := p.clusterDrifts(fs, cluster, targetEnv)
+		if err != nil {
+			return report, err
+		}
+		report.Drifts = append(report.Drifts, drifts...)
+	}
+
+	if len(report.Drifts) == 0 || !p.resync {
+		return report, nil
+	}
+
+	err = p.resyncDrifts(ctx, &report, clusters)
+	return report, err
+}
+
+// clusterDrifts returns the drifted workloads of the cluster. The workloads which the cluster doesn't allow,
+// which are no longer in the source or have no source cluster, or whose source environment isn't consistent
+// are left out: they are taken care of by promotions.
+func (p *Promoter) clusterDrifts(fs billy.Filesystem, cluster clusterconf.Cluster, targetEnv environment.Env) ([]Drift, error) {
+	workloads, err := p.config.Layout.Workloads(fs, cluster.ManifestFolder())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("layout.Workloads: %w", err)
+	}
+
+	manifestSource, err := p.config.Pipeline.ManifestSource(targetEnv)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline.ManifestSource: %w", err)
+	}
+
+	var drifts []Drift
+	for _, name := range workloads {
+		workload, err := p.registry.Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("registry.Get: %w", err)
+		}
+
+		if !cluster.AllowWorkload(workload) {
+			continue
+		}
+
+		err = p.verifyWorkloadConsistency(workload, manifestSource)
+		if errors.Is(err, ErrClustersNotInSync) {
+			p.logger.WithError(err).Warn("Skipping workload which isn't consistent across the source environment")
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("verifyWorkloadConsistency: %w", err)
+		}
+
+		change := detect.WorkloadChange{Op: detect.OperationCopy, W: detect.Workload{Name: name, SourceEnv: string(manifestSource)}}
+		sourceDir, err := p.getSourceDir(change, targetEnv)
+		if errors.Is(err, ErrNoSourceCluster) {
+			p.logger.WithError(err).WithField("cluster", cluster.Name()).Warn(NoSourceMsg)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := fs.Stat(sourceDir); err != nil {
+			continue
+		}
+
+		targetDir := cluster.WorkloadPath(name)
+		sourceHash, err := p.dirHash(fs, sourceDir)
+		if err != nil {
+			return nil, fmt.Errorf("hash directory %s: %w", sourceDir, err)
+		}
+
+		targetHash, err := p.dirHash(fs, targetDir)
+		if err != nil {
+			return nil, fmt.Errorf("hash directory %s: %w", targetDir, err)
+		}
+
+		if sourceHash == targetHash {
+			continue
+		}
+
+		// a workload which the source had before is behind it, pending promotion or held back, rather than drifted
+		behind, err := p.manifestRepo.HadContentOf(sourceDir, targetDir)
+		if err != nil {
+			return nil, fmt.Errorf("HadContentOf: %w", err)
+		}
+		if !behind {
+			drifts = append(drifts, Drift{Cluster: cluster.Name(), Workload: name, SourceDir: sourceDir, TargetDir: targetDir})
+		}
+	}
+	return drifts, nil
+}
+
+// resyncDrifts raises a single pull request copying the source over the drifted workloads, going through the gates
+// of a promotion: change freezes, soak time, promotion policies, rollout waves, pauses and dependencies. The drifts
+// held back by a gate are left for a later run.
+func (p *Promoter) resyncDrifts(ctx context.Context, report *DriftReport, clusters clusterconf.Clusters) error {
+	targetEnv := report.Env
+	freezes := p.config.ActiveFreezes(targetEnv, time.Now())
+	if err := p.checkFreezes(freezes, targetEnv); err != nil {
+		return err
+	}
+
+	manifestSource, err := p.config.Pipeline.ManifestSource(targetEnv)
+	if err != nil {
+		return fmt.Errorf("pipeline.ManifestSource: %w", err)
+	}
+
+	var changes []detect.WorkloadChange
+	drifted := map[string]map[string]bool{}
+	for _, d := range report.Drifts {
+		if _, exists := drifted[d.Cluster]; !exists {
+			drifted[d.Cluster] = map[string]bool{}
+		}
+		drifted[d.Cluster][d.Workload] = true
+
+		if !containsChange(changes, d.Workload) {
+			changes = append(changes, detect.WorkloadChange{Op: detect.OperationCopy, W: detect.Workload{Name: d.Workload, SourceEnv: string(manifestSource)}})
+		}
+	}
+
+	resync, err := promotion.NewPromotionResync(ctx, p.logger, targetEnv, p.manifestRepo, changes, clusters, p.registry, p.manual)
+	if err != nil {
+		return err
+	}
+
+	changes, clusters, err = resync.Changes()
+	if err != nil {
+		return err
+	}
+
+	summary := Summary{Env: targetEnv}
+	changes = p.unfrozenChanges(changes, freezes, &summary)
+	changes, err = p.soakedChanges(changes, targetEnv, &summary)
+	report.Postponed = summary.Postponed
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		p.logger.Info(ResyncHeldMsg)
+		return nil
+	}
+
+	clusters, err = p.currentWave(ctx, changes, clusters, targetEnv)
+	if err != nil {
+		return err
+	}
+
+	branchName, err := p.manifestRepo.NewPromoteBranch()
+	if err != nil {
+		return err
+	}
+
+	results := promotion.Results{}
+	for _, cluster := range clusters {
+		var clusterChanges []detect.WorkloadChange
+		for _, change := range changes {
+			if drifted[cluster.Name()][change.W.Name] {
+				clusterChanges = append(clusterChanges, change)
+			}
+		}
+		if len(clusterChanges) == 0 {
+			continue
+		}
+
+		clusterResults, _, err := p.performChanges(ctx, clusterChanges, clusterconf.Clusters{cluster}, targetEnv)
+		if err != nil {
+			return err
+		}
+		for name, workloads := range clusterResults {
+			results[name] = workloads
+		}
+	}
+
+	if len(results) == 0 {
+		p.logger.Info(ResyncHeldMsg)
+		return nil
+	}
+
+	if err := resync.AfterChanges(results, clusters); err != nil {
+		return err
+	}
+
+	pr := p.prBuilder.
+		ForEnv(targetEnv).
+		WithFreezeOverride(p.freezeOverride, overriddenFreezes(freezes, results, p.freezeOverride)).
+		WithSkippedWorkloads(resync.Skipped()).
+		Build(results, resync.SourceCommits(), resync.Kind())
+
+	pr.Reviewers, pr.TeamReviewers, err = p.workloadOwners(results)
+	if err != nil {
+		return err
+	}
+
+	if err := p.manifestRepo.Commit(pr.CommitMessage); err != nil {
+		return err
+	}
+
+	if err := p.manifestRepo.RaisePromotion(ctx, branchName, pr, resync.Assignes()); err != nil {
+		return err
+	}
+
+	report.PullRequests = append(report.PullRequests, pr.Title)
+	return nil
+}
+
+func containsChange(changes []detect.WorkloadChange, workload string) bool {
+	for _, change := range changes {
+		if change.W.Name == workload {
+			return true
+		}
+	}
+	return false
+}
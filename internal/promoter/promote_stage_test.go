@@ -21,6 +21,7 @@ import (
 	"github.com/form3tech/k8s-promoter/internal/testutils"
 
 	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
@@ -49,6 +50,8 @@ resources:{{range $element := .}}
 	user3     = "test-user-3"
 	user4     = "test-user-4"
 	buildUser = "github-builduser-form3"
+
+	configCommit = "c0ffee0c0ffee0c0ffee0c0ffee0c0ffee0c0ffe"
 )
 
 type CommitRange struct {
@@ -75,6 +78,9 @@ type PromoteStage struct {
 	args        promoter.Args
 	commitRange CommitRange
 	err         error
+	summaries   []promoter.Summary
+	expired     []promoter.ExpiredExclusion
+	drifts      []promoter.Drift
 
 	repository *git.Repository
 	githubFake *testutils.GithubFake
@@ -82,6 +88,7 @@ type PromoteStage struct {
 	pr         gh.PullRequest
 	prCommit   *object.Commit
 	parentHash plumbing.Hash
+	commitAge  time.Duration
 
 	expSourceCommits []SourceCommit
 }
@@ -113,9 +120,11 @@ func (s *PromoteStage) a_fake_github_server() *PromoteStage {
 		testutils.WithOrgAndRepo(s.args.CloneArgs.Owner, s.args.CloneArgs.Repo),
 		testutils.WithGitFakeForRepo(s.repository, s.args.CloneArgs.Auth),
 		testutils.WithRepoAssignees("test-user-1", "test-user-2", "test-user-3", "test-user-4"),
+		testutils.WithRepoTeams("payments"),
 		testutils.WithConfigRepo(s.args.ConfigRepository),
 	)
 	githubFake.StartServer().InitClient()
+	githubFake.SetConfigRef(promoter.DefaultConfigRef, configCommit)
 
 	_, err := s.repository.CreateRemote(&config.RemoteConfig{
 		Name: "origin",
@@ -164,6 +173,60 @@ func (s *PromoteStage) a_clusters_configuration_file() *PromoteStage {
 	return s
 }
 
+func (s *PromoteStage) a_clusters_configuration_directory_with_a_file_per_environment() *PromoteStage {
+	for _, env := range []environment.Env{environment.Development, environment.Test, environment.Production} {
+		clustersYAML, err := toYAML(allClusters().Filter(clusterconf.ByEnvironment(env)))
+		require.NoError(s.t, err)
+
+		s.githubFake.SetContent(fmt.Sprintf("clusters/%s.yaml", env), clustersYAML)
+	}
+
+	s.args.ConfigPath = "clusters"
+	return s
+}
+
+func (s *PromoteStage) local_clusters_configuration_files_per_environment_matching(pattern string) *PromoteStage {
+	dir := s.t.TempDir()
+	for _, env := range []environment.Env{environment.Development, environment.Test, environment.Production} {
+		clustersYAML, err := toYAML(allClusters().Filter(clusterconf.ByEnvironment(env)))
+		require.NoError(s.t, err)
+
+		require.NoError(s.t, os.MkdirAll(filepath.Join(dir, string(env)), 0o700))
+		require.NoError(s.t, os.WriteFile(filepath.Join(dir, string(env), "clusters.yaml"), []byte(clustersYAML), 0o600))
+	}
+
+	s.args.ConfigSource = promoter.ConfigSourceLocal
+	s.args.ConfigPath = filepath.Join(dir, pattern)
+	return s
+}
+
+func (s *PromoteStage) a_local_clusters_configuration_file() *PromoteStage {
+	clustersYAML, err := toYAML(allClusters())
+	require.NoError(s.t, err)
+
+	configPath := filepath.Join(s.t.TempDir(), "clusters.yaml")
+	require.NoError(s.t, os.WriteFile(configPath, []byte(clustersYAML), 0o600))
+
+	s.args.ConfigSource = promoter.ConfigSourceLocal
+	s.args.ConfigPath = configPath
+	return s
+}
+
+func (s *PromoteStage) a_clusters_configuration_file_in_the_manifest_repository() *PromoteStage {
+	clustersYAML, err := toYAML(allClusters())
+	require.NoError(s.t, err)
+
+	wt, err := s.repository.Worktree()
+	require.NoError(s.t, err)
+
+	testutils.WriteFile(s.t, wt.Filesystem, "config/clusters.yaml", clustersYAML)
+	s.CommitChange("Adding clusters configuration", user2, user2, false, false)
+
+	s.args.ConfigSource = promoter.ConfigSourceManifestRepo
+	s.args.ConfigPath = "config/clusters.yaml"
+	return s
+}
+
 func (s *PromoteStage) a_clusters_configuration_file_with_new_dev_cluster() *PromoteStage {
 	clusters := allClusters()
 	clusters = append(clusters, cluster("development", "dev1", "cloud1"))
@@ -176,6 +239,56 @@ func (s *PromoteStage) a_clusters_configuration_file_with_new_dev_cluster() *Pro
 	return s
 }
 
+// a_relocated_dev_cluster_in_the_manifest_repository_configuration moves the folders of dev2-cloud1 in the
+// clusters configuration of the manifest repository, see a_clusters_configuration_file_in_the_manifest_repository.
+func (s *PromoteStage) a_relocated_dev_cluster_in_the_manifest_repository_configuration() *PromoteStage {
+	clusters := allClusters()
+	clusters[0].Spec.ManifestFolder = path("/promoted/development/cloud1/dev2")
+	clusters[0].Spec.ConfigFolder = path("/config/development/cloud1/dev2")
+
+	clustersYAML, err := toYAML(clusters)
+	require.NoError(s.t, err)
+
+	wt, err := s.repository.Worktree()
+	require.NoError(s.t, err)
+
+	testutils.WriteFile(s.t, wt.Filesystem, "config/clusters.yaml", clustersYAML)
+	s.CommitChange("Relocating dev2-cloud1", user2, user2, false, false)
+	return s
+}
+
+func (s *PromoteStage) a_clusters_configuration_file_with_paused_cluster(name, reason string) *PromoteStage {
+	clusters := allClusters()
+	for i := range clusters {
+		if clusters[i].Name() == name {
+			clusters[i].Spec.Paused = &clusterconf.Pause{Reason: reason}
+		}
+	}
+
+	clustersYAML, err := toYAML(clusters)
+	require.NoError(s.t, err)
+
+	s.githubFake.SetContent("clusters.yaml", clustersYAML)
+	s.args.ConfigPath = "clusters.yaml"
+	return s
+}
+
+func (s *PromoteStage) a_clusters_configuration_file_with_paused_environment(env, reason string) *PromoteStage {
+	clusters := allClusters()
+	for i := range clusters {
+		if clusters[i].Environment() == environment.Env(env) {
+			clusters[i].Spec.Paused = &clusterconf.Pause{Reason: reason}
+		}
+	}
+
+	clustersYAML, err := toYAML(clusters)
+	require.NoError(s.t, err)
+
+	s.githubFake.SetContent("clusters.yaml", clustersYAML)
+	s.args.ConfigPath = "clusters.yaml"
+	return s
+}
+
 func (s *PromoteStage) a_clusters_configuration_file_with_new_test_clusters() *PromoteStage {
 	clusters := allClusters()
 	clusters = append(clusters, cluster("test", "new-test-cluster-1", "cloud1"))
@@ -202,6 +315,192 @@ func (s *PromoteStage) a_clusters_configuration_file_with_new_prod_clusters() *P
 	return s
 }
 
+func (s *PromoteStage) a_clusters_configuration_file_with_regional_production() *PromoteStage {
+	environments := []clusterconf.Environment{
+		environmentDoc("development", ""),
+		environmentDoc("test", ""),
+		environmentDoc("prod-eu", "test"),
+		environmentDoc("prod-us", "test"),
+	}
+	clusters := allClusters().Filter(func(c clusterconf.Cluster) bool {
+		return c.Environment() != environment.Production
+	})
+	clusters = append(clusters, cluster("prod-eu", "prod-eu1", "cloud1"))
+	clusters = append(clusters, cluster("prod-us", "prod-us1", "cloud2"))
+
+	clustersYAML, err := toYAML(clusters)
+	require.NoError(s.t, err)
+
+	s.githubFake.SetContent("clusters.yaml", environmentsToYAML(s.t, environments)+clustersYAML)
+	s.args.ConfigPath = "clusters.yaml"
+	return s
+}
+
+func (s *PromoteStage) a_clusters_configuration_file_grouping_test_clusters_by_cloud() *PromoteStage {
+	test := environmentDoc("test", "")
+	test.Spec.Grouping = &clusterconf.GroupingPolicy{
+		Strategy: clusterconf.GroupByLabel,
+		Label:    "cloud",
+	}
+	environments := []clusterconf.Environment{
+		environmentDoc("development", ""),
+		test,
+		environmentDoc("production", ""),
+	}
+
+	clustersYAML, err := toYAML(allClusters())
+	require.NoError(s.t, err)
+
+	s.githubFake.SetContent("clusters.yaml", environmentsToYAML(s.t, environments)+clustersYAML)
+	s.args.ConfigPath = "clusters.yaml"
+	return s
+}
+
+func (s *PromoteStage) a_clusters_configuration_file_with_production_canary_wave() *PromoteStage {
+	production := environmentDoc("production", "")
+	production.Spec.Waves = []clusterconf.Wave{
+		{Name: "canary", Clusters: []string{"prod1-cloud1"}},
+	}
+	environments := []clusterconf.Environment{
+		environmentDoc("development", ""),
+		environmentDoc("test", ""),
+		production,
+	}
+
+	clustersYAML, err := toYAML(allClusters())
+	require.NoError(s.t, err)
+
+	s.githubFake.SetContent("clusters.yaml", environmentsToYAML(s.t, environments)+clustersYAML)
+	s.args.ConfigPath = "clusters.yaml"
+	return s
+}
+
+func (s *PromoteStage) a_clusters_configuration_file_with_test_soak_time(soakTime time.Duration) *PromoteStage {
+	test := environmentDoc("test", "")
+	test.Spec.MinSoakTime = soakTime
+	environments := []clusterconf.Environment{
+		environmentDoc("development", ""),
+		test,
+		environmentDoc("production", ""),
+	}
+
+	clustersYAML, err := toYAML(allClusters())
+	require.NoError(s.t, err)
+
+	s.githubFake.SetContent("clusters.yaml", environmentsToYAML(s.t, environments)+clustersYAML)
+	s.args.ConfigPath = "clusters.yaml"
+	return s
+}
+
+func (s *PromoteStage) a_clusters_configuration_file_with_test_soak_time_and_paused_cluster(soakTime time.Duration, name string) *PromoteStage {
+	test := environmentDoc("test", "")
+	test.Spec.MinSoakTime = soakTime
+	environments := []clusterconf.Environment{
+		environmentDoc("development", ""),
+		test,
+		environmentDoc("production", ""),
+	}
+
+	clusters := allClusters()
+	for i := range clusters {
+		if clusters[i].Name() == name {
+			clusters[i].Spec.Paused = &clusterconf.Pause{Reason: "maintenance"}
+		}
+	}
+
+	clustersYAML, err := toYAML(clusters)
+	require.NoError(s.t, err)
+
+	s.githubFake.SetContent("clusters.yaml", environmentsToYAML(s.t, environments)+clustersYAML)
+	s.args.ConfigPath = "clusters.yaml"
+	return s
+}
+
+func (s *PromoteStage) a_clusters_configuration_file_with_new_prod_cluster_and_test_soak_time(soakTime time.Duration) *PromoteStage {
+	test := environmentDoc("test", "")
+	test.Spec.MinSoakTime = soakTime
+	environments := []clusterconf.Environment{
+		environmentDoc("development", ""),
+		test,
+		environmentDoc("production", ""),
+	}
+
+	clusters := allClusters()
+	clusters = append(clusters, cluster("production", "new-prd", "cloud1"))
+
+	clustersYAML, err := toYAML(clusters)
+	require.NoError(s.t, err)
+
+	s.githubFake.SetContent("clusters.yaml", environmentsToYAML(s.t, environments)+clustersYAML)
+	s.args.ConfigPath = "clusters.yaml"
+	return s
+}
+
+func (s *PromoteStage) a_clusters_configuration_file_with_production_freeze(workloads ...string) *PromoteStage {
+	production := environmentDoc("production", "")
+	production.Spec.Freezes = []clusterconf.FreezeWindow{{
+		Name:      "quarter-end",
+		Start:     time.Now().Add(-time.Hour).UTC().Truncate(time.Second),
+		End:       time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		Workloads: workloads,
+	}}
+	environments := []clusterconf.Environment{
+		environmentDoc("development", ""),
+		environmentDoc("test", ""),
+		production,
+	}
+
+	clustersYAML, err := toYAML(allClusters())
+	require.NoError(s.t, err)
+
+	s.githubFake.SetContent("clusters.yaml", environmentsToYAML(s.t, environments)+clustersYAML)
+	s.args.ConfigPath = "clusters.yaml"
+	return s
+}
+
+func (s *PromoteStage) a_clusters_configuration_file_with_new_prod_cluster_and_production_freeze(workloads ...string) *PromoteStage {
+	production := environmentDoc("production", "")
+	production.Spec.Freezes = []clusterconf.FreezeWindow{{
+		Name:      "quarter-end",
+		Start:     time.Now().Add(-time.Hour).UTC().Truncate(time.Second),
+		End:       time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		Workloads: workloads,
+	}}
+	environments := []clusterconf.Environment{
+		environmentDoc("development", ""),
+		environmentDoc("test", ""),
+		production,
+	}
+
+	clusters := allClusters()
+	clusters = append(clusters, cluster("production", "new-prd", "cloud1"))
+
+	clustersYAML, err := toYAML(clusters)
+	require.NoError(s.t, err)
+
+	s.githubFake.SetContent("clusters.yaml", environmentsToYAML(s.t, environments)+clustersYAML)
+	s.args.ConfigPath = "clusters.yaml"
+	return s
+}
+
+func (s *PromoteStage) a_clusters_configuration_file_with_namespaced_workloads() *PromoteStage {
+	clustersYAML, err := toYAML(allClusters())
+	require.NoError(s.t, err)
+
+	clustersYAML += `---
+version: v0.1
+configType: Layout
+spec:
+  source: flux/manifests/{{.workload}}
+  promoted: flux/promoted/{{.environment}}/{{.cluster}}/{{.cloud}}/{{.workload}}
+  workloadDepth: 2
+`
+
+	s.githubFake.SetContent("clusters.yaml", clustersYAML)
+	s.args.ConfigPath = "clusters.yaml"
+	return s
+}
+
 func (s *PromoteStage) a_clusters_file_with_only_dev_clusters() *PromoteStage {
 	clusters := []clusterconf.Cluster{
 		cluster("development", "dev2", "cloud1"),
@@ -233,12 +532,130 @@ spec:
 `)
 }
 
+func (s *PromoteStage) an_expired_cloud1_only_config_file_for_foo() *PromoteStage {
+	return s.a_workload_config_file_for_foo(
+		`version: "v0.1"
+configType: Workload
+metadata:
+  name: foo
+  description: "A workload with an expired exclusion"
+spec:
+  exclusions:
+  - key: "cloud"
+    operator: "NotEqual"
+    value: "cloud1"
+    until: 2020-01-01T00:00:00Z
+    reason: "INC-42 cloud2 outage"
+`)
+}
+
+func (s *PromoteStage) an_unrestricted_config_file_for_foo() *PromoteStage {
+	return s.a_workload_config_file_for_foo(
+		`version: "v0.1"
+configType: Workload
+metadata:
+  name: foo
+  description: "A workload with no exclusions"
+spec:
+  exclusions: []
+`)
+}
+
+func (s *PromoteStage) an_owned_config_file_for_foo() *PromoteStage {
+	return s.a_workload_config_file_for_foo(
+		`version: "v0.1"
+configType: Workload
+metadata:
+  name: foo
+  description: "A workload owned by the payments team"
+  owners:
+    users: ["test-user-1", "test-user-4", "not-a-collaborator"]
+    teams: ["payments", "not-a-team"]
+spec:
+  exclusions: []
+`)
+}
+
+func (s *PromoteStage) a_manual_development_promotion_config_file_for_foo() *PromoteStage {
+	return s.a_workload_config_file_for_foo(
+		`version: "v0.1"
+configType: Workload
+metadata:
+  name: foo
+  description: "A workload promoted to development manually"
+spec:
+  exclusions: []
+  promotion:
+    manual: ["development"]
+`)
+}
+
+func (s *PromoteStage) a_test_only_promotion_config_file_for_foo() *PromoteStage {
+	return s.a_workload_config_file_for_foo(
+		`version: "v0.1"
+configType: Workload
+metadata:
+  name: foo
+  description: "A workload only promoted to test"
+spec:
+  exclusions: []
+  promotion:
+    environments: ["test"]
+`)
+}
+
+func (s *PromoteStage) a_max_clusters_per_PR_config_file_for_foo(max int) *PromoteStage {
+	return s.a_workload_config_file_for_foo(fmt.Sprintf(
+		`version: "v0.1"
+configType: Workload
+metadata:
+  name: foo
+  description: "A workload promoted to a few clusters at a time"
+spec:
+  exclusions: []
+  promotion:
+    maxClustersPerPR: %d
+`, max))
+}
+
+func (s *PromoteStage) a_cloud2_only_inclusion_config_file_for_foo() *PromoteStage {
+	return s.a_workload_config_file_for_foo(
+		`version: "v0.1"
+configType: Workload
+metadata:
+  name: foo
+  description: "A workload deployed to cloud2 only"
+spec:
+  inclusions:
+  - key: "cloud"
+    operator: "In"
+    values: ["cloud2"]
+`)
+}
+
 func (s *PromoteStage) a_workload_config_file_for_foo(content string) *PromoteStage {
+	return s.a_workload_config_file_for("foo", content)
+}
+
+func (s *PromoteStage) a_config_file_for_bar_depending_on_foo() *PromoteStage {
+	return s.a_workload_config_file_for("bar",
+		`version: "v0.1"
+configType: Workload
+metadata:
+  name: bar
+  description: "A workload depending on foo"
+spec:
+  exclusions: []
+  dependsOn: ["foo"]
+`)
+}
+
+func (s *PromoteStage) a_workload_config_file_for(workload, content string) *PromoteStage {
 	wt, err := s.repository.Worktree()
 	require.NoError(s.t, err)
 
 	fs := wt.Filesystem
-	testutils.WriteFile(s.t, fs, path("/manifests/foo/workload.yaml"), content)
+	testutils.WriteFile(s.t, fs, path(fmt.Sprintf("/manifests/%s/workload.yaml", workload)), content)
 
 	s.CommitChange("Workload config file", user0, user0, false, false)
 	return s
@@ -282,6 +699,19 @@ func (s *PromoteStage) new_source_manifests_for_the_workload(workload string) *P
 	return s.source_manifests_for_the_workload(workload, newContent, user2, user3, true)
 }
 
+// an_orphaned_cluster adds the manifest and config folders of a cluster which is not in the clusters configuration.
+func (s *PromoteStage) an_orphaned_cluster(env, name, cloud string) *PromoteStage {
+	s.a_promoted_manifest_for_the_workload("foo", env, name, cloud, "orphaned content")
+
+	wt, err := s.repository.Worktree()
+	require.NoError(s.t, err)
+	testutils.WriteFile(s.t, wt.Filesystem, path(fmt.Sprintf("/config/%s/%s/%s/kustomization.yaml", env, name, cloud)), "test kustomization content")
+
+	s.CommitChange(fmt.Sprintf("Adding orphaned cluster %s-%s", name, cloud), user2, user2, false, false)
+
+	return s
+}
+
 func (s *PromoteStage) with_config_for_the_workload(workload string) *PromoteStage {
 	wt, err := s.repository.Worktree()
 	require.NoError(s.t, err)
@@ -361,6 +791,12 @@ func (s *PromoteStage) commit_range_end() *PromoteStage {
 	return s
 }
 
+// commits_made_ago backdates the commits made from then on by age, e.g. for them to have soaked.
+func (s *PromoteStage) commits_made_ago(age time.Duration) *PromoteStage {
+	s.commitAge = age
+	return s
+}
+
 func (s *PromoteStage) empty_commit_range() *PromoteStage {
 	s.commitRange.Start = s.parentHash.String()
 	s.commitRange.End = s.parentHash.String()
@@ -373,15 +809,80 @@ func (s *PromoteStage) old_dev_manifests_for_the_workload_foo() *PromoteStage {
 }
 
 func (s *PromoteStage) old_dev_manifests_for_the_workload_bar() *PromoteStage {
-	s.a_promoted_manifest_for_the_workload("bar", "development", "dev2", "cloud1", oldContent)
-	s.a_promoted_manifest_for_the_workload("bar", "development", "dev3", "cloud1", oldContent)
-	s.a_promoted_manifest_for_the_workload("bar", "development", "dev4", "cloud2", oldContent)
+	return s.old_dev_manifests_for_the_workload("bar")
+}
+
+func (s *PromoteStage) old_dev_manifests_for_the_workload(workload string) *PromoteStage {
+	s.a_promoted_manifest_for_the_workload(workload, "development", "dev2", "cloud1", oldContent)
+	s.a_promoted_manifest_for_the_workload(workload, "development", "dev3", "cloud1", oldContent)
+	s.a_promoted_manifest_for_the_workload(workload, "development", "dev4", "cloud2", oldContent)
+
+	s.CommitChange("Commit initial manifests", buildUser, buildUser, false, false)
+
+	return s
+}
+
+func (s *PromoteStage) old_test_manifests_for_the_workload(workload string) *PromoteStage {
+	s.a_promoted_manifest_for_the_workload(workload, "test", "test1", "cloud1", oldContent)
+	s.a_promoted_manifest_for_the_workload(workload, "test", "test2", "cloud1", oldContent)
+	s.a_promoted_manifest_for_the_workload(workload, "test", "test3", "cloud2", oldContent)
+
+	s.CommitChange("Commit initial manifests", buildUser, buildUser, false, false)
+
+	return s
+}
+
+func (s *PromoteStage) old_dev_manifests_for_the_workload_foo_in_cloud1() *PromoteStage {
+	s.a_promoted_manifest_for_the_workload("foo", "development", "dev2", "cloud1", oldContent)
+	s.a_promoted_manifest_for_the_workload("foo", "development", "dev3", "cloud1", oldContent)
 
 	s.CommitChange("Commit initial manifests", buildUser, buildUser, false, false)
 
 	return s
 }
 
+// dev_manifests_for_the_workload_foo_excluded_from_cloud2 merges the development promotion of a cloud1 only
+// workload.yaml for foo: it is copied to the clusters in cloud1 and foo is removed from the cluster in cloud2.
+func (s *PromoteStage) dev_manifests_for_the_workload_foo_excluded_from_cloud2() *PromoteStage {
+	wt, err := s.repository.Worktree()
+	require.NoError(s.t, err)
+
+	fs := wt.Filesystem
+	config, err := util.ReadFile(fs, path("/manifests/foo/workload.yaml"))
+	require.NoError(s.t, err)
+
+	testutils.WriteFile(s.t, fs, path("/promoted/development/dev2/cloud1/foo/workload.yaml"), string(config))
+	testutils.WriteFile(s.t, fs, path("/promoted/development/dev3/cloud1/foo/workload.yaml"), string(config))
+	testutils.DeleteFile(s.t, fs, path("/promoted/development/dev4/cloud2/foo/file"))
+
+	s.CommitChange("Retarget foo in development", buildUser, buildUser, true, false)
+
+	return s
+}
+
+// a_hand_edited_manifest_for_the_workload_foo edits the manifest of foo promoted to a cluster, outside of promotions.
+func (s *PromoteStage) a_hand_edited_manifest_for_the_workload_foo(env, cluster, cloud string) *PromoteStage {
+	return s.a_file_with_content(path(fmt.Sprintf("/promoted/%s/%s/%s/foo/file", env, cluster, cloud)), "hand-edited-content")
+}
+
+func (s *PromoteStage) a_deployment_for_the_workload_foo_in_the_source_and_development() *PromoteStage {
+	wt, err := s.repository.Worktree()
+	require.NoError(s.t, err)
+
+	deployment := "kind: Deployment\nmetadata:\n  name: foo\n"
+	testutils.WriteFile(s.t, wt.Filesystem, path("/manifests/foo/deployment.yaml"), deployment)
+	for _, dir := range []string{"dev2/cloud1", "dev3/cloud1", "dev4/cloud2"} {
+		testutils.WriteFile(s.t, wt.Filesystem, path(fmt.Sprintf("/promoted/development/%s/foo/deployment.yaml", dir)), deployment)
+	}
+
+	s.CommitChange("Adding foo deployment", buildUser, buildUser, false, false)
+	return s
+}
+
+func (s *PromoteStage) a_cosmetic_change_to_the_deployment_of_foo() *PromoteStage {
+	return s.a_file_with_content(path("/manifests/foo/deployment.yaml"), "# the foo deployment\nmetadata: {name: foo}\nkind: Deployment\n")
+}
+
 func (s *PromoteStage) new_dev_manifests_for_the_workload_foo() *PromoteStage {
 	return s.dev_manifests_for_the_workload_foo(newContent, true)
 }
@@ -400,6 +901,15 @@ func (s *PromoteStage) old_test_manifests_for_the_workload_foo() *PromoteStage {
 	return s.test_manifests_for_the_workload_foo(oldContent, false)
 }
 
+func (s *PromoteStage) old_regional_prod_manifests_for_the_workload_foo() *PromoteStage {
+	s.a_promoted_manifest_for_the_workload("foo", "prod-eu", "prod-eu1", "cloud1", oldContent)
+	s.a_promoted_manifest_for_the_workload("foo", "prod-us", "prod-us1", "cloud2", oldContent)
+
+	s.CommitChange("Commit initial manifests", buildUser, buildUser, false, false)
+
+	return s
+}
+
 func (s *PromoteStage) old_prod_manifests_for_the_workload_foo() *PromoteStage {
 	s.a_promoted_manifest_for_the_workload("foo", "production", "prod1", "cloud1", oldContent)
 	s.a_promoted_manifest_for_the_workload("foo", "production", "prod2", "cloud1", oldContent)
@@ -410,6 +920,16 @@ func (s *PromoteStage) old_prod_manifests_for_the_workload_foo() *PromoteStage {
 	return s
 }
 
+func (s *PromoteStage) prod_manifests_for_the_workload_foo(content string, clusters ...string) *PromoteStage {
+	for _, c := range clusters {
+		s.a_promoted_manifest_for_the_workload("foo", "production", c, "cloud1", content)
+	}
+
+	s.CommitChange("Commit production manifests", buildUser, buildUser, false, false)
+
+	return s
+}
+
 func (s *PromoteStage) new_test_manifests_for_the_workload_foo() *PromoteStage {
 	return s.test_manifests_for_the_workload_foo(newContent, true)
 }
@@ -462,7 +982,7 @@ func (s *PromoteStage) CommitChange(msg string, author string, committer string,
 	hash, err := wt.Commit(msg, &git.CommitOptions{
 		All: true,
 		Author: &object.Signature{
-			When: time.Now(),
+			When: time.Now().Add(-s.commitAge),
 		},
 	})
 	require.NoError(s.t, err)
@@ -494,6 +1014,50 @@ func (s *PromoteStage) with_env(env environment.Env) *PromoteStage {
 	return s
 }
 
+func (s *PromoteStage) with_envs(envs ...environment.Env) *PromoteStage {
+	var names []string
+	for _, env := range envs {
+		names = append(names, string(env))
+	}
+	s.args.TargetEnv = strings.Join(names, ",")
+	return s
+}
+
+func (s *PromoteStage) with_freeze_override(reason string) *PromoteStage {
+	s.args.FreezeOverride = reason
+	return s
+}
+
+func (s *PromoteStage) with_decommission() *PromoteStage {
+	s.args.Decommission = true
+	return s
+}
+
+func (s *PromoteStage) with_manual() *PromoteStage {
+	s.args.Manual = true
+	return s
+}
+
+func (s *PromoteStage) with_validate() *PromoteStage {
+	s.args.Validate = true
+	return s
+}
+
+func (s *PromoteStage) with_drift() *PromoteStage {
+	s.args.Drift = true
+	return s
+}
+
+func (s *PromoteStage) with_semantic_diff() *PromoteStage {
+	s.args.SemanticDiff = true
+	return s
+}
+
+func (s *PromoteStage) with_resync() *PromoteStage {
+	s.args.Resync = true
+	return s
+}
+
 func (s *PromoteStage) with_no_issue_users(users ...string) *PromoteStage {
 	s.args.NoIssueUsers = users
 	return s
@@ -525,7 +1089,35 @@ func (s *PromoteStage) is_called() *PromoteStage {
 	prom, err := promoter.NewPromoter(context.Background(), &s.args, log, s.githubFake.Client, 0)
 	require.NoError(s.t, err)
 
-	s.err = prom.Promote(context.Background(), s.args.TargetEnv)
+	if s.args.Validate {
+		s.expired, s.err = prom.Validate()
+		return s
+	}
+
+	if s.err = prom.ValidateTargets(s.args.TargetEnvs()); s.err != nil {
+		return s
+	}
+
+	if s.args.Drift {
+		for _, target := range s.args.TargetEnvs() {
+			var report promoter.DriftReport
+			report, s.err = prom.Drift(context.Background(), target)
+			s.drifts = append(s.drifts, report.Drifts...)
+			if s.err != nil {
+				break
+			}
+		}
+		return s
+	}
+
+	for _, target := range s.args.TargetEnvs() {
+		var summary promoter.Summary
+		summary, s.err = prom.Promote(context.Background(), target)
+		s.summaries = append(s.summaries, summary)
+		if s.err != nil {
+			break
+		}
+	}
 	return s
 }
 
@@ -536,6 +1128,11 @@ func (s *PromoteStage) promote_succeeds() *PromoteStage {
 	return s
 }
 
+func (s *PromoteStage) promote_fails_with(err error) *PromoteStage {
+	require.ErrorIs(s.t, s.err, err)
+	return s
+}
+
 func (s *PromoteStage) a_message_is_logged(msg string, level logrus.Level) *PromoteStage {
 	logs := s.logBuffer.String()
 
@@ -546,6 +1143,36 @@ func (s *PromoteStage) a_message_is_logged(msg string, level logrus.Level) *Prom
 	return s
 }
 
+func (s *PromoteStage) the_expired_exclusions_are(exclusions ...string) *PromoteStage {
+	var expired []string
+	for _, exc := range s.expired {
+		expired = append(expired, exc.String())
+	}
+	require.Equal(s.t, exclusions, expired)
+	return s
+}
+
+func (s *PromoteStage) the_drifts_are(drifts ...string) *PromoteStage {
+	var got []string
+	for _, d := range s.drifts {
+		got = append(got, d.String())
+	}
+	require.Equal(s.t, drifts, got)
+	return s
+}
+
+func (s *PromoteStage) the_summary_lists_postponed_workloads(workloads ...string) *PromoteStage {
+	var postponed []string
+	for _, summary := range s.summaries {
+		for _, w := range summary.Postponed {
+			postponed = append(postponed, w.Workload)
+		}
+	}
+
+	require.Equal(s.t, workloads, postponed)
+	return s
+}
+
 func (s *PromoteStage) the_remote_repository_is_not_updated_with_new_branch() *PromoteStage {
 	return s.the_remote_repository_is_updated_with_new_branches(0)
 }
@@ -591,6 +1218,16 @@ func (s *PromoteStage) a_PR_for(workload string, env environment.Env, clusters .
 	return s
 }
 
+func (s *PromoteStage) has_description_referencing_the_manifest_repository_config() *PromoteStage {
+	return s.has_description_containing(fmt.Sprintf(
+		"Clusters configuration: `config/clusters.yaml` in form3tech/k8s-promoter at %s\n", s.commitRange.End))
+}
+
+func (s *PromoteStage) has_description_containing(text string) *PromoteStage {
+	require.Contains(s.t, s.pr.GetBody(), text)
+	return s
+}
+
 func (s *PromoteStage) the_number_of_raised_PRs_equals(n int) *PromoteStage {
 	assert.Equal(s.t, n, len(s.githubFake.CreatedPullRequests), "the number of raised PRs doesn't match the expectation")
 	return s
@@ -676,6 +1313,20 @@ func (s *PromoteStage) has_assignees(assignees ...string) *PromoteStage {
 	return s
 }
 
+func (s *PromoteStage) has_reviewers(users []string, teams []string) *PromoteStage {
+	reviewers, teamReviewers := s.githubFake.FindPRReviewers(s.pr.GetNumber())
+	require.Equal(s.t, users, reviewers)
+	require.Equal(s.t, teams, teamReviewers)
+	return s
+}
+
+func (s *PromoteStage) has_no_reviewers() *PromoteStage {
+	reviewers, teamReviewers := s.githubFake.FindPRReviewers(s.pr.GetNumber())
+	require.Empty(s.t, reviewers, "found reviewers on PR #%d", s.pr.GetNumber())
+	require.Empty(s.t, teamReviewers, "found team reviewers on PR #%d", s.pr.GetNumber())
+	return s
+}
+
 func (s *PromoteStage) has_no_assignees() *PromoteStage {
 	res := s.githubFake.FindPRAssignees(s.pr.GetNumber())
 	require.Empty(s.t, res, "found assignees on PR #%d", s.pr.GetNumber())
@@ -783,6 +1434,18 @@ func path(p string) string {
 	return filepath.Join("/flux", p)
 }
 
+func (s *PromoteStage) that_removes_the_folders(folders ...string) *PromoteStage {
+	tree, err := s.prCommit.Tree()
+	require.NoError(s.t, err)
+
+	for _, folder := range folders {
+		_, err := tree.Tree(strings.TrimLeft(path(folder), "/"))
+		require.ErrorIs(s.t, err, object.ErrDirectoryNotFound, "folder %s is still in the repository", folder)
+	}
+
+	return s
+}
+
 func (s *PromoteStage) that_deletes_manifests(workload string, clusterManifestDirs ...string) *PromoteStage {
 	tree, err := s.prCommit.Tree()
 	require.NoError(s.t, err)
@@ -940,6 +1603,28 @@ func cluster(env, name, cloud string) clusterconf.Cluster {
 	}
 }
 
+// environmentsToYAML returns the environment documents, each followed by a separator.
+func environmentsToYAML(t *testing.T, environments []clusterconf.Environment) string {
+	builder := strings.Builder{}
+	for i := range environments {
+		dump, err := yaml.Marshal(&environments[i])
+		require.NoError(t, err)
+
+		builder.WriteString(string(dump))
+		builder.WriteString("---\n")
+	}
+	return builder.String()
+}
+
+func environmentDoc(name, source string) clusterconf.Environment {
+	return clusterconf.Environment{
+		Version:    "v0.1",
+		ConfigType: "Environment",
+		Metadata:   clusterconf.EnvironmentMetadata{Name: name},
+		Spec:       clusterconf.EnvironmentSpec{Source: source},
+	}
+}
+
 func toYAML(clusters clusterconf.Clusters) (string, error) {
 	builder := strings.Builder{}
 	count := len(clusters) - 1
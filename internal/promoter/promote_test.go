@@ -2,9 +2,11 @@ package promoter_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/form3tech/k8s-promoter/internal/environment"
 	"github.com/form3tech/k8s-promoter/internal/promoter"
+	"github.com/form3tech/k8s-promoter/internal/promotion"
 	"github.com/sirupsen/logrus"
 )
 
@@ -618,6 +620,7 @@ func Test_PromotionExcludeUserIssueAssignment(t *testing.T) {
 		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
 		has_labels("k8s-promoter/automated-promotion").
 		has_assignees("test-user-3").
+		has_no_reviewers().
 		has_branch().with_one_commit().with_source_commit().
 		that_contains_updated_foo_manifests_for_clusters(
 			"/promoted/development/dev2/cloud1",
@@ -632,15 +635,16 @@ func Test_PromotionExcludeUserIssueAssignment(t *testing.T) {
 		that_has_kustomization_for_workloads("/promoted/development/dev4/cloud2", "foo")
 }
 
-func Test_PromotionOfWorkloadsWithWorkloadExclusion(t *testing.T) {
+func Test_PromotionRequestsReviewsFromWorkloadOwners(t *testing.T) {
 	given, when, then := PromoteTest(t)
 
 	given.
 		a_repository().
 		with_config_for_the_workload("foo").
 		a_fake_github_server().
+		old_dev_manifests_for_the_workload_foo().
 		a_clusters_configuration_file().
-		an_cloud1_only_config_file_for_foo().
+		an_owned_config_file_for_foo().
 		commit_range_start().
 		new_source_manifests_for_the_workload("foo").
 		commit_range_end()
@@ -648,74 +652,59 @@ func Test_PromotionOfWorkloadsWithWorkloadExclusion(t *testing.T) {
 	when.
 		promote().
 		with_env(environment.Development).
+		with_no_issue_users("test-user-4").
 		is_called()
 
 	then.
 		promote_succeeds().
-		the_remote_repository_is_updated_with_new_branch().
 		the_number_of_raised_PRs_equals(1)
 
-	// "foo" is cloud1 only workload, so no pull requests for clusters in cloud2
+	// the owners which can't be assigned are neither assignees nor reviewers
 	then.
-		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1").
-		has_labels("k8s-promoter/automated-promotion").
-		has_no_assignees().
-		has_branch().with_one_commit().
-		that_contains_updated_foo_manifests_for_clusters(
-			"/promoted/development/dev2/cloud1",
-			"/promoted/development/dev3/cloud1").
-		that_contains_foo_changes_only_for_directories(
-			"/promoted/development/dev2/cloud1",
-			"/promoted/development/dev3/cloud1").
-		that_has_kustomization_for_workloads("/promoted/development/dev2/cloud1", "foo").
-		that_has_kustomization_for_workloads("/promoted/development/dev3/cloud1", "foo")
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
+		has_assignees("test-user-1", "test-user-2", "test-user-3").
+		has_reviewers([]string{"test-user-1"}, []string{"payments"})
 }
 
-func Test_PromotionOfWorkloadsWithWorkloadExclusionToTestEnv(t *testing.T) {
+func Test_PromotionSkipsWorkloadWithManualPromotionPolicy(t *testing.T) {
 	given, when, then := PromoteTest(t)
 
 	given.
 		a_repository().
 		with_config_for_the_workload("foo").
+		with_config_for_the_workload("bar").
 		a_fake_github_server().
 		a_clusters_configuration_file().
-		an_cloud1_only_config_file_for_foo().
-		new_source_manifests_for_the_workload("foo").
+		a_manual_development_promotion_config_file_for_foo().
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
 		commit_range_start().
-		new_dev_manifests_for_the_workload_foo().
+		new_source_manifests_for_the_workload("foo").
+		new_source_manifests_for_the_workload("bar").
 		commit_range_end()
 
 	when.
 		promote().
-		with_env(environment.Test).
+		with_env(environment.Development).
 		is_called()
 
 	then.
 		promote_succeeds().
-		the_remote_repository_is_updated_with_2_new_branches().
-		the_number_of_raised_PRs_equals(2)
-
-	// "foo" is cloud1 only workload, so no pull requests for clusters in cloud2
-	then.
-		a_PR_for("foo", environment.Test, "test1-cloud1").
-		has_labels("k8s-promoter/automated-promotion").
-		has_no_assignees().
-		has_branch().with_one_commit().
-		that_contains_updated_foo_manifests_for_cluster("/promoted/test/test1/cloud1").
-		that_contains_changes_only_for_directory("/promoted/test/test1/cloud1").
-		that_has_kustomization_for_workloads("/promoted/test/test1/cloud1", "foo")
+		the_number_of_raised_PRs_equals(1).
+		a_message_is_logged(promotion.SkippedMsg, logrus.InfoLevel)
 
 	then.
-		a_PR_for("foo", environment.Test, "test2-cloud1").
-		has_labels("k8s-promoter/automated-promotion").
-		has_no_assignees().
+		a_PR_for("bar", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
+		has_description_containing("* foo: promoted to development manually only\n").
 		has_branch().with_one_commit().
-		that_contains_updated_foo_manifests_for_cluster("/promoted/test/test2/cloud1").
-		that_contains_changes_only_for_directory("/promoted/test/test2/cloud1").
-		that_has_kustomization_for_workloads("/promoted/test/test2/cloud1", "foo")
+		that_has_kustomization_for_workloads("/promoted/development/dev2/cloud1", "bar", "foo").
+		that_contains_bar_changes_only_for_directories(
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1",
+			"/promoted/development/dev4/cloud2")
 }
 
-func Test_PromotionToTestWhenDevelopmentInInconsistentState(t *testing.T) {
+func Test_ManualPromotionOfWorkloadWithManualPromotionPolicy(t *testing.T) {
 	given, when, then := PromoteTest(t)
 
 	given.
@@ -723,80 +712,117 @@ func Test_PromotionToTestWhenDevelopmentInInconsistentState(t *testing.T) {
 		with_config_for_the_workload("foo").
 		a_fake_github_server().
 		a_clusters_configuration_file().
-		new_source_manifests_for_the_workload("foo").
-		new_dev_manifests_for_the_workload_foo().
+		a_manual_development_promotion_config_file_for_foo().
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
 		commit_range_start().
-		a_file_with_content(path("/promoted/development/dev2/cloud1/foo/file"), "inconsistent").
+		new_source_manifests_for_the_workload("foo").
 		commit_range_end()
 
 	when.
 		promote().
-		with_env(environment.Test).
+		with_env(environment.Development).
+		with_manual().
 		is_called()
 
 	then.
 		promote_succeeds().
-		a_message_is_logged(promoter.NotInSyncMsg, logrus.InfoLevel)
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
+		has_branch().with_one_commit().
+		that_contains_updated_foo_manifests_for_clusters(
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1",
+			"/promoted/development/dev4/cloud2")
 }
 
-func Test_PromotionToProductionWhenTestInInconsistentState(t *testing.T) {
+func Test_PromotionSplitsPRsByWorkloadMaxClustersPerPR(t *testing.T) {
 	given, when, then := PromoteTest(t)
 
 	given.
 		a_repository().
 		with_config_for_the_workload("foo").
 		a_fake_github_server().
-		a_clusters_configuration_file_with_new_prod_clusters().
+		a_clusters_configuration_file().
+		a_max_clusters_per_PR_config_file_for_foo(2).
 		old_source_manifests_for_the_workload("foo").
 		old_dev_manifests_for_the_workload_foo().
-		old_test_manifests_for_the_workload_foo().
-		old_prod_manifests_for_the_workload_foo().
-		a_file_with_content(path("/promoted/test/test1/cloud1/foo/file"), "inconsistent").
-		empty_commit_range()
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
 
 	when.
 		promote().
-		with_env(environment.Production).
+		with_env(environment.Development).
 		is_called()
 
 	then.
 		promote_succeeds().
-		a_message_is_logged(promoter.NotInSyncMsg, logrus.InfoLevel)
+		the_number_of_raised_PRs_equals(2)
+
+	then.
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1").
+		has_branch().with_one_commit().
+		that_contains_foo_changes_only_for_directories(
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1")
+
+	then.
+		a_PR_for("foo", environment.Development, "dev4-cloud2").
+		has_branch().with_one_commit().
+		that_contains_foo_changes_only_for_directories("/promoted/development/dev4/cloud2")
 }
 
-func Test_PromotionToNonExistingEnvironment(t *testing.T) {
+func Test_PromotionWaitsForDependencyPromotion(t *testing.T) {
 	given, when, then := PromoteTest(t)
 
 	given.
 		a_repository().
 		with_config_for_the_workload("foo").
+		with_config_for_the_workload("bar").
 		a_fake_github_server().
-		a_clusters_file_with_only_dev_clusters().
+		a_clusters_configuration_file().
+		old_source_manifests_for_the_workload("foo").
+		old_source_manifests_for_the_workload("bar").
+		a_config_file_for_bar_depending_on_foo().
+		old_dev_manifests_for_the_workload_foo().
+		old_dev_manifests_for_the_workload_bar().
+		new_source_manifests_for_the_workload("foo").
 		commit_range_start().
-		new_dev_manifests_for_the_workload_foo().
+		new_source_manifests_for_the_workload("bar").
 		commit_range_end()
 
 	when.
 		promote().
-		with_env(environment.Test).
+		with_env(environment.Development).
 		is_called()
 
+	// the new foo manifests aren't promoted to development, so bar isn't either
 	then.
 		promote_succeeds().
-		a_message_is_logged(promoter.NoClustersMsg, logrus.InfoLevel)
+		the_number_of_raised_PRs_equals(0).
+		a_message_is_logged(promoter.DependencyMsg, logrus.WarnLevel)
 }
 
-func Test_PromotionOfNewDevClusterWithNoManualManifestsChanges(t *testing.T) {
+func Test_PromotionOfWorkloadWithPromotedDependency(t *testing.T) {
 	given, when, then := PromoteTest(t)
 
 	given.
 		a_repository().
 		with_config_for_the_workload("foo").
+		with_config_for_the_workload("bar").
 		a_fake_github_server().
+		a_clusters_configuration_file().
 		old_source_manifests_for_the_workload("bar").
+		a_config_file_for_bar_depending_on_foo().
 		old_dev_manifests_for_the_workload_bar().
-		a_clusters_configuration_file_with_new_dev_cluster().
-		empty_commit_range()
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_source_manifests_for_the_workload("bar").
+		commit_range_end()
 
 	when.
 		promote().
@@ -805,33 +831,33 @@ func Test_PromotionOfNewDevClusterWithNoManualManifestsChanges(t *testing.T) {
 
 	then.
 		promote_succeeds().
-		the_remote_repository_is_updated_with_new_branch().
 		the_number_of_raised_PRs_equals(1)
 
 	then.
-		a_PR_for("bar", environment.Development, "dev1-cloud1").
-		has_labels("k8s-promoter/automated-promotion").
+		a_PR_for("bar", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
 		has_branch().with_one_commit().
-		that_contains_bar_manifests_for_clusters(
-			"/promoted/development/dev1/cloud1").
-		that_has_kustomization_for_workloads("/promoted/development/dev1/cloud1", "bar").
 		that_contains_bar_changes_only_for_directories(
-			"/promoted/development/dev1/cloud1",
-			"/config/development/dev1/cloud1/",
-		)
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1",
+			"/promoted/development/dev4/cloud2")
 }
 
-func Test_PromotionOfNewDevClusterWithManualManifestsChanges(t *testing.T) {
+func Test_PromotionOfWorkloadWithItsDependency(t *testing.T) {
 	given, when, then := PromoteTest(t)
 
 	given.
 		a_repository().
 		with_config_for_the_workload("foo").
+		with_config_for_the_workload("bar").
 		a_fake_github_server().
+		a_clusters_configuration_file().
+		old_source_manifests_for_the_workload("foo").
 		old_source_manifests_for_the_workload("bar").
+		a_config_file_for_bar_depending_on_foo().
+		old_dev_manifests_for_the_workload_foo().
 		old_dev_manifests_for_the_workload_bar().
-		a_clusters_configuration_file_with_new_dev_cluster().
 		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
 		new_source_manifests_for_the_workload("bar").
 		commit_range_end()
 
@@ -842,98 +868,127 @@ func Test_PromotionOfNewDevClusterWithManualManifestsChanges(t *testing.T) {
 
 	then.
 		promote_succeeds().
-		the_remote_repository_is_updated_with_new_branches(2).
-		the_number_of_raised_PRs_equals(2)
-
-	then.
-		a_PR_for("bar", environment.Development, "dev1-cloud1").
-		has_labels("k8s-promoter/automated-promotion").
-		has_branch().with_one_commit().
-		that_contains_updated_bar_manifests_for_clusters(
-			"/promoted/development/dev1/cloud1").
-		that_has_kustomization_for_workloads("/promoted/development/dev1/cloud1", "bar").
-		that_contains_bar_changes_only_for_directories(
-			"/promoted/development/dev1/cloud1",
-			"/config/development/dev1/cloud1/",
-		)
+		the_number_of_raised_PRs_equals(1)
 
 	then.
 		a_PR_for("bar", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
-		has_labels("k8s-promoter/automated-promotion").
 		has_branch().with_one_commit().
-		that_contains_updated_bar_manifests_for_clusters(
+		that_contains_updated_foo_manifests_for_clusters(
 			"/promoted/development/dev2/cloud1",
 			"/promoted/development/dev3/cloud1",
 			"/promoted/development/dev4/cloud2").
-		that_has_kustomization_for_workloads("/promoted/development/dev2/cloud1", "bar").
-		that_has_kustomization_for_workloads("/promoted/development/dev3/cloud1", "bar").
-		that_has_kustomization_for_workloads("/promoted/development/dev4/cloud2", "bar").
-		that_contains_bar_changes_only_for_directories(
+		that_contains_updated_bar_manifests_for_clusters(
 			"/promoted/development/dev2/cloud1",
 			"/promoted/development/dev3/cloud1",
-			"/promoted/development/dev4/cloud2",
-		)
+			"/promoted/development/dev4/cloud2")
 }
 
-func Test_PromotionOfNewTestClustersNoManualManifestsChanges(t *testing.T) {
+func Test_PromotionOfWorkloadsWithWorkloadExclusion(t *testing.T) {
 	given, when, then := PromoteTest(t)
 
 	given.
 		a_repository().
 		with_config_for_the_workload("foo").
 		a_fake_github_server().
-		old_dev_manifests_for_the_workload_foo().
-		old_test_manifests_for_the_workload_foo().
-		new_source_manifests_for_the_workload("foo").
-		new_dev_manifests_for_the_workload_foo().
+		a_clusters_configuration_file().
+		an_cloud1_only_config_file_for_foo().
 		commit_range_start().
-		a_clusters_configuration_file_with_new_test_clusters().
+		new_source_manifests_for_the_workload("foo").
 		commit_range_end()
 
 	when.
 		promote().
-		with_env(environment.Test).
+		with_env(environment.Development).
 		is_called()
 
 	then.
 		promote_succeeds().
-		the_remote_repository_is_updated_with_2_new_branches().
-		the_number_of_raised_PRs_equals(2)
+		the_remote_repository_is_updated_with_new_branch().
+		the_number_of_raised_PRs_equals(1)
 
+	// "foo" is cloud1 only workload, so no pull requests for clusters in cloud2
 	then.
-		a_PR_for("foo", environment.Test, "new-test-cluster-1-cloud1").
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1").
 		has_labels("k8s-promoter/automated-promotion").
 		has_no_assignees().
 		has_branch().with_one_commit().
-		that_has_kustomization_for_workloads("/promoted/test/new-test-cluster-1/cloud1", "foo").
-		that_contains_updated_foo_manifests_for_cluster("/promoted/test/new-test-cluster-1/cloud1").
+		that_contains_updated_foo_manifests_for_clusters(
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1").
 		that_contains_foo_changes_only_for_directories(
-			"/promoted/test/new-test-cluster-1/cloud1",
-			"/config/test/new-test-cluster-1/cloud1")
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1").
+		that_has_kustomization_for_workloads("/promoted/development/dev2/cloud1", "foo").
+		that_has_kustomization_for_workloads("/promoted/development/dev3/cloud1", "foo")
+}
+
+func Test_PromotionOfWorkloadsWithExpiredWorkloadExclusion(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		an_expired_cloud1_only_config_file_for_foo().
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
 
 	then.
-		a_PR_for("foo", environment.Test, "new-test-cluster-2-cloud2").
-		has_labels("k8s-promoter/automated-promotion").
-		has_no_assignees().
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(1).
+		a_message_is_logged(promoter.ExpiredExclusionMsg, logrus.WarnLevel)
+
+	// the exclusion expired, so "foo" is promoted to the clusters in cloud2 too
+	then.
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
 		has_branch().with_one_commit().
-		that_has_kustomization_for_workloads("/promoted/test/new-test-cluster-2/cloud2", "foo").
-		that_contains_updated_foo_manifests_for_cluster("/promoted/test/new-test-cluster-2/cloud2").
-		that_contains_foo_changes_only_for_directories(
-			"/promoted/test/new-test-cluster-2/cloud2",
-			"/config/test/new-test-cluster-2/cloud2")
+		that_contains_updated_foo_manifests_for_clusters(
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1",
+			"/promoted/development/dev4/cloud2")
 }
 
-func Test_PromotionOfNewTestClustersWithManualManifestsChanges(t *testing.T) {
+func Test_ValidateListsExpiredWorkloadExclusions(t *testing.T) {
 	given, when, then := PromoteTest(t)
 
 	given.
 		a_repository().
 		with_config_for_the_workload("foo").
 		a_fake_github_server().
-		old_dev_manifests_for_the_workload_foo().
-		old_test_manifests_for_the_workload_foo().
+		a_clusters_configuration_file().
+		an_expired_cloud1_only_config_file_for_foo().
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_validate().
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(0).
+		the_expired_exclusions_are("foo: cloud NotEqual cloud1 until 2020-01-01T00:00:00Z (INC-42 cloud2 outage)")
+}
+
+func Test_PromotionOfWorkloadsWithWorkloadExclusionToTestEnv(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		an_cloud1_only_config_file_for_foo().
 		new_source_manifests_for_the_workload("foo").
-		a_clusters_configuration_file_with_new_test_clusters().
 		commit_range_start().
 		new_dev_manifests_for_the_workload_foo().
 		commit_range_end()
@@ -945,15 +1000,15 @@ func Test_PromotionOfNewTestClustersWithManualManifestsChanges(t *testing.T) {
 
 	then.
 		promote_succeeds().
-		the_remote_repository_is_updated_with_new_branches(5).
-		the_number_of_raised_PRs_equals(5)
+		the_remote_repository_is_updated_with_2_new_branches().
+		the_number_of_raised_PRs_equals(2)
 
-	// promotions to existing clusters
+	// "foo" is cloud1 only workload, so no pull requests for clusters in cloud2
 	then.
 		a_PR_for("foo", environment.Test, "test1-cloud1").
 		has_labels("k8s-promoter/automated-promotion").
-		has_assignees("test-user-2", "test-user-3").
-		has_branch().with_one_commit().with_source_commit().
+		has_no_assignees().
+		has_branch().with_one_commit().
 		that_contains_updated_foo_manifests_for_cluster("/promoted/test/test1/cloud1").
 		that_contains_changes_only_for_directory("/promoted/test/test1/cloud1").
 		that_has_kustomization_for_workloads("/promoted/test/test1/cloud1", "foo")
@@ -961,55 +1016,139 @@ func Test_PromotionOfNewTestClustersWithManualManifestsChanges(t *testing.T) {
 	then.
 		a_PR_for("foo", environment.Test, "test2-cloud1").
 		has_labels("k8s-promoter/automated-promotion").
-		has_assignees("test-user-2", "test-user-3").
-		has_branch().with_one_commit().with_source_commit().
+		has_no_assignees().
+		has_branch().with_one_commit().
 		that_contains_updated_foo_manifests_for_cluster("/promoted/test/test2/cloud1").
 		that_contains_changes_only_for_directory("/promoted/test/test2/cloud1").
 		that_has_kustomization_for_workloads("/promoted/test/test2/cloud1", "foo")
+}
+
+func Test_PromotionOfNewWorkloadExclusion(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
+		commit_range_start().
+		an_cloud1_only_config_file_for_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
 
 	then.
-		a_PR_for("foo", environment.Test, "test3-cloud2").
-		has_labels("k8s-promoter/automated-promotion").
-		has_assignees("test-user-2", "test-user-3").
-		has_branch().with_one_commit().with_source_commit().
-		that_contains_updated_foo_manifests_for_cluster("/promoted/test/test3/cloud2").
-		that_contains_changes_only_for_directory("/promoted/test/test3/cloud2").
-		that_has_kustomization_for_workloads("/promoted/test/test3/cloud2", "foo")
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(1).
+		a_message_is_logged(promoter.RetargetMsg, logrus.InfoLevel)
 
-	// promotions to new clusters
+	// "foo" just became cloud1 only, so it is removed from the cluster in cloud2
 	then.
-		a_PR_for("foo", environment.Test, "new-test-cluster-1-cloud1").
-		has_labels("k8s-promoter/automated-promotion").
-		has_no_assignees().
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
+		has_description_containing("|dev4-cloud2|:heavy_minus_sign: (targeting change)|").
 		has_branch().with_one_commit().
-		that_contains_updated_foo_manifests_for_cluster("/promoted/test/new-test-cluster-1/cloud1").
-		that_contains_foo_changes_only_for_directories(
-			"/promoted/test/new-test-cluster-1/cloud1",
-			"/config/test/new-test-cluster-1/cloud1").
-		that_has_kustomization_for_workloads("/promoted/test/new-test-cluster-1/cloud1", "foo")
+		that_contains_workload_manifests_for_clusters("foo",
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1").
+		that_deletes_manifests("foo", "/promoted/development/dev4/cloud2").
+		that_has_kustomization_for_workloads("/promoted/development/dev2/cloud1", "foo")
+}
+
+func Test_PromotionOfRemovedWorkloadExclusion(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		an_cloud1_only_config_file_for_foo().
+		old_source_manifests_for_the_workload("foo").
+		old_source_manifests_for_the_workload("bar").
+		old_dev_manifests_for_the_workload_foo_in_cloud1().
+		old_dev_manifests_for_the_workload_bar().
+		commit_range_start().
+		an_unrestricted_config_file_for_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
 
 	then.
-		a_PR_for("foo", environment.Test, "new-test-cluster-2-cloud2").
-		has_labels("k8s-promoter/automated-promotion").
-		has_no_assignees().
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(1)
+
+	// "foo" is no longer cloud1 only, so it is copied to the cluster in cloud2
+	then.
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
+		has_description_containing("|dev2-cloud1|:heavy_check_mark:|").
+		has_description_containing("|dev4-cloud2|:heavy_check_mark: (targeting change)|").
 		has_branch().with_one_commit().
-		that_contains_updated_foo_manifests_for_cluster("/promoted/test/new-test-cluster-2/cloud2").
-		that_contains_foo_changes_only_for_directories(
-			"/promoted/test/new-test-cluster-2/cloud2",
-			"/config/test/new-test-cluster-2/cloud2").
-		that_has_kustomization_for_workloads("/promoted/test/new-test-cluster-2/cloud2", "foo")
+		that_contains_workload_manifests_for_clusters("foo",
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1",
+			"/promoted/development/dev4/cloud2").
+		that_has_kustomization_for_workloads("/promoted/development/dev4/cloud2", "bar", "foo")
 }
 
-func Test_PromotionOfNewTestClusterWhenWorkloadNotPromotedToDevelopment(t *testing.T) {
+func Test_PromotionOfNewWorkloadExclusionToTestEnv(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
+		old_test_manifests_for_the_workload_foo().
+		an_cloud1_only_config_file_for_foo().
+		commit_range_start().
+		dev_manifests_for_the_workload_foo_excluded_from_cloud2().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Test).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(3)
+
+	then.
+		a_PR_for("foo", environment.Test, "test3-cloud2").
+		has_description_containing("|test3-cloud2|:heavy_minus_sign: (targeting change)|").
+		has_branch().with_one_commit().
+		that_deletes_manifests("foo", "/promoted/test/test3/cloud2")
+
+	then.
+		a_PR_for("foo", environment.Test, "test1-cloud1").
+		has_description_containing("|test1-cloud1|:heavy_check_mark:|").
+		has_branch().with_one_commit().
+		that_contains_workload_manifests_for_clusters("foo", "/promoted/test/test1/cloud1").
+		that_contains_changes_only_for_directory("/promoted/test/test1/cloud1")
+}
+
+func Test_PromotionToTestWhenDevelopmentInInconsistentState(t *testing.T) {
 	given, when, then := PromoteTest(t)
 
 	given.
 		a_repository().
 		with_config_for_the_workload("foo").
 		a_fake_github_server().
+		a_clusters_configuration_file().
 		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
 		commit_range_start().
-		a_clusters_configuration_file_with_new_test_clusters().
+		a_file_with_content(path("/promoted/development/dev2/cloud1/foo/file"), "inconsistent").
 		commit_range_end()
 
 	when.
@@ -1019,6 +1158,1376 @@ func Test_PromotionOfNewTestClusterWhenWorkloadNotPromotedToDevelopment(t *testi
 
 	then.
 		promote_succeeds().
-		the_remote_repository_is_not_updated_with_new_branch().
+		a_message_is_logged(promoter.NotInSyncMsg, logrus.InfoLevel)
+}
+
+func Test_PromotionToProductionWhenTestInInconsistentState(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_new_prod_clusters().
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
+		old_test_manifests_for_the_workload_foo().
+		old_prod_manifests_for_the_workload_foo().
+		a_file_with_content(path("/promoted/test/test1/cloud1/foo/file"), "inconsistent").
+		empty_commit_range()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		is_called()
+
+	then.
+		promote_succeeds().
+		a_message_is_logged(promoter.NotInSyncMsg, logrus.InfoLevel)
+}
+
+func Test_DecommissionedClusterIsRemovedWhenTestInInconsistentState(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		an_orphaned_cluster("production", "prod9", "cloud1").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
+		old_test_manifests_for_the_workload_foo().
+		a_file_with_content(path("/promoted/test/test1/cloud1/foo/file"), "inconsistent").
+		empty_commit_range()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		with_decommission().
+		is_called()
+
+	then.
+		promote_succeeds().
+		a_message_is_logged(promoter.NotInSyncMsg, logrus.InfoLevel).
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("Decommission", environment.Production, "prod9/cloud1").
+		has_labels(promoter.DecommissionLabel)
+}
+
+func Test_PromotionToNonExistingEnvironment(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_file_with_only_dev_clusters().
+		commit_range_start().
+		new_dev_manifests_for_the_workload_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Test).
+		is_called()
+
+	then.
+		promote_succeeds().
+		a_message_is_logged(promoter.NoClustersMsg, logrus.InfoLevel)
+}
+
+func Test_PromotionOfNewDevClusterWithNoManualManifestsChanges(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		old_source_manifests_for_the_workload("bar").
+		old_dev_manifests_for_the_workload_bar().
+		a_clusters_configuration_file_with_new_dev_cluster().
+		empty_commit_range()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_remote_repository_is_updated_with_new_branch().
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("bar", environment.Development, "dev1-cloud1").
+		has_labels("k8s-promoter/automated-promotion").
+		has_branch().with_one_commit().
+		that_contains_bar_manifests_for_clusters(
+			"/promoted/development/dev1/cloud1").
+		that_has_kustomization_for_workloads("/promoted/development/dev1/cloud1", "bar").
+		that_contains_bar_changes_only_for_directories(
+			"/promoted/development/dev1/cloud1",
+			"/config/development/dev1/cloud1/",
+		)
+}
+
+func Test_PromotionOfNewDevClusterSkipsWorkloadLimitedToOtherEnvironment(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		with_config_for_the_workload("bar").
+		a_fake_github_server().
+		a_test_only_promotion_config_file_for_foo().
+		old_source_manifests_for_the_workload("foo").
+		old_source_manifests_for_the_workload("bar").
+		old_dev_manifests_for_the_workload_bar().
+		a_clusters_configuration_file_with_new_dev_cluster().
+		empty_commit_range()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(1).
+		a_message_is_logged(promotion.SkippedMsg, logrus.InfoLevel)
+
+	then.
+		a_PR_for("bar", environment.Development, "dev1-cloud1").
+		has_description_containing("* foo: not promoted to development\n").
+		has_branch().with_one_commit().
+		that_has_kustomization_for_workloads("/promoted/development/dev1/cloud1", "bar").
+		that_contains_bar_changes_only_for_directories(
+			"/promoted/development/dev1/cloud1",
+			"/config/development/dev1/cloud1/",
+		)
+}
+
+func Test_PromotionOfNewDevClusterWithManualManifestsChanges(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		old_source_manifests_for_the_workload("bar").
+		old_dev_manifests_for_the_workload_bar().
+		a_clusters_configuration_file_with_new_dev_cluster().
+		commit_range_start().
+		new_source_manifests_for_the_workload("bar").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_remote_repository_is_updated_with_new_branches(2).
+		the_number_of_raised_PRs_equals(2)
+
+	then.
+		a_PR_for("bar", environment.Development, "dev1-cloud1").
+		has_labels("k8s-promoter/automated-promotion").
+		has_branch().with_one_commit().
+		that_contains_updated_bar_manifests_for_clusters(
+			"/promoted/development/dev1/cloud1").
+		that_has_kustomization_for_workloads("/promoted/development/dev1/cloud1", "bar").
+		that_contains_bar_changes_only_for_directories(
+			"/promoted/development/dev1/cloud1",
+			"/config/development/dev1/cloud1/",
+		)
+
+	then.
+		a_PR_for("bar", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
+		has_labels("k8s-promoter/automated-promotion").
+		has_branch().with_one_commit().
+		that_contains_updated_bar_manifests_for_clusters(
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1",
+			"/promoted/development/dev4/cloud2").
+		that_has_kustomization_for_workloads("/promoted/development/dev2/cloud1", "bar").
+		that_has_kustomization_for_workloads("/promoted/development/dev3/cloud1", "bar").
+		that_has_kustomization_for_workloads("/promoted/development/dev4/cloud2", "bar").
+		that_contains_bar_changes_only_for_directories(
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1",
+			"/promoted/development/dev4/cloud2",
+		)
+}
+
+func Test_PromotionOfNewTestClustersNoManualManifestsChanges(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		old_dev_manifests_for_the_workload_foo().
+		old_test_manifests_for_the_workload_foo().
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		commit_range_start().
+		a_clusters_configuration_file_with_new_test_clusters().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Test).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_remote_repository_is_updated_with_2_new_branches().
+		the_number_of_raised_PRs_equals(2)
+
+	then.
+		a_PR_for("foo", environment.Test, "new-test-cluster-1-cloud1").
+		has_labels("k8s-promoter/automated-promotion").
+		has_no_assignees().
+		has_branch().with_one_commit().
+		that_has_kustomization_for_workloads("/promoted/test/new-test-cluster-1/cloud1", "foo").
+		that_contains_updated_foo_manifests_for_cluster("/promoted/test/new-test-cluster-1/cloud1").
+		that_contains_foo_changes_only_for_directories(
+			"/promoted/test/new-test-cluster-1/cloud1",
+			"/config/test/new-test-cluster-1/cloud1")
+
+	then.
+		a_PR_for("foo", environment.Test, "new-test-cluster-2-cloud2").
+		has_labels("k8s-promoter/automated-promotion").
+		has_no_assignees().
+		has_branch().with_one_commit().
+		that_has_kustomization_for_workloads("/promoted/test/new-test-cluster-2/cloud2", "foo").
+		that_contains_updated_foo_manifests_for_cluster("/promoted/test/new-test-cluster-2/cloud2").
+		that_contains_foo_changes_only_for_directories(
+			"/promoted/test/new-test-cluster-2/cloud2",
+			"/config/test/new-test-cluster-2/cloud2")
+}
+
+func Test_PromotionOfNewTestClustersWithManualManifestsChanges(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		old_dev_manifests_for_the_workload_foo().
+		old_test_manifests_for_the_workload_foo().
+		new_source_manifests_for_the_workload("foo").
+		a_clusters_configuration_file_with_new_test_clusters().
+		commit_range_start().
+		new_dev_manifests_for_the_workload_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Test).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_remote_repository_is_updated_with_new_branches(5).
+		the_number_of_raised_PRs_equals(5)
+
+	// promotions to existing clusters
+	then.
+		a_PR_for("foo", environment.Test, "test1-cloud1").
+		has_labels("k8s-promoter/automated-promotion").
+		has_assignees("test-user-2", "test-user-3").
+		has_branch().with_one_commit().with_source_commit().
+		that_contains_updated_foo_manifests_for_cluster("/promoted/test/test1/cloud1").
+		that_contains_changes_only_for_directory("/promoted/test/test1/cloud1").
+		that_has_kustomization_for_workloads("/promoted/test/test1/cloud1", "foo")
+
+	then.
+		a_PR_for("foo", environment.Test, "test2-cloud1").
+		has_labels("k8s-promoter/automated-promotion").
+		has_assignees("test-user-2", "test-user-3").
+		has_branch().with_one_commit().with_source_commit().
+		that_contains_updated_foo_manifests_for_cluster("/promoted/test/test2/cloud1").
+		that_contains_changes_only_for_directory("/promoted/test/test2/cloud1").
+		that_has_kustomization_for_workloads("/promoted/test/test2/cloud1", "foo")
+
+	then.
+		a_PR_for("foo", environment.Test, "test3-cloud2").
+		has_labels("k8s-promoter/automated-promotion").
+		has_assignees("test-user-2", "test-user-3").
+		has_branch().with_one_commit().with_source_commit().
+		that_contains_updated_foo_manifests_for_cluster("/promoted/test/test3/cloud2").
+		that_contains_changes_only_for_directory("/promoted/test/test3/cloud2").
+		that_has_kustomization_for_workloads("/promoted/test/test3/cloud2", "foo")
+
+	// promotions to new clusters
+	then.
+		a_PR_for("foo", environment.Test, "new-test-cluster-1-cloud1").
+		has_labels("k8s-promoter/automated-promotion").
+		has_no_assignees().
+		has_branch().with_one_commit().
+		that_contains_updated_foo_manifests_for_cluster("/promoted/test/new-test-cluster-1/cloud1").
+		that_contains_foo_changes_only_for_directories(
+			"/promoted/test/new-test-cluster-1/cloud1",
+			"/config/test/new-test-cluster-1/cloud1").
+		that_has_kustomization_for_workloads("/promoted/test/new-test-cluster-1/cloud1", "foo")
+
+	then.
+		a_PR_for("foo", environment.Test, "new-test-cluster-2-cloud2").
+		has_labels("k8s-promoter/automated-promotion").
+		has_no_assignees().
+		has_branch().with_one_commit().
+		that_contains_updated_foo_manifests_for_cluster("/promoted/test/new-test-cluster-2/cloud2").
+		that_contains_foo_changes_only_for_directories(
+			"/promoted/test/new-test-cluster-2/cloud2",
+			"/config/test/new-test-cluster-2/cloud2").
+		that_has_kustomization_for_workloads("/promoted/test/new-test-cluster-2/cloud2", "foo")
+}
+
+func Test_PromotionOfNewTestClusterWhenWorkloadNotPromotedToDevelopment(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_start().
+		a_clusters_configuration_file_with_new_test_clusters().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Test).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_remote_repository_is_not_updated_with_new_branch().
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_PromotionToRegionalProductionEnvironmentsSharingTest(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_regional_production().
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		old_regional_prod_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_test_manifests_for_the_workload_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_envs("prod-eu", "prod-us").
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_remote_repository_is_updated_with_2_new_branches().
+		the_number_of_raised_PRs_equals(2)
+
+	then.
+		a_PR_for("foo", "prod-eu", "prod-eu1-cloud1").
+		has_labels("k8s-promoter/automated-promotion").
+		has_branch().with_one_commit().
+		that_contains_updated_foo_manifests_for_cluster("/promoted/prod-eu/prod-eu1/cloud1").
+		that_contains_changes_only_for_directory("/promoted/prod-eu/prod-eu1/cloud1").
+		that_has_kustomization_for_workloads("/promoted/prod-eu/prod-eu1/cloud1", "foo")
+
+	then.
+		a_PR_for("foo", "prod-us", "prod-us1-cloud2").
+		has_labels("k8s-promoter/automated-promotion").
+		has_branch().with_one_commit().
+		that_contains_updated_foo_manifests_for_cluster("/promoted/prod-us/prod-us1/cloud2").
+		that_contains_changes_only_for_directory("/promoted/prod-us/prod-us1/cloud2").
+		that_has_kustomization_for_workloads("/promoted/prod-us/prod-us1/cloud2", "foo")
+}
+
+func Test_PromotionToRegionalProductionWithUnknownTarget(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_regional_production().
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		old_regional_prod_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_test_manifests_for_the_workload_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_envs("prod-eu", "prod-uss").
+		is_called()
+
+	// the typo in the second target is caught before promoting the first one
+	then.
+		promote_fails_with(promoter.ErrInvalidEnvironment).
+		the_remote_repository_is_not_updated_with_new_branch().
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_PromotionToTargetsWithDifferentSources(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_regional_production().
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		old_regional_prod_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_test_manifests_for_the_workload_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_envs("prod-eu", "test").
+		is_called()
+
+	then.
+		promote_fails_with(promoter.ErrTargetsSource).
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_PromotionToRegionalProductionWhenTestInInconsistentState(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_regional_production().
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		old_regional_prod_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_test_manifests_for_the_workload_foo().
+		a_file_with_content(path("/promoted/test/test1/cloud1/foo/file"), "inconsistent").
+		commit_range_end()
+
+	when.
+		promote().
+		with_envs("prod-eu", "prod-us").
+		is_called()
+
+	then.
+		promote_succeeds().
+		a_message_is_logged(promoter.NotInSyncMsg, logrus.InfoLevel).
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_PromotionToTestGroupedByCloud(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_grouping_test_clusters_by_cloud().
+		old_dev_manifests_for_the_workload_foo().
+		old_test_manifests_for_the_workload_foo().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_start().
+		new_dev_manifests_for_the_workload_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Test).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_remote_repository_is_updated_with_2_new_branches().
+		the_number_of_raised_PRs_equals(2)
+
+	then.
+		a_PR_for("foo", environment.Test, "test1-cloud1", "test2-cloud1").
+		has_labels("k8s-promoter/automated-promotion").
+		has_branch().with_one_commit().with_source_commit().
+		that_contains_updated_foo_manifests_for_clusters(
+			"/promoted/test/test1/cloud1",
+			"/promoted/test/test2/cloud1").
+		that_contains_foo_changes_only_for_directories(
+			"/promoted/test/test1/cloud1",
+			"/promoted/test/test2/cloud1")
+
+	then.
+		a_PR_for("foo", environment.Test, "test3-cloud2").
+		has_labels("k8s-promoter/automated-promotion").
+		has_branch().with_one_commit().with_source_commit().
+		that_contains_updated_foo_manifests_for_cluster("/promoted/test/test3/cloud2").
+		that_contains_changes_only_for_directory("/promoted/test/test3/cloud2")
+}
+
+func Test_PromotionToProductionOnlyPromotesCanaryWave(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_production_canary_wave().
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		prod_manifests_for_the_workload_foo(oldContent, "prod1", "prod2", "prod3").
+		commit_range_start().
+		new_test_manifests_for_the_workload_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_remote_repository_is_updated_with_new_branch().
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("foo", environment.Production, "prod1-cloud1").
+		has_labels("k8s-promoter/automated-promotion").
+		has_branch().with_one_commit().
+		that_contains_updated_foo_manifests_for_cluster("/promoted/production/prod1/cloud1").
+		that_contains_changes_only_for_directory("/promoted/production/prod1/cloud1")
+}
+
+func Test_PromotionToProductionContinuesWithNextWaveOnceCanaryMatches(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_production_canary_wave().
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		prod_manifests_for_the_workload_foo(oldContent, "prod2", "prod3").
+		commit_range_start().
+		new_test_manifests_for_the_workload_foo().
+		prod_manifests_for_the_workload_foo(newContent, "prod1").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_remote_repository_is_updated_with_2_new_branches().
+		the_number_of_raised_PRs_equals(2)
+
+	then.
+		a_PR_for("foo", environment.Production, "prod2-cloud1").
+		has_branch().with_one_commit().
+		that_contains_changes_only_for_directory("/promoted/production/prod2/cloud1")
+
+	then.
+		a_PR_for("foo", environment.Production, "prod3-cloud1").
+		has_branch().with_one_commit().
+		that_contains_changes_only_for_directory("/promoted/production/prod3/cloud1")
+}
+
+func Test_PromotionToProductionWhenAllWavesMatch(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_production_canary_wave().
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_test_manifests_for_the_workload_foo().
+		prod_manifests_for_the_workload_foo(newContent, "prod1", "prod2", "prod3").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		is_called()
+
+	then.
+		promote_succeeds().
+		a_message_is_logged(promoter.WavesDoneMsg, logrus.InfoLevel).
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_PromotionToProductionIsPostponedUntilWorkloadSoakedInTest(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_test_soak_time(24 * time.Hour).
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_test_manifests_for_the_workload_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		is_called()
+
+	then.
+		promote_succeeds().
+		a_message_is_logged(promoter.NotSoakedMsg, logrus.InfoLevel).
+		the_summary_lists_postponed_workloads("foo").
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_PromotionOfNewProdClusterIsPostponedUntilAllWorkloadsSoakedInTest(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		with_config_for_the_workload("bar").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_new_prod_cluster_and_test_soak_time(24 * time.Hour).
+		commits_made_ago(48 * time.Hour).
+		old_source_manifests_for_the_workload("bar").
+		old_test_manifests_for_the_workload("bar").
+		commits_made_ago(0).
+		old_source_manifests_for_the_workload("foo").
+		old_test_manifests_for_the_workload_foo().
+		empty_commit_range()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		is_called()
+
+	then.
+		promote_succeeds().
+		a_message_is_logged(promoter.NewClusterHeldMsg, logrus.InfoLevel).
+		the_summary_lists_postponed_workloads("foo").
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_PromotionToProductionIgnoresChangesToPausedTestClustersWhenSoaking(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_test_soak_time_and_paused_cluster(24*time.Hour, "test1-cloud1").
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		old_prod_manifests_for_the_workload_foo().
+		commit_range_start().
+		commits_made_ago(48*time.Hour).
+		new_test_manifests_for_the_workload_foo().
+		commits_made_ago(0).
+		a_file_with_content(path("/promoted/test/test1/cloud1/foo/file"), "catching up").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_summary_lists_postponed_workloads().
+		the_number_of_raised_PRs_equals(3)
+}
+
+func Test_PromotionToTestIgnoresSoakTimeOfTest(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_test_soak_time(24 * time.Hour).
+		old_dev_manifests_for_the_workload_foo().
+		old_test_manifests_for_the_workload_foo().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_start().
+		new_dev_manifests_for_the_workload_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Test).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_summary_lists_postponed_workloads().
+		the_number_of_raised_PRs_equals(3)
+}
+
+func Test_PromotionToProductionDuringChangeFreeze(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_production_freeze().
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_test_manifests_for_the_workload_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		is_called()
+
+	then.
+		promote_fails_with(promoter.ErrChangeFreeze).
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_PromotionToProductionOverridingChangeFreeze(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_production_freeze().
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_test_manifests_for_the_workload_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		with_freeze_override("INC-123 hotfix").
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(3)
+
+	then.
+		a_PR_for("foo", environment.Production, "prod1-cloud1").
+		has_description_containing("* quarter-end\n").
+		has_description_containing("Reason: INC-123 hotfix")
+}
+
+func Test_PromotionToProductionPostponesFrozenWorkload(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_production_freeze("foo").
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_test_manifests_for_the_workload_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		is_called()
+
+	then.
+		promote_succeeds().
+		a_message_is_logged(promoter.FrozenMsg, logrus.InfoLevel).
+		the_summary_lists_postponed_workloads("foo").
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_PromotionOfNewProdClusterIsPostponedWhileAWorkloadIsFrozen(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		with_config_for_the_workload("bar").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_new_prod_cluster_and_production_freeze("foo").
+		old_source_manifests_for_the_workload("foo").
+		old_source_manifests_for_the_workload("bar").
+		old_test_manifests_for_the_workload_foo().
+		old_test_manifests_for_the_workload("bar").
+		empty_commit_range()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		is_called()
+
+	then.
+		promote_succeeds().
+		a_message_is_logged(promoter.NewClusterHeldMsg, logrus.InfoLevel).
+		the_summary_lists_postponed_workloads("foo").
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_PromotionOfWorkloadsWithWorkloadInclusion(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		a_cloud2_only_inclusion_config_file_for_foo().
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_remote_repository_is_updated_with_new_branch().
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("foo", environment.Development).
+		has_branch().with_one_commit().
+		that_contains_updated_foo_manifests_for_cluster("/promoted/development/dev4/cloud2").
+		that_contains_changes_only_for_directory("/promoted/development/dev4/cloud2")
+}
+
+func Test_PromotionRecordsClustersConfigurationFromGitHub(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
+		has_description_containing("Clusters configuration: `clusters.yaml` in form3tech/infrastructure-k8s-admin at master (" + configCommit + ")")
+}
+
+func Test_PromotionWithLocalClustersConfiguration(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_local_clusters_configuration_file().
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
+		has_description_containing("clusters.yaml` (local file)")
+}
+
+func Test_PromotionWithClustersConfigurationInManifestRepository(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_in_the_manifest_repository().
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
+		has_description_referencing_the_manifest_repository_config()
+}
+
+func Test_PromotionWithClustersConfigurationDirectory(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_directory_with_a_file_per_environment().
+		old_source_manifests_for_the_workload("foo").
+		a_promoted_manifest_for_the_workload("foo", "development", "dev2", "cloud1", oldContent).
+		a_promoted_manifest_for_the_workload("foo", "development", "dev3", "cloud1", oldContent).
+		a_promoted_manifest_for_the_workload("foo", "development", "dev4", "cloud2", oldContent).
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
+		has_description_containing("Clusters configuration: `clusters` in form3tech/infrastructure-k8s-admin at master")
+}
+
+func Test_PromotionWithLocalClustersConfigurationGlob(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		local_clusters_configuration_files_per_environment_matching("**/*.yaml").
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2")
+}
+
+func Test_DecommissionedClusterIsRemoved(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		an_orphaned_cluster("development", "dev1", "cloud1").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		with_decommission().
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(2)
+
+	then.
+		a_PR_for("Decommission", environment.Development, "dev1/cloud1").
+		has_labels(promoter.DecommissionLabel).
+		has_branch().
+		with_one_commit().
+		that_removes_the_folders(
+			"/promoted/development/dev1/cloud1",
+			"/config/development/dev1/cloud1",
+		)
+}
+
+func Test_DecommissionedClusterIsKeptWithoutOptIn(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		an_orphaned_cluster("development", "dev1", "cloud1").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2")
+}
+
+func Test_RelocatedClusterIsMovedUnchanged(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_promoted_manifest_for_the_workload("foo", "development", "dev2", "cloud1", oldContent).
+		a_promoted_manifest_for_the_workload("foo", "development", "dev3", "cloud1", oldContent).
+		a_promoted_manifest_for_the_workload("foo", "development", "dev4", "cloud2", oldContent).
+		a_fake_github_server().
+		a_clusters_configuration_file_in_the_manifest_repository().
+		commit_range_start().
+		a_relocated_dev_cluster_in_the_manifest_repository_configuration().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(2)
+
+	then.
+		a_PR_for("foo", environment.Development, "dev3-cloud1", "dev4-cloud2")
+
+	then.
+		a_PR_for("Relocate", environment.Development, "dev2-cloud1").
+		has_description_containing("dev2-cloud1: `/flux/promoted/development/dev2/cloud1` to `/flux/promoted/development/cloud1/dev2`").
+		has_description_containing("dev2-cloud1: `/flux/config/development/dev2/cloud1` to `/flux/config/development/cloud1/dev2`").
+		has_branch().
+		with_one_commit().
+		that_contains_workload_manifests_for_clusters("foo", "/promoted/development/cloud1/dev2").
+		that_removes_the_folders(
+			"/promoted/development/dev2/cloud1",
+			"/config/development/dev2/cloud1",
+		)
+}
+
+func Test_PromotionSkipsPausedCluster(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_paused_cluster("dev3-cloud1", "rebuild").
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("foo", environment.Development, "dev2-cloud1", "dev4-cloud2").
+		has_description_containing("|dev3-cloud1 (paused: rebuild)|:pause_button:|").
+		has_branch().with_one_commit().
+		that_contains_updated_foo_manifests_for_clusters(
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev4/cloud2").
+		that_contains_foo_changes_only_for_directories(
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev4/cloud2")
+}
+
+func Test_PromotionToProductionIgnoresPausedTestCluster(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_paused_cluster("test1-cloud1", "maintenance").
+		new_source_manifests_for_the_workload("foo").
+		new_dev_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_test_manifests_for_the_workload_foo().
+		a_file_with_content(path("/promoted/test/test1/cloud1/foo/file"), "behind").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(3)
+
+	then.
+		a_PR_for("foo", environment.Production, "prod1-cloud1").
+		has_branch().with_one_commit().
+		that_contains_updated_foo_manifests_for_cluster("/promoted/production/prod1/cloud1")
+}
+
+func Test_PromotionOfNamespacedWorkload(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("payments/ledger").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_namespaced_workloads().
+		old_source_manifests_for_the_workload("payments/ledger").
+		old_dev_manifests_for_the_workload("payments/ledger").
+		commit_range_start().
+		new_source_manifests_for_the_workload("payments/api").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("payments/api", environment.Development, "dev2-cloud1", "dev3-cloud1", "dev4-cloud2").
+		has_branch().with_one_commit().
+		that_contains_updated_workload_manifests_for_clusters("payments/api",
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1",
+			"/promoted/development/dev4/cloud2").
+		that_contains_workload_changes_only_for_directories("payments/api",
+			"/promoted/development/dev2/cloud1",
+			"/promoted/development/dev3/cloud1",
+			"/promoted/development/dev4/cloud2").
+		that_has_kustomization_for_workloads("/promoted/development/dev2/cloud1", "payments/api", "payments/ledger")
+}
+
+func Test_DriftOfDevelopmentCluster(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
+		commit_range_start().
+		a_hand_edited_manifest_for_the_workload_foo("development", "dev3", "cloud1").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		with_drift().
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_drifts_are("dev3-cloud1: /flux/promoted/development/dev3/cloud1/foo differs from /flux/manifests/foo").
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_DriftOfTestClusterIsResynced(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
+		old_test_manifests_for_the_workload_foo().
+		an_owned_config_file_for_foo().
+		commit_range_start().
+		a_hand_edited_manifest_for_the_workload_foo("test", "test3", "cloud2").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Test).
+		with_drift().
+		with_resync().
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_drifts_are("test3-cloud2: /flux/promoted/test/test3/cloud2/foo differs from /flux/promoted/development/dev2/cloud1/foo").
+		the_number_of_raised_PRs_equals(1)
+
+	then.
+		a_PR_for("foo", environment.Test, "test3-cloud2").
+		has_labels("k8s-promoter/automated-promotion", "k8s-promoter/drift-resync").
+		has_description_containing("|test3-cloud2 (drifted)|:heavy_check_mark:|").
+		has_assignees("test-user-1", "test-user-4").
+		has_branch().with_one_commit().
+		that_contains_workload_manifests_for_clusters("foo", "/promoted/test/test3/cloud2").
+		that_contains_changes_only_for_directory("/promoted/test/test3/cloud2")
+}
+
+func Test_DriftIgnoresPendingPromotion(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
+		commit_range_start().
+		new_source_manifests_for_the_workload("foo").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		with_drift().
+		with_resync().
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_drifts_are().
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_DriftIsNotResyncedDuringChangeFreeze(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_production_freeze().
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
+		old_test_manifests_for_the_workload_foo().
+		old_prod_manifests_for_the_workload_foo().
+		commit_range_start().
+		a_hand_edited_manifest_for_the_workload_foo("production", "prod1", "cloud1").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Production).
+		with_drift().
+		with_resync().
+		is_called()
+
+	then.
+		promote_fails_with(promoter.ErrChangeFreeze).
+		the_drifts_are("prod1-cloud1: /flux/promoted/production/prod1/cloud1/foo differs from /flux/promoted/test/test1/cloud1/foo").
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_DriftSkipsWorkloadWithNoSourceCluster(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file_with_paused_environment("development", "maintenance").
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
+		old_test_manifests_for_the_workload_foo().
+		commit_range_start().
+		a_hand_edited_manifest_for_the_workload_foo("test", "test3", "cloud2").
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Test).
+		with_drift().
+		is_called()
+
+	then.
+		promote_succeeds().
+		a_message_is_logged(promoter.NoSourceMsg, logrus.WarnLevel).
+		the_drifts_are().
+		the_number_of_raised_PRs_equals(0)
+}
+
+func Test_DriftIgnoresCosmeticChangesSkippedBySemanticDiff(t *testing.T) {
+	given, when, then := PromoteTest(t)
+
+	given.
+		a_repository().
+		with_config_for_the_workload("foo").
+		a_fake_github_server().
+		a_clusters_configuration_file().
+		old_source_manifests_for_the_workload("foo").
+		old_dev_manifests_for_the_workload_foo().
+		a_deployment_for_the_workload_foo_in_the_source_and_development().
+		commit_range_start().
+		a_cosmetic_change_to_the_deployment_of_foo().
+		commit_range_end()
+
+	when.
+		promote().
+		with_env(environment.Development).
+		with_semantic_diff().
+		with_drift().
+		is_called()
+
+	then.
+		promote_succeeds().
+		the_drifts_are().
 		the_number_of_raised_PRs_equals(0)
 }
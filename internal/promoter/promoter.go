@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -16,21 +16,35 @@ import (
 	"github.com/form3tech/k8s-promoter/internal/github"
 	"github.com/form3tech/k8s-promoter/internal/kustomization"
 	promotion "github.com/form3tech/k8s-promoter/internal/promotion"
+	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/util"
 	gh "github.com/google/go-github/v33/github"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	Timeout       = 5 * time.Minute
-	NotInSyncMsg  = "Clusters for target environment are out of sync. Not raising further PRs until this is resolved"
-	NoClustersMsg = "Found no clusters to promote workload to, please check clusters.yaml if you think this is an error"
-	NoChangesMsg  = "No detected changes match our source environment. Not taking any action"
+	Timeout             = 5 * time.Minute
+	NotInSyncMsg        = "Clusters for target environment are out of sync. Not raising further PRs until this is resolved"
+	NoClustersMsg       = "Found no clusters to promote workload to, please check clusters.yaml if you think this is an error"
+	NoChangesMsg        = "No detected changes match our source environment. Not taking any action"
+	WavesDoneMsg        = "All rollout waves of target environment match the source. Not taking any action"
+	NotSoakedMsg        = "No detected changes soaked long enough in the source environment. Not taking any action"
+	FrozenMsg           = "All detected changes are frozen in target environment. Not taking any action"
+	ExpiredExclusionMsg = "Exclusion expired, the workload is no longer excluded from the cluster. Please remove the exclusion"
+	DependencyMsg       = "Dependency isn't promoted to the cluster at the version of the source environment. Not promoting the workload until it is"
+	OrphanedMsg         = "Found cluster directories which are no longer in the clusters configuration. Enable decommissioning to raise pull requests removing them"
+	RetargetMsg         = "Cluster was just excluded by the workload configuration. Removing the workload from the cluster"
+	NewClusterHeldMsg   = "Not all workloads can be promoted to the new clusters yet. Not promoting them until they can"
+	NoSourceMsg         = "No cluster of the source environment allows the workload, or all of them are paused. Skipping the workload"
+	ResyncHeldMsg       = "All drifted workloads are held back by a change freeze, soak time, promotion policy, rollout wave or dependency. Not resyncing"
 )
 
 var (
 	ErrClustersNotInSync  = errors.New("clusters not in sync")
 	ErrInvalidEnvironment = errors.New("invalid environment name")
+	ErrChangeFreeze       = errors.New("change freeze in force")
+	ErrTargetsSource      = errors.New("target environments don't share their source environment")
+	ErrNoSourceCluster    = errors.New("filtered clusters are zero, expected at least one")
 )
 
 type Args struct {
@@ -43,10 +57,52 @@ type Args struct {
 	GPGKeyPath       string
 	ConfigRepository string
 
+	// ConfigSource tells where ConfigPath is read from, the config repository on GitHub by default.
+	ConfigSource ConfigSource
+	// ConfigRef is the ref of the config repository to read, DefaultConfigRef by default.
+	ConfigRef string
+	// PreviousConfigRef is the ref of the clusters configuration before the promotion, telling the former manifest
+	// folder of the relocated clusters. It defaults to the start of the commit range when the configuration is in
+	// the manifest repository, clusters aren't relocated otherwise.
+	PreviousConfigRef string
+
 	CommitterName  string
 	CommitterEmail string
 
 	NoIssueUsers []string
+
+	// FreezeOverride is the reason for promoting despite change freezes. It is recorded in the pull requests.
+	FreezeOverride string
+
+	// Decommission enables raising pull requests removing the directories of clusters which are no longer configured.
+	Decommission bool
+
+	// Validate only validates the configuration, see Promoter.Validate, instead of promoting.
+	Validate bool
+
+	// Manual tells the promotion was triggered manually, promoting workloads to their manual-only environments.
+	Manual bool
+
+	// SemanticDiff skips the workload changes which only modify YAML files cosmetically, e.g. comments or key order.
+	SemanticDiff bool
+
+	// Drift only reports the workloads which drifted from their source, see Promoter.Drift, instead of promoting.
+	Drift bool
+
+	// Resync enables raising pull requests re-syncing the drifted workloads with their source, with Drift.
+	Resync bool
+}
+
+// TargetEnvs returns the target environments, as TargetEnv can list several environments
+// promoted from the same source, e.g. "prod-eu,prod-us".
+func (a *Args) TargetEnvs() []string {
+	var envs []string
+	for _, env := range strings.Split(a.TargetEnv, ",") {
+		if env = strings.TrimSpace(env); env != "" {
+			envs = append(envs, env)
+		}
+	}
+	return envs
 }
 
 type Promotion interface {
@@ -56,6 +112,7 @@ type Promotion interface {
 	Kind() promotion.Kind
 	Assignes() []string
 	SourceCommits() []*github.Commit
+	Skipped() []promotion.Skipped
 }
 
 type Promoter struct {
@@ -64,8 +121,15 @@ type Promoter struct {
 	kustomization *kustomization.Kust
 	prBuilder     *PullRequestBuilder
 
-	registry clusterconf.WorkloadRegistry // providing workload exclusion filtering
-	clusters clusterconf.ClusterDetection
+	registry  clusterconf.WorkloadRegistry // providing workload exclusion filtering
+	inspecter *clusterconf.ClusterInspecter
+	config    clusterconf.Config
+
+	freezeOverride string
+	decommission   bool
+	manual         bool
+	resync         bool
+	semanticDiff   bool
 
 	logger *logrus.Entry
 }
@@ -108,10 +172,17 @@ func NewPromoter(ctx context.Context, args *Args, log *logrus.Entry, ghClient *g
 		return nil, err
 	}
 
-	builder, err := NewPullRequestBuilder(fs, log, environment.Env(args.TargetEnv))
+	config, configOrigin, err := fetchClustersConfig(ctxTimeout, ghClient, repo, fs, args)
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("config", configOrigin.String()).Info("Read clusters configuration")
+
+	builder, err := NewPullRequestBuilder(fs, log, config.Pipeline, environment.Env(args.TargetEnv))
 	if err != nil {
 		return nil, fmt.Errorf("descriptionBuilder: %w", err)
 	}
+	builder = builder.WithConfigOrigin(configOrigin.String())
 
 	// Explicitly remove the .git file created after clone and checkout.
 	// See the test Test_GitAssumptions_StorageUsedForGitCloneCanImpactOnClonedRepoWorktree
@@ -120,82 +191,240 @@ func NewPromoter(ctx context.Context, args *Args, log *logrus.Entry, ghClient *g
 		return nil, fmt.Errorf("removing billy.Filesystem's .git: %w", err)
 	}
 
-	clustersFromConfigRepo, err := fetchClustersConfig(ctx, ghClient, args)
-	if err != nil {
-		return nil, err
-	}
-
-	workloadRegistry := clusterconf.NewWorkloadRegistry(fs, "flux/manifests", log)
+	workloadRegistry := clusterconf.NewWorkloadRegistry(fs, config.Layout, log)
 
-	d, err := detect.NewDetect(repo, args.CommitRange, workloadRegistry, log)
+	d, err := detect.NewDetect(repo, args.CommitRange, workloadRegistry, config.Layout, log)
 	if err != nil {
 		return nil, fmt.Errorf("detect.New: %w", err)
 	}
+	d.SemanticDiff = args.SemanticDiff
 
-	clusterDetector, err := clusterconf.NewClusterInspecter(repo, log)
+	clusterInspecter, err := clusterconf.NewClusterInspecter(repo, config.Layout, log)
 	if err != nil {
 		return nil, fmt.Errorf("detect.NewCluster: %w", err)
 	}
 
-	clusters, err := clusterDetector.Detect(clustersFromConfigRepo, environment.Env(args.TargetEnv))
+	clusterInspecter.Previous, err = fetchPreviousClusters(ctxTimeout, ghClient, repo, args)
 	if err != nil {
-		return nil, fmt.Errorf("clusterconf.ClusterDetection: %w", err)
+		return nil, err
 	}
 
 	promoter := &Promoter{
 		manifestRepo:  manifestRepo,
 		detect:        d,
-		kustomization: kustomization.NewKust(log),
+		kustomization: kustomization.NewKust(config.Layout, log),
 		prBuilder:     builder,
 		registry:      workloadRegistry,
-		clusters:      clusters,
+		inspecter:     clusterInspecter,
+		config:        config,
 		logger:        log,
+
+		freezeOverride: args.FreezeOverride,
+		decommission:   args.Decommission,
+		manual:         args.Manual,
+		resync:         args.Resync,
+		semanticDiff:   args.SemanticDiff,
 	}
 	return promoter, nil
 }
 
-func (p *Promoter) Promote(ctx context.Context, env string) error {
+// ValidateTargets checks the target environments before any of them is promoted: each of them must be
+// in the pipeline, and they must all be promoted from the same source environment.
+func (p *Promoter) ValidateTargets(targets []string) error {
+	var source environment.Env
+	for i, target := range targets {
+		targetEnv := environment.Env(target)
+		if err := p.config.Pipeline.Validate(targetEnv); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidEnvironment, err)
+		}
+
+		targetSource, err := p.config.Pipeline.ManifestSource(targetEnv)
+		if err != nil {
+			return fmt.Errorf("pipeline.ManifestSource: %w", err)
+		}
+		if i > 0 && targetSource != source {
+			return fmt.Errorf("%w: %s is promoted from %s, %s from %s", ErrTargetsSource, targets[0], source, target, targetSource)
+		}
+		source = targetSource
+	}
+	return nil
+}
+
+// Promote raises promotion pull requests for the target environment. Several environments
+// sharing the same source can be promoted one after another, each of them is checked for
+// consistency and gets its own pull requests.
+// ErrChangeFreeze is returned when a change freeze of the whole environment is in force.
+func (p *Promoter) Promote(ctx context.Context, env string) (Summary, error) {
 	targetEnv := environment.Env(env)
-	if err := targetEnv.Validate(); err != nil {
-		return ErrInvalidEnvironment
+	summary := Summary{Env: targetEnv}
+	if err := p.config.Pipeline.Validate(targetEnv); err != nil {
+		return summary, ErrInvalidEnvironment
+	}
+
+	freezes := p.config.ActiveFreezes(targetEnv, time.Now())
+	if err := p.checkFreezes(freezes, targetEnv); err != nil {
+		return summary, err
+	}
+
+	clusters, err := p.inspecter.Detect(p.config.Clusters, p.config.Pipeline, targetEnv)
+	if err != nil {
+		return summary, fmt.Errorf("clusterconf.ClusterDetection: %w", err)
+	}
+
+	// relocations and decommissions don't promote any workload, they don't wait for the clusters to be in sync
+	err = p.promoteWorkloads(ctx, clusters, targetEnv, freezes, &summary)
+	if errors.Is(err, ErrClustersNotInSync) {
+		p.logger.Info(NotInSyncMsg)
+	} else if err != nil {
+		return summary, err
+	}
+
+	ctxRelocation, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	promotionRelocation := promotion.NewPromotionRelocation(p.logger, targetEnv, p.manifestRepo, clusters)
+	if err := p.relocateClusters(ctxRelocation, promotionRelocation, targetEnv, &summary); err != nil {
+		return summary, err
+	}
+
+	ctxDecommission, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	promotionDecommission := promotion.NewPromotionDecommission(p.logger, targetEnv, p.manifestRepo, clusters)
+	if err := p.decommissionClusters(ctxDecommission, promotionDecommission, targetEnv, &summary); err != nil {
+		return summary, err
 	}
 
+	return summary, nil
+}
+
+// promoteWorkloads raises the pull requests promoting the changed workloads to the existing clusters, then those
+// promoting all the workloads to the new clusters.
+func (p *Promoter) promoteWorkloads(ctx context.Context, clusters clusterconf.ClusterDetection, targetEnv environment.Env, freezes []clusterconf.FreezeWindow, summary *Summary) error {
 	ctxExisting, cancel := context.WithTimeout(ctx, Timeout)
 	defer cancel()
 
-	promotionManifests, err := promotion.NewPromotionManifestUpdate(ctxExisting, p.logger, targetEnv, p.manifestRepo, p.detect, p.clusters)
+	promotionManifests, err := promotion.NewPromotionManifestUpdate(ctxExisting, p.logger, targetEnv, p.manifestRepo, p.detect, clusters, p.registry, p.manual)
 	if err != nil {
 		return err
 	}
-	err = p.promote(ctxExisting, promotionManifests, targetEnv)
-	if err != nil {
-		if errors.Is(err, ErrClustersNotInSync) {
-			p.logger.Info(NotInSyncMsg)
-			return nil
-		}
+	if err := p.promote(ctxExisting, promotionManifests, targetEnv, freezes, summary); err != nil {
 		return err
 	}
 
 	ctxNew, cancel := context.WithTimeout(ctx, Timeout)
 	defer cancel()
 
-	promotionNewCluster, err := promotion.NewPromotionToNewCluster(ctxNew, p.logger, targetEnv, p.manifestRepo, p.detect, p.clusters)
+	promotionNewCluster, err := promotion.NewPromotionToNewCluster(ctxNew, p.logger, targetEnv, p.manifestRepo, p.detect, clusters, p.registry, p.manual)
 	if err != nil {
 		return err
 	}
-	err = p.promote(ctxNew, promotionNewCluster, targetEnv)
+	return p.promote(ctxNew, promotionNewCluster, targetEnv, freezes, summary)
+}
+
+// relocateClusters raises a single pull request moving the folders of the clusters whose manifest folder changed.
+func (p *Promoter) relocateClusters(ctx context.Context, relocation *promotion.PromotionRelocation, targetEnv environment.Env, summary *Summary) error {
+	_, clusters, err := relocation.Changes()
 	if err != nil {
-		if errors.Is(err, ErrClustersNotInSync) {
-			p.logger.Info(NotInSyncMsg)
-			return nil
-		}
 		return err
 	}
 
+	if len(clusters) == 0 {
+		return nil
+	}
+
+	for _, r := range relocation.Relocations() {
+		p.logger.WithFields(logrus.Fields{
+			"cluster":    r.Cluster.Name(),
+			"from":       r.From.ManifestFolder(),
+			"to":         r.Cluster.ManifestFolder(),
+			"target_env": targetEnv,
+		}).Info("Found relocated cluster")
+	}
+
+	branchName, err := p.manifestRepo.NewPromoteBranch()
+	if err != nil {
+		return err
+	}
+
+	results, err := relocation.Moves()
+	if err != nil {
+		return err
+	}
+
+	if err := relocation.AfterChanges(results, clusters); err != nil {
+		return err
+	}
+
+	pr := p.prBuilder.ForEnv(targetEnv).WithRelocations(relocation.Relocations()).Build(results, relocation.SourceCommits(), relocation.Kind())
+	if err := p.manifestRepo.Commit(pr.CommitMessage); err != nil {
+		return err
+	}
+
+	if err := p.manifestRepo.RaisePromotion(ctx, branchName, pr, relocation.Assignes()); err != nil {
+		return err
+	}
+
+	summary.PullRequests = append(summary.PullRequests, pr.Title)
+	return nil
+}
+
+// decommissionClusters raises a pull request per orphaned cluster, removing its manifest and config folders.
+// Without opting in to decommissioning, the orphaned clusters are only logged.
+func (p *Promoter) decommissionClusters(ctx context.Context, decommission *promotion.PromotionDecommission, targetEnv environment.Env, summary *Summary) error {
+	_, clusters, err := decommission.Changes()
+	if err != nil {
+		return err
+	}
+
+	if len(clusters) == 0 {
+		return nil
+	}
+
+	for _, cluster := range clusters {
+		p.logger.WithFields(logrus.Fields{
+			"cluster":        cluster.Name(),
+			"manifestFolder": cluster.ManifestFolder(),
+			"configFolder":   cluster.ConfigFolder(),
+			"target_env":     targetEnv,
+		}).Warn("Found orphaned cluster")
+	}
+
+	if !p.decommission {
+		p.logger.Warn(OrphanedMsg)
+		return nil
+	}
+
+	for _, cluster := range clusters {
+		branchName, err := p.manifestRepo.NewPromoteBranch()
+		if err != nil {
+			return err
+		}
+
+		results, err := decommission.Removals(cluster)
+		if err != nil {
+			return err
+		}
+
+		if err := decommission.AfterChanges(results, clusterconf.Clusters{cluster}); err != nil {
+			return err
+		}
+
+		pr := p.prBuilder.ForEnv(targetEnv).Build(results, decommission.SourceCommits(), decommission.Kind())
+		if err := p.manifestRepo.Commit(pr.CommitMessage); err != nil {
+			return err
+		}
+
+		if err := p.manifestRepo.RaisePromotion(ctx, branchName, pr, decommission.Assignes()); err != nil {
+			return err
+		}
+
+		summary.PullRequests = append(summary.PullRequests, pr.Title)
+	}
 	return nil
 }
 
-func (p *Promoter) promote(ctx context.Context, promotion Promotion, targetEnv environment.Env) error {
+func (p *Promoter) promote(ctx context.Context, promotion Promotion, targetEnv environment.Env, freezes []clusterconf.FreezeWindow, summary *Summary) error {
 	p.logger.WithFields(
 		logrus.Fields{
 			"promotion_type": promotion.Kind(),
@@ -212,13 +441,56 @@ func (p *Promoter) promote(ctx context.Context, promotion Promotion, targetEnv e
 		return nil
 	}
 
-	for _, clustersGroup := range clusters.Group(targetEnv) {
+	unfrozen := p.unfrozenChanges(changes, freezes, summary)
+	if len(unfrozen) == 0 {
+		p.logger.Info(FrozenMsg)
+		return nil
+	}
+
+	if isNewCluster(promotion) && len(unfrozen) < len(changes) {
+		p.logger.Info(NewClusterHeldMsg)
+		return nil
+	}
+	changes = unfrozen
+
+	soaked, err := p.soakedChanges(changes, targetEnv, summary)
+	if err != nil {
+		return err
+	}
+
+	if len(soaked) == 0 {
+		p.logger.Info(NotSoakedMsg)
+		return nil
+	}
+
+	if isNewCluster(promotion) && len(soaked) < len(changes) {
+		p.logger.Info(NewClusterHeldMsg)
+		return nil
+	}
+	changes = soaked
+
+	clusters, err = p.currentWave(ctx, changes, clusters, targetEnv)
+	if err != nil {
+		return err
+	}
+
+	if len(clusters) == 0 {
+		p.logger.Info(WavesDoneMsg)
+		return nil
+	}
+
+	grouping, err := p.groupingPolicy(changes, targetEnv)
+	if err != nil {
+		return err
+	}
+
+	for _, clustersGroup := range clusters.Group(grouping) {
 		branchName, err := p.manifestRepo.NewPromoteBranch()
 		if err != nil {
 			return err
 		}
 
-		results, err := p.performChanges(ctx, changes, clustersGroup, targetEnv)
+		results, paused, err := p.performChanges(ctx, changes, clustersGroup, targetEnv)
 		if err != nil {
 			return err
 		}
@@ -231,7 +503,18 @@ func (p *Promoter) promote(ctx context.Context, promotion Promotion, targetEnv e
 			return err
 		}
 
-		pr := p.prBuilder.Build(results, promotion.SourceCommits(), promotion.Kind())
+		pr := p.prBuilder.
+			ForEnv(targetEnv).
+			WithFreezeOverride(p.freezeOverride, overriddenFreezes(freezes, results, p.freezeOverride)).
+			WithPausedClusters(paused, clustersGroup).
+			WithSkippedWorkloads(promotion.Skipped()).
+			Build(results, promotion.SourceCommits(), promotion.Kind())
+
+		pr.Reviewers, pr.TeamReviewers, err = p.workloadOwners(results)
+		if err != nil {
+			return err
+		}
+
 		err = p.manifestRepo.Commit(pr.CommitMessage)
 		if err != nil {
 			return err
@@ -241,37 +524,84 @@ func (p *Promoter) promote(ctx context.Context, promotion Promotion, targetEnv e
 		if err != nil {
 			return err
 		}
+
+		summary.PullRequests = append(summary.PullRequests, pr.Title)
 	}
 	return nil
 }
 
+// isNewCluster tells whether the promotion bootstraps new clusters. Once they exist, the clusters only get the
+// workloads changed in the commit range, so all the workloads are promoted to them at once or not at all.
+func isNewCluster(p Promotion) bool {
+	return p.Kind() == promotion.NewCluster
+}
+
+// groupingPolicy returns the grouping policy of the target environment, limited by the maximum number
+// of clusters per pull request of the changed workloads.
+func (p *Promoter) groupingPolicy(changes []detect.WorkloadChange, targetEnv environment.Env) (clusterconf.GroupingPolicy, error) {
+	policy := p.config.GroupingPolicy(targetEnv)
+	for _, change := range changes {
+		workload, err := p.registry.Get(change.W.Name)
+		if err != nil {
+			return policy, fmt.Errorf("registry.Get: %w", err)
+		}
+
+		max := workload.Spec.Promotion.MaxClustersPerPR
+		if max > 0 && (policy.MaxSize == 0 || max < policy.MaxSize) {
+			policy.MaxSize = max
+		}
+	}
+	return policy, nil
+}
+
 // performChanges performs change.OP for all changes in each cluster where workload belonging to the change is allowed
 // This will change the working tree of the repository i.e. un-staged changes.
-func (p *Promoter) performChanges(ctx context.Context, changes []detect.WorkloadChange, clusters clusterconf.Clusters, targetEnv environment.Env) (promotion.Results, error) {
+// The changes allowed in paused clusters aren't performed, they are returned separately.
+func (p *Promoter) performChanges(ctx context.Context, changes []detect.WorkloadChange, clusters clusterconf.Clusters, targetEnv environment.Env) (promotion.Results, promotion.Results, error) {
 	// A cluster can have workload exclusion and at this point we will omit it (happens in allowedChanges)
 	// We need to keep track of which clusters actually received promotion to raise PR only for them
 	promotions := make(promotion.Results, len(clusters))
+	paused := promotion.Results{}
 
+	now := time.Now()
 	for _, cluster := range clusters {
-		clusterChanges, err := p.allowedChanges(ctx, changes, cluster)
+		clusterChanges, err := p.allowedChanges(ctx, changes, cluster, targetEnv)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		if cluster.IsPaused(now) && len(clusterChanges) > 0 {
+			p.logger.WithFields(logrus.Fields{
+				"cluster": cluster.Name(),
+				"pause":   cluster.Spec.Paused.String(),
+			}).Info("Skipping paused cluster")
+
+			paused[cluster.Name()] = make(map[string]detect.WorkloadChange)
+			for _, change := range clusterChanges {
+				paused[cluster.Name()][change.W.Name] = change
+			}
+			continue
+		}
+
+		clusterChanges, err = p.changesWithPromotedDependencies(clusterChanges, cluster, targetEnv)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		for _, clusterWorkloadChange := range clusterChanges {
 			workload, err := p.registry.Get(clusterWorkloadChange.W.Name)
 			if err != nil {
-				return nil, fmt.Errorf("registry.Get: %w", err)
+				return nil, nil, fmt.Errorf("registry.Get: %w", err)
 			}
 
 			err = p.verifyWorkloadConsistency(workload, environment.Env(clusterWorkloadChange.W.SourceEnv))
 			if err != nil {
-				return nil, fmt.Errorf("verifyWorkloadConsistency: %w", err)
+				return nil, nil, fmt.Errorf("verifyWorkloadConsistency: %w", err)
 			}
 
 			err = p.performChange(ctx, cluster, clusterWorkloadChange, targetEnv)
 			if err != nil {
-				return nil, fmt.Errorf("performChange: %w", err)
+				return nil, nil, fmt.Errorf("performChange: %w", err)
 			}
 
 			// this tells us what was promoted to where and why (what's the kind of the promotion)
@@ -284,7 +614,88 @@ func (p *Promoter) performChanges(ctx context.Context, changes []detect.Workload
 		}
 	}
 
-	return promotions, nil
+	return promotions, paused, nil
+}
+
+// changesWithPromotedDependencies drops the changes of workloads whose dependencies are neither promoted along
+// with them nor already promoted to the cluster, at the version of the source environment or a newer one.
+func (p *Promoter) changesWithPromotedDependencies(changes []detect.WorkloadChange, cluster clusterconf.Cluster, targetEnv environment.Env) ([]detect.WorkloadChange, error) {
+	copied := map[string]bool{}
+	for _, change := range changes {
+		if change.Op == detect.OperationCopy {
+			copied[change.W.Name] = true
+		}
+	}
+
+	// a blocked workload blocks the workloads depending on it in turn
+	blocked := map[string]string{}
+	for updated := true; updated; {
+		updated = false
+		for _, change := range changes {
+			if !copied[change.W.Name] || blocked[change.W.Name] != "" {
+				continue
+			}
+
+			workload, err := p.registry.Get(change.W.Name)
+			if err != nil {
+				return nil, fmt.Errorf("registry.Get: %w", err)
+			}
+
+			for _, dependency := range workload.Spec.DependsOn {
+				if copied[dependency] && blocked[dependency] == "" {
+					continue
+				}
+
+				promoted, err := p.dependencyPromoted(dependency, cluster, targetEnv)
+				if err != nil {
+					return nil, err
+				}
+				if !promoted {
+					blocked[change.W.Name] = dependency
+					updated = true
+					break
+				}
+			}
+		}
+	}
+
+	var allowed []detect.WorkloadChange
+	for _, change := range changes {
+		if dependency, ok := blocked[change.W.Name]; ok {
+			p.logger.WithFields(logrus.Fields{
+				"cluster":    cluster.Name(),
+				"workload":   change.W.Name,
+				"dependency": dependency,
+			}).Warn(DependencyMsg)
+			continue
+		}
+		allowed = append(allowed, change)
+	}
+	return allowed, nil
+}
+
+// dependencyPromoted checks whether the dependency is promoted to the cluster at the version of the source
+// environment or a newer one.
+func (p *Promoter) dependencyPromoted(dependency string, cluster clusterconf.Cluster, targetEnv environment.Env) (bool, error) {
+	workload, err := p.registry.Get(dependency)
+	if err != nil {
+		return false, fmt.Errorf("registry.Get: %w", err)
+	}
+
+	if !cluster.AllowWorkload(workload) {
+		return false, nil
+	}
+
+	sourceDir, err := p.getSourceDir(detect.WorkloadChange{W: detect.Workload{Name: dependency}}, targetEnv)
+	if err != nil {
+		return false, err
+	}
+
+	promoted, err := p.manifestRepo.HadContentOf(cluster.WorkloadPath(dependency), sourceDir)
+	if err != nil {
+		return false, fmt.Errorf("HadContentOf: %w", err)
+	}
+	return promoted, nil
 }
 
 // performChange uses previous environment as source for copying workload manifests from.
@@ -344,14 +755,13 @@ func (p *Promoter) performChange(ctx context.Context, cluster clusterconf.Cluste
 }
 
 func (p *Promoter) getSourceDir(change detect.WorkloadChange, targetEnv environment.Env) (string, error) {
-	manifestsSource, err := targetEnv.ManifestSource()
+	manifestsSource, err := p.config.Pipeline.ManifestSource(targetEnv)
 	if err != nil {
-		return "", fmt.Errorf("targetEnv.ManifestSource: %w", err)
+		return "", fmt.Errorf("pipeline.ManifestSource: %w", err)
 	}
 
 	if manifestsSource == environment.SourceManifest {
-		// TODO we should provide a better way of constructing these paths
-		return clusterconf.Path(filepath.Join(string(environment.SourceManifest), change.W.Name)), nil
+		return p.config.Layout.SourcePath(change.W.Name), nil
 	}
 
 	workload, err := p.registry.Get(change.W.Name)
@@ -359,18 +769,14 @@ func (p *Promoter) getSourceDir(change detect.WorkloadChange, targetEnv environm
 		return "", fmt.Errorf("registry.Get: %w", err)
 	}
 
-	previousClusters := p.clusters.All.
+	// paused clusters may be behind the rest of the environment
+	previousClusters := p.config.Clusters.
 		Filter(clusterconf.ByAllowWorkload(workload)).
-		Filter(clusterconf.ByEnvironment(manifestsSource))
+		Filter(clusterconf.ByEnvironment(manifestsSource)).
+		Filter(clusterconf.NotPaused(time.Now()))
 
 	if len(previousClusters) == 0 {
-		p.logger.WithFields(logrus.Fields{
-			"workload":     workload,
-			"previous_env": manifestsSource,
-		}).Error("filtered clusters are zero, expected at least one")
-
-		return "", fmt.Errorf("workload: %s, env: %s: filtered clusters are zero, expected at least one",
-			workload, manifestsSource)
+		return "", fmt.Errorf("workload: %s, env: %s: %w", workload.Name(), manifestsSource, ErrNoSourceCluster)
 	}
 
 	return previousClusters[0].WorkloadPath(change.W.Name), nil
@@ -378,15 +784,17 @@ func (p *Promoter) getSourceDir(change detect.WorkloadChange, targetEnv environm
 
 // verifyWorkloadConsistency ensures that a workload inside an environment is consistent, meaning that the
 // contents of the workload directory are identical. The business requirement is that all PRs
-// for an environment are merged before continuing to the next environment.
+// for an environment are merged before continuing to the next environment. Paused clusters don't
+// receive the PRs, so they are left out.
 func (p *Promoter) verifyWorkloadConsistency(workload clusterconf.Workload, manifestSource environment.Env) error {
 	if manifestSource == environment.SourceManifest {
 		return nil
 	}
 
-	previousClusters := p.clusters.All.
+	previousClusters := p.config.Clusters.
 		Filter(clusterconf.ByAllowWorkload(workload)).
-		Filter(clusterconf.ByEnvironment(manifestSource))
+		Filter(clusterconf.ByEnvironment(manifestSource)).
+		Filter(clusterconf.NotPaused(time.Now()))
 
 	fs, err := p.manifestRepo.WorkingTreeFS()
 	if err != nil {
@@ -415,57 +823,419 @@ func (p *Promoter) verifyWorkloadConsistency(workload clusterconf.Workload, mani
 	return nil
 }
 
-func (p *Promoter) allowedChanges(ctx context.Context, changes []detect.WorkloadChange, cluster clusterconf.Cluster) ([]detect.WorkloadChange, error) {
-	if ctx.Err() != nil {
-		return nil, fmt.Errorf("allowedChanges: %w", ctx.Err())
+// checkFreezes returns ErrChangeFreeze if a freeze of the whole environment is in force and not overridden.
+func (p *Promoter) checkFreezes(freezes []clusterconf.FreezeWindow, targetEnv environment.Env) error {
+	var envFreezes []string
+	for _, freeze := range freezes {
+		if len(freeze.Workloads) == 0 {
+			envFreezes = append(envFreezes, freeze.String())
+		}
 	}
 
-	var perClusterChanges []detect.WorkloadChange
+	if len(envFreezes) == 0 {
+		return nil
+	}
+
+	log := p.logger.WithFields(logrus.Fields{
+		"target_env": targetEnv,
+		"freezes":    envFreezes,
+	})
+	if p.freezeOverride != "" {
+		log.WithField("reason", p.freezeOverride).Warn("Overriding change freeze")
+		return nil
+	}
+
+	log.Warn("Change freeze in force, not promoting")
+	return fmt.Errorf("%s: %s: %w", targetEnv, strings.Join(envFreezes, ", "), ErrChangeFreeze)
+}
+
+// unfrozenChanges drops the changes of workloads frozen by a workload specific freeze, unless freezes
+// are overridden. Dropped workloads are logged and added to the summary as postponed.
+func (p *Promoter) unfrozenChanges(changes []detect.WorkloadChange, freezes []clusterconf.FreezeWindow, summary *Summary) []detect.WorkloadChange {
+	if p.freezeOverride != "" {
+		return changes
+	}
 
+	var unfrozen []detect.WorkloadChange
 	for _, change := range changes {
-		workload, err := p.registry.Get(change.W.Name)
+		frozen := false
+		for _, freeze := range freezes {
+			if len(freeze.Workloads) == 0 || !freeze.Freezes(change.W.Name) {
+				continue
+			}
+
+			until, _ := freeze.EndsAt(time.Now())
+			p.logger.WithFields(logrus.Fields{
+				"workload": change.W.Name,
+				"freeze":   freeze.String(),
+				"until":    until.Format(time.RFC3339),
+			}).Info("Postponing frozen workload")
+
+			summary.postpone(change.W.Name, fmt.Sprintf("change freeze %s", freeze.Name), until)
+			frozen = true
+		}
+
+		if !frozen {
+			unfrozen = append(unfrozen, change)
+		}
+	}
+
+	return unfrozen
+}
+
+// overriddenFreezes returns the descriptions of the freezes applying to the promoted workloads when freezes are overridden.
+func overriddenFreezes(freezes []clusterconf.FreezeWindow, results promotion.Results, override string) []string {
+	if override == "" {
+		return nil
+	}
+
+	var overridden []string
+	for _, freeze := range freezes {
+		for _, workload := range results.WorkloadNames() {
+			if freeze.Freezes(workload) {
+				overridden = append(overridden, freeze.String())
+				break
+			}
+		}
+	}
+	return overridden
+}
+
+// soakedChanges drops the changes of workloads which were changed in the source environment more recently
+// than its minimum soak time allows. Dropped workloads are logged and added to the summary as postponed.
+func (p *Promoter) soakedChanges(changes []detect.WorkloadChange, targetEnv environment.Env, summary *Summary) ([]detect.WorkloadChange, error) {
+	sourceEnv, err := p.config.Pipeline.ManifestSource(targetEnv)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline.ManifestSource: %w", err)
+	}
+
+	soakTime := p.config.MinSoakTime(sourceEnv)
+	if soakTime == 0 {
+		return changes, nil
+	}
+
+	var soaked []detect.WorkloadChange
+	for _, change := range changes {
+		lastChanged, err := p.lastChangedInEnv(change.W.Name, sourceEnv)
 		if err != nil {
-			return nil, fmt.Errorf("p.registry.Get: %w", err)
+			return nil, err
+		}
+
+		until := lastChanged.Add(soakTime)
+		if time.Now().Before(until) {
+			p.logger.WithFields(logrus.Fields{
+				"workload":     change.W.Name,
+				"source_env":   sourceEnv,
+				"last_changed": lastChanged.Format(time.RFC3339),
+				"soak_time":    soakTime,
+			}).Info("Postponing workload which hasn't soaked long enough in the source environment")
+
+			summary.postpone(change.W.Name, fmt.Sprintf("soaking in %s", sourceEnv), until)
+			continue
+		}
+
+		soaked = append(soaked, change)
+	}
+
+	return soaked, nil
+}
+
+// lastChangedInEnv returns the time of the most recent commit changing the workload in any unpaused cluster of env.
+func (p *Promoter) lastChangedInEnv(workloadName string, env environment.Env) (time.Time, error) {
+	workload, err := p.registry.Get(workloadName)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("registry.Get: %w", err)
+	}
+
+	// paused clusters may be behind the rest of the environment, catching up doesn't make the workload change
+	clusters := p.config.Clusters.
+		Filter(clusterconf.ByAllowWorkload(workload)).
+		Filter(clusterconf.ByEnvironment(env)).
+		Filter(clusterconf.NotPaused(time.Now()))
+
+	var lastChanged time.Time
+	for _, c := range clusters {
+		changed, err := p.manifestRepo.LastChanged(c.WorkloadPath(workloadName))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("manifestRepo.LastChanged: %w", err)
+		}
+
+		if changed.After(lastChanged) {
+			lastChanged = changed
+		}
+	}
+
+	return lastChanged, nil
+}
+
+// currentWave returns the clusters of the earliest rollout wave that don't match the source yet.
+// Later waves are only promoted once all clusters of the previous waves match the source. Paused
+// clusters don't hold back the later waves, they are returned along with the other clusters of
+// their wave.
+func (p *Promoter) currentWave(ctx context.Context, changes []detect.WorkloadChange, clusters clusterconf.Clusters, targetEnv environment.Env) (clusterconf.Clusters, error) {
+	waves := p.config.Waves(targetEnv, clusters)
+	if len(waves) == 1 {
+		return clusters, nil
+	}
+
+	now := time.Now()
+	for i, wave := range waves {
+		var pending, paused clusterconf.Clusters
+		for _, cluster := range wave {
+			if cluster.IsPaused(now) {
+				paused = append(paused, cluster)
+				continue
+			}
+
+			inSync, err := p.matchesSource(ctx, changes, cluster, targetEnv)
+			if err != nil {
+				return nil, fmt.Errorf("matchesSource: %w", err)
+			}
+
+			if !inSync {
+				pending = append(pending, cluster)
+			}
+		}
+
+		if len(pending) > 0 {
+			p.logger.WithFields(logrus.Fields{
+				"wave":       i + 1,
+				"waves":      len(waves),
+				"target_env": targetEnv,
+			}).Info("Promoting rollout wave")
+			return append(pending, paused...), nil
+		}
+	}
+
+	return clusterconf.Clusters{}, nil
+}
+
+// matchesSource checks whether the workloads of the changes allowed in the cluster are identical to their source.
+func (p *Promoter) matchesSource(ctx context.Context, changes []detect.WorkloadChange, cluster clusterconf.Cluster, targetEnv environment.Env) (bool, error) {
+	if ctx.Err() != nil {
+		return false, fmt.Errorf("matchesSource: %w", ctx.Err())
+	}
+
+	clusterChanges, err := p.clusterChanges(changes, cluster, targetEnv, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	fs, err := p.manifestRepo.WorkingTreeFS()
+	if err != nil {
+		return false, err
+	}
+
+	for _, change := range clusterChanges {
+		if change.excluded {
+			continue
+		}
+
+		targetDir := cluster.WorkloadPath(change.W.Name)
+		_, err := fs.Stat(targetDir)
+		exists := err == nil
+
+		if change.Op == detect.OperationRemove {
+			if exists {
+				return false, nil
+			}
+			continue
+		}
+
+		if !exists {
+			return false, nil
+		}
+
+		sourceDir, err := p.getSourceDir(change.WorkloadChange, targetEnv)
+		if err != nil {
+			return false, err
+		}
+
+		sourceHash, err := p.dirHash(fs, sourceDir)
+		if err != nil {
+			return false, fmt.Errorf("hash directory %s: %w", sourceDir, err)
+		}
+
+		targetHash, err := p.dirHash(fs, targetDir)
+		if err != nil {
+			return false, fmt.Errorf("hash directory %s: %w", targetDir, err)
 		}
 
-		if cluster.AllowWorkload(workload) {
-			perClusterChanges = append(perClusterChanges, change)
-		} else {
+		if sourceHash != targetHash {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// dirHash hashes the files of dir to compare a workload with its source. With the semantic diff, the cosmetic
+// changes of YAML files aren't promoted, so they are left out of the comparison.
+func (p *Promoter) dirHash(fs billy.Filesystem, dir string) (string, error) {
+	if p.semanticDiff {
+		return filesystem.SemanticDirHash(fs, dir)
+	}
+	return filesystem.DirHash(fs, dir)
+}
+
+// allowedChanges returns the changes to perform in the cluster, logging the excluded and retargeted workloads.
+func (p *Promoter) allowedChanges(ctx context.Context, changes []detect.WorkloadChange, cluster clusterconf.Cluster, targetEnv environment.Env) ([]detect.WorkloadChange, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("allowedChanges: %w", ctx.Err())
+	}
+
+	now := time.Now()
+	clusterChanges, err := p.clusterChanges(changes, cluster, targetEnv, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var perClusterChanges []detect.WorkloadChange
+	for _, change := range clusterChanges {
+		switch {
+		case change.excluded:
 			p.logger.WithFields(
 				logrus.Fields{
 					"cluster":   cluster.Name(),
-					"workload":  workload,
+					"workload":  change.workload,
 					"operation": change.Op,
 				}).Infof("workload excluded")
+			continue
+		case change.Op == detect.OperationRemove && change.Targeting:
+			p.logger.WithFields(
+				logrus.Fields{
+					"cluster":  cluster.Name(),
+					"workload": change.workload.Name(),
+				}).Info(RetargetMsg)
+		default:
+			p.warnExpiredExclusions(change.workload, cluster, now)
 		}
+		perClusterChanges = append(perClusterChanges, change.WorkloadChange)
 	}
 
 	return perClusterChanges, nil
 }
 
-func fetchClustersConfig(ctx context.Context, ghClient *gh.Client, args *Args) (clusterconf.Clusters, error) {
-	config, _, _, err := ghClient.Repositories.GetContents(
-		ctx,
-		args.CloneArgs.Owner,
-		args.ConfigRepository,
-		args.ConfigPath,
-		&gh.RepositoryContentGetOptions{
-			Ref: "master",
-		},
-	)
+// clusterChange is a change of the commit range as performed in a cluster.
+type clusterChange struct {
+	detect.WorkloadChange
+	workload clusterconf.Workload
+
+	// excluded tells that the cluster doesn't allow the workload, the change isn't performed.
+	excluded bool
+}
+
+// clusterChanges returns the changes as performed in the cluster, without logging. The changes of workloads whose
+// inclusions or exclusions changed in the commit range retarget them: they are removed from the cluster if it was
+// just excluded, or copied to it if it was just included.
+func (p *Promoter) clusterChanges(changes []detect.WorkloadChange, cluster clusterconf.Cluster, targetEnv environment.Env, now time.Time) ([]clusterChange, error) {
+	var clusterChanges []clusterChange
+	for _, change := range changes {
+		workload, err := p.registry.Get(change.W.Name)
+		if err != nil {
+			return nil, fmt.Errorf("p.registry.Get: %w", err)
+		}
+
+		allowed := cluster.AllowWorkloadAt(workload, now)
+		wasAllowed, err := p.previouslyAllowed(change, cluster, targetEnv, now)
+		if err != nil {
+			return nil, err
+		}
+
+		if allowed {
+			change.Targeting = change.Op == detect.OperationCopy && !wasAllowed
+			clusterChanges = append(clusterChanges, clusterChange{WorkloadChange: change, workload: workload})
+			continue
+		}
+
+		promoted, err := p.promotedTo(cluster, change.W.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if change.Op == detect.OperationCopy && wasAllowed && promoted {
+			remove := detect.WorkloadChange{Op: detect.OperationRemove, W: change.W, Targeting: true}
+			clusterChanges = append(clusterChanges, clusterChange{WorkloadChange: remove, workload: workload})
+			continue
+		}
+
+		clusterChanges = append(clusterChanges, clusterChange{WorkloadChange: change, workload: workload, excluded: true})
+	}
+
+	return clusterChanges, nil
+}
+
+// previouslyAllowed checks whether the cluster allowed the workload of the change at the start of the commit
+// range, according to the workload.yaml of the source environment. Workloads which didn't exist then are
+// considered allowed, so that adding a workload isn't a targeting change.
+func (p *Promoter) previouslyAllowed(change detect.WorkloadChange, cluster clusterconf.Cluster, targetEnv environment.Env, now time.Time) (bool, error) {
+	if change.Op != detect.OperationCopy {
+		return true, nil
+	}
+
+	sourceDir, err := p.getSourceDir(change, targetEnv)
 	if err != nil {
-		return clusterconf.Clusters{}, fmt.Errorf("fetching config: %w", err)
+		return false, err
 	}
 
-	content, err := config.GetContent()
+	workload, existed, err := p.detect.PreviousWorkload(change.W.Name, sourceDir)
 	if err != nil {
-		return clusterconf.Clusters{}, fmt.Errorf("decoding config: %w", err)
+		return false, fmt.Errorf("detect.PreviousWorkload: %w", err)
 	}
 
-	clusters, err := clusterconf.ParseClusters(strings.NewReader(content))
+	return !existed || cluster.AllowWorkloadAt(workload, now), nil
+}
+
+// promotedTo checks whether the workload is in the manifest folder of the cluster.
+func (p *Promoter) promotedTo(cluster clusterconf.Cluster, workloadName string) (bool, error) {
+	fs, err := p.manifestRepo.WorkingTreeFS()
 	if err != nil {
-		return clusterconf.Clusters{}, fmt.Errorf("parse clusters: %w", err)
+		return false, err
+	}
+
+	_, err = fs.Stat(cluster.WorkloadPath(workloadName))
+	return err == nil, nil
+}
+
+// warnExpiredExclusions warns about the expired exclusions of the workload which would still exclude it from the cluster.
+func (p *Promoter) warnExpiredExclusions(workload clusterconf.Workload, cluster clusterconf.Cluster, now time.Time) {
+	for _, exc := range workload.ExpiredExclusions(now) {
+		if !exc.Excludes(cluster.Metadata.Labels) {
+			continue
+		}
+
+		p.logger.WithFields(logrus.Fields{
+			"cluster":   cluster.Name(),
+			"workload":  workload.Name(),
+			"exclusion": exc.String(),
+		}).Warn(ExpiredExclusionMsg)
+	}
+}
+
+// workloadOwners returns the users and teams owning the promoted workloads, to request their reviews.
+func (p *Promoter) workloadOwners(results promotion.Results) ([]string, []string, error) {
+	users, teams := map[string]bool{}, map[string]bool{}
+	for _, name := range results.WorkloadNames() {
+		workload, err := p.registry.Get(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("registry.Get: %w", err)
+		}
+
+		for _, user := range workload.Metadata.Owners.Users {
+			users[user] = true
+		}
+		for _, team := range workload.Metadata.Owners.Teams {
+			teams[team] = true
+		}
 	}
+	return sortedKeys(users), sortedKeys(teams), nil
+}
 
-	return clusters, nil
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,49 @@
+package promoter
+
+import (
+	"time"
+
+	"github.com/form3tech/k8s-promoter/internal/environment"
+	"github.com/sirupsen/logrus"
+)
+
+// Summary describes the outcome of promoting a target environment.
+type Summary struct {
+	Env          environment.Env
+	PullRequests []string
+	Postponed    []PostponedWorkload
+}
+
+// PostponedWorkload is a workload with detected changes that was not promoted during this run.
+type PostponedWorkload struct {
+	Workload string
+	Reason   string
+	Until    time.Time
+}
+
+func (s *Summary) postpone(workload, reason string, until time.Time) {
+	s.Postponed = append(s.Postponed, PostponedWorkload{
+		Workload: workload,
+		Reason:   reason,
+		Until:    until,
+	})
+}
+
+// Log writes the summary to the logger, one entry per raised pull request and postponed workload.
+func (s Summary) Log(log *logrus.Entry) {
+	log = log.WithField("target_env", s.Env)
+	for _, title := range s.PullRequests {
+		log.WithField("title", title).Info("Raised pull request")
+	}
+	for _, w := range s.Postponed {
+		log.WithFields(logrus.Fields{
+			"workload": w.Workload,
+			"reason":   w.Reason,
+			"until":    w.Until.Format(time.RFC3339),
+		}).Info("Postponed workload")
+	}
+	log.WithFields(logrus.Fields{
+		"pull_requests": len(s.PullRequests),
+		"postponed":     len(s.Postponed),
+	}).Info("Promotion finished")
+}
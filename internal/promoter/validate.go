@@ -0,0 +1,41 @@
+package promoter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/form3tech/k8s-promoter/internal/clusterconf"
+	"github.com/sirupsen/logrus"
+)
+
+// ExpiredExclusion is an exclusion of a workload which no longer applies and should be removed.
+type ExpiredExclusion struct {
+	Workload  string
+	Exclusion clusterconf.Exclusion
+}
+
+func (e ExpiredExclusion) String() string {
+	return fmt.Sprintf("%s: %s", e.Workload, e.Exclusion)
+}
+
+// Validate lists the expired exclusions of all the workloads of the repository, so that they can be cleaned up.
+func (p *Promoter) Validate() ([]ExpiredExclusion, error) {
+	workloads, err := p.registry.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("registry.GetAll: %w", err)
+	}
+
+	now := time.Now()
+	var expired []ExpiredExclusion
+	for _, workload := range workloads {
+		for _, exc := range workload.ExpiredExclusions(now) {
+			p.logger.WithFields(logrus.Fields{
+				"workload":  workload.Name(),
+				"exclusion": exc.String(),
+			}).Warn("Found expired exclusion")
+
+			expired = append(expired, ExpiredExclusion{Workload: workload.Name(), Exclusion: exc})
+		}
+	}
+	return expired, nil
+}
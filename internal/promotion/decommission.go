@@ -0,0 +1,108 @@
+package promotion
+
+import (
+	"fmt"
+
+	"github.com/form3tech/k8s-promoter/internal/clusterconf"
+	"github.com/form3tech/k8s-promoter/internal/detect"
+	"github.com/form3tech/k8s-promoter/internal/environment"
+	"github.com/form3tech/k8s-promoter/internal/github"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/sirupsen/logrus"
+)
+
+// PromotionDecommission implements Promotion interface. It encapsulates the logic of cleaning up the clusters
+// which were removed from the config repo, but whose directories are still in the repository.
+// It doesn't promote workloads: the manifest and config folders of the orphaned clusters are removed in AfterChanges.
+type PromotionDecommission struct {
+	env           environment.Env
+	kind          Kind
+	sourceCommits []*github.Commit
+	assignees     []string
+
+	clusters clusterconf.ClusterDetection
+	repo     *github.ManifestRepository
+
+	logger *logrus.Entry
+}
+
+func NewPromotionDecommission(l *logrus.Entry, env environment.Env, r *github.ManifestRepository, c clusterconf.ClusterDetection) *PromotionDecommission {
+	return &PromotionDecommission{
+		env:           env,
+		clusters:      c,
+		kind:          Decommission,
+		assignees:     []string{},
+		sourceCommits: []*github.Commit{},
+		logger:        l,
+		repo:          r,
+	}
+}
+
+func (s *PromotionDecommission) Kind() Kind {
+	return s.kind
+}
+
+func (s *PromotionDecommission) Assignes() []string {
+	return s.assignees
+}
+
+func (s *PromotionDecommission) SourceCommits() []*github.Commit {
+	return s.sourceCommits
+}
+
+// Changes returns the orphaned clusters. There are no workload changes to promote to them.
+func (s *PromotionDecommission) Changes() ([]detect.WorkloadChange, clusterconf.Clusters, error) {
+	return []detect.WorkloadChange{}, s.clusters.Orphaned, nil
+}
+
+// Removals returns the workloads removed along with the cluster, as removal changes.
+func (s *PromotionDecommission) Removals(cluster clusterconf.Cluster) (Results, error) {
+	fs, err := s.repo.WorkingTreeFS()
+	if err != nil {
+		return nil, err
+	}
+
+	workloads, err := s.clusters.Layout.Workloads(fs, cluster.ManifestFolder())
+	if err != nil {
+		return nil, fmt.Errorf("list workload directories: %w", err)
+	}
+
+	results := Results{cluster.Name(): map[string]detect.WorkloadChange{}}
+	for _, workload := range workloads {
+		results[cluster.Name()][workload] = detect.WorkloadChange{
+			W:  detect.Workload{Name: workload, SourceEnv: string(s.env)},
+			Op: detect.OperationRemove,
+		}
+	}
+	return results, nil
+}
+
+func (s *PromotionDecommission) AfterChanges(_ Results, clusters clusterconf.Clusters) error {
+	fs, err := s.repo.WorkingTreeFS()
+	if err != nil {
+		return err
+	}
+
+	return s.removeClusters(fs, clusters)
+}
+
+func (s *PromotionDecommission) removeClusters(fs billy.Filesystem, clusters clusterconf.Clusters) error {
+	for _, cluster := range clusters {
+		for _, folder := range []string{cluster.ManifestFolder(), cluster.ConfigFolder()} {
+			if folder == "" {
+				continue
+			}
+
+			s.logger.WithFields(logrus.Fields{
+				"cluster": cluster.Name(),
+				"folder":  folder,
+			}).Info("Removing folder of decommissioned cluster")
+
+			if err := util.RemoveAll(fs, folder); err != nil {
+				return fmt.Errorf("remove %s: %w", folder, err)
+			}
+		}
+	}
+	return nil
+}
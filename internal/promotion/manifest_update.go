@@ -20,20 +20,25 @@ type PromotionManifestUpdate struct {
 	kind          Kind
 	sourceCommits []*github.Commit
 	assignees     []string
+	manual        bool
+	skipped       []Skipped
 
 	clusters clusterconf.ClusterDetection
 	detect   *detect.Detect
 	repo     *github.ManifestRepository
+	registry clusterconf.WorkloadRegistry
 
 	logger *logrus.Entry
 }
 
-func NewPromotionManifestUpdate(ctx context.Context, l *logrus.Entry, env environment.Env, r *github.ManifestRepository, d *detect.Detect, c clusterconf.ClusterDetection) (*PromotionManifestUpdate, error) {
+// NewPromotionManifestUpdate applies the promotion policies of the workloads from the registry, manual
+// tells whether the promotion was triggered manually.
+func NewPromotionManifestUpdate(ctx context.Context, l *logrus.Entry, env environment.Env, r *github.ManifestRepository, d *detect.Detect, c clusterconf.ClusterDetection, registry clusterconf.WorkloadRegistry, manual bool) (*PromotionManifestUpdate, error) {
 	var sourceCommits []*github.Commit
 	var err error
 
-	if env == environment.Development {
-		l.Debug("promoting to development: finding authors from GitHub")
+	if c.SourceEnv == environment.SourceManifest {
+		l.Debugf("promoting to %s: finding authors from GitHub", env)
 		sourceCommits, err = r.GetCommits(ctx, d.CR.FromPrefix, d.CR.ToPrefix)
 	} else {
 		l.Debugf("promoting to %s: finding source manifest authors and commits from commit messages", env)
@@ -53,11 +58,13 @@ func NewPromotionManifestUpdate(ctx context.Context, l *logrus.Entry, env enviro
 		env:           env,
 		kind:          ManifestUpdate,
 		assignees:     assignees,
+		manual:        manual,
 		sourceCommits: sourceCommits,
 		detect:        d,
 		logger:        l,
 		clusters:      c,
 		repo:          r,
+		registry:      registry,
 	}, nil
 }
 
@@ -72,6 +79,11 @@ func (s *PromotionManifestUpdate) Changes() ([]detect.WorkloadChange, clustercon
 		return nil, nil, fmt.Errorf("promoteAllWorkloadsToNewClusters: %w", err)
 	}
 
+	selectedChanges, err = s.changesAllowedByPolicy(selectedChanges)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if len(selectedChanges) == 0 {
 		s.logger.WithFields(
 			logrus.Fields{
@@ -87,9 +99,9 @@ func (s *PromotionManifestUpdate) Changes() ([]detect.WorkloadChange, clustercon
 func (s *PromotionManifestUpdate) changesFromPreviousEnv(changes []detect.WorkloadChange) ([]detect.WorkloadChange, error) {
 	var selected []detect.WorkloadChange
 
-	manifestSource, err := s.env.ManifestSource()
-	if err != nil {
-		return selected, err
+	manifestSource := s.clusters.SourceEnv
+	if manifestSource == "" {
+		return selected, fmt.Errorf("%s: %w", s.env, environment.ErrUnknownEnvironment)
 	}
 
 	for _, change := range changes {
@@ -108,6 +120,40 @@ func (s *PromotionManifestUpdate) changesFromPreviousEnv(changes []detect.Worklo
 	return selected, nil
 }
 
+func (s *PromotionManifestUpdate) changesAllowedByPolicy(changes []detect.WorkloadChange) ([]detect.WorkloadChange, error) {
+	allowed, skipped, err := changesAllowedByPolicy(s.logger, s.registry, changes, s.env, s.manual)
+	s.skipped = skipped
+	return allowed, err
+}
+
+// changesAllowedByPolicy drops the changes of the workloads whose promotion policy skips the target
+// environment, returning them as skipped to be reported.
+func changesAllowedByPolicy(l *logrus.Entry, registry clusterconf.WorkloadRegistry, changes []detect.WorkloadChange, env environment.Env, manual bool) ([]detect.WorkloadChange, []Skipped, error) {
+	var allowed []detect.WorkloadChange
+	var skipped []Skipped
+
+	for _, change := range changes {
+		workload, err := registry.Get(change.W.Name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("registry.Get: %w", err)
+		}
+
+		reason := workload.Spec.Promotion.SkipReason(env, manual)
+		if reason == "" {
+			allowed = append(allowed, change)
+			continue
+		}
+
+		l.WithFields(logrus.Fields{
+			"workload":   change.W.Name,
+			"target_env": env,
+			"reason":     reason,
+		}).Info(SkippedMsg)
+		skipped = append(skipped, Skipped{Workload: change.W.Name, Reason: reason})
+	}
+	return allowed, skipped, nil
+}
+
 func (s *PromotionManifestUpdate) AfterChanges(_ Results, _ clusterconf.Clusters) error {
 	s.logger.Info("nothing to do")
 	return nil
@@ -121,6 +167,11 @@ func (s *PromotionManifestUpdate) Assignes() []string {
 	return s.assignees
 }
 
+// Skipped returns the workloads with changes whose promotion policy skipped the target environment.
+func (s *PromotionManifestUpdate) Skipped() []Skipped {
+	return s.skipped
+}
+
 func (s *PromotionManifestUpdate) SourceCommits() []*github.Commit {
 	return s.sourceCommits
 }
@@ -22,24 +22,31 @@ type PromotionToNewCluster struct {
 	kind          Kind
 	sourceCommits []*github.Commit
 	assignees     []string
+	manual        bool
+	skipped       []Skipped
 
 	clusters clusterconf.ClusterDetection
 	detect   *detect.Detect
 	repo     *github.ManifestRepository
+	registry clusterconf.WorkloadRegistry
 
 	logger *logrus.Entry
 }
 
-func NewPromotionToNewCluster(ctx context.Context, l *logrus.Entry, env environment.Env, r *github.ManifestRepository, d *detect.Detect, c clusterconf.ClusterDetection) (*PromotionToNewCluster, error) {
+// NewPromotionToNewCluster applies the promotion policies of the workloads from the registry, manual
+// tells whether the promotion was triggered manually.
+func NewPromotionToNewCluster(ctx context.Context, l *logrus.Entry, env environment.Env, r *github.ManifestRepository, d *detect.Detect, c clusterconf.ClusterDetection, registry clusterconf.WorkloadRegistry, manual bool) (*PromotionToNewCluster, error) {
 	return &PromotionToNewCluster{
 		env:           env,
 		clusters:      c,
 		kind:          NewCluster,
 		assignees:     []string{},
+		manual:        manual,
 		sourceCommits: []*github.Commit{},
 		detect:        d,
 		logger:        l,
 		repo:          r,
+		registry:      registry,
 	}, nil
 }
 
@@ -51,6 +58,11 @@ func (s *PromotionToNewCluster) Assignes() []string {
 	return s.assignees
 }
 
+// Skipped returns the workloads of the source environment whose promotion policy skipped the target environment.
+func (s *PromotionToNewCluster) Skipped() []Skipped {
+	return s.skipped
+}
+
 func (s *PromotionToNewCluster) SourceCommits() []*github.Commit {
 	return s.sourceCommits
 }
@@ -60,11 +72,16 @@ func (s *PromotionToNewCluster) Changes() ([]detect.WorkloadChange, clusterconf.
 		return []detect.WorkloadChange{}, clusterconf.Clusters{}, nil
 	}
 
-	changes, err := s.detect.NewClusterWorkloads(s.env, s.clusters.PreviousEnv)
+	changes, err := s.detect.NewClusterWorkloads(s.clusters.SourceEnv, s.clusters.PreviousEnv)
 	if err != nil {
 		return []detect.WorkloadChange{}, clusterconf.Clusters{}, fmt.Errorf("promoteAllWorkloadsToNewClusters: %w", err)
 	}
 
+	changes, s.skipped, err = changesAllowedByPolicy(s.logger, s.registry, changes, s.env, s.manual)
+	if err != nil {
+		return []detect.WorkloadChange{}, clusterconf.Clusters{}, err
+	}
+
 	return changes, s.clusters.New, nil
 }
 
@@ -1,6 +1,7 @@
 package promotion
 
 import (
+	"fmt"
 	"sort"
 
 	"github.com/form3tech/k8s-promoter/internal/detect"
@@ -8,6 +9,7 @@ import (
 
 const (
 	NoChangesMsg = "No detected changes match our source environment. Not taking any action"
+	SkippedMsg   = "Skipping workload according to its promotion policy"
 )
 
 type Kind string
@@ -15,8 +17,21 @@ type Kind string
 const (
 	ManifestUpdate Kind = "manifests_updated"
 	NewCluster     Kind = "new_cluster_detected"
+	Decommission   Kind = "cluster_decommissioned"
+	Relocation     Kind = "cluster_relocated"
+	Resync         Kind = "drift_resynced"
 )
 
+// Skipped is a workload with changes which isn't promoted due to its promotion policy.
+type Skipped struct {
+	Workload string
+	Reason   string
+}
+
+func (s Skipped) String() string {
+	return fmt.Sprintf("%s: %s", s.Workload, s.Reason)
+}
+
 // Results holds the result of promotions. This structure of this:
 // map[cluster]map[workload]detect.WorkloadChange.
 type Results map[string]map[string]detect.WorkloadChange
@@ -0,0 +1,126 @@
+package promotion
+
+import (
+	"fmt"
+
+	"github.com/form3tech/k8s-promoter/internal/clusterconf"
+	"github.com/form3tech/k8s-promoter/internal/detect"
+	"github.com/form3tech/k8s-promoter/internal/environment"
+	"github.com/form3tech/k8s-promoter/internal/filesystem"
+	"github.com/form3tech/k8s-promoter/internal/github"
+	"github.com/go-git/go-billy/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// PromotionRelocation implements Promotion interface. It encapsulates the logic of moving the directories of the
+// clusters whose manifest folder changed in the config repo. The workloads are moved unchanged in AfterChanges,
+// instead of promoting them again from the previous environment.
+type PromotionRelocation struct {
+	env           environment.Env
+	kind          Kind
+	sourceCommits []*github.Commit
+	assignees     []string
+
+	clusters clusterconf.ClusterDetection
+	repo     *github.ManifestRepository
+
+	logger *logrus.Entry
+}
+
+func NewPromotionRelocation(l *logrus.Entry, env environment.Env, r *github.ManifestRepository, c clusterconf.ClusterDetection) *PromotionRelocation {
+	return &PromotionRelocation{
+		env:           env,
+		clusters:      c,
+		kind:          Relocation,
+		assignees:     []string{},
+		sourceCommits: []*github.Commit{},
+		logger:        l,
+		repo:          r,
+	}
+}
+
+func (s *PromotionRelocation) Kind() Kind {
+	return s.kind
+}
+
+func (s *PromotionRelocation) Assignes() []string {
+	return s.assignees
+}
+
+func (s *PromotionRelocation) SourceCommits() []*github.Commit {
+	return s.sourceCommits
+}
+
+// Changes returns the relocated clusters. There are no workload changes to promote to them.
+func (s *PromotionRelocation) Changes() ([]detect.WorkloadChange, clusterconf.Clusters, error) {
+	clusters := clusterconf.Clusters{}
+	for _, r := range s.clusters.Relocated {
+		clusters = append(clusters, r.Cluster)
+	}
+	return []detect.WorkloadChange{}, clusters, nil
+}
+
+// Relocations returns the relocated clusters together with the folders they are moved from.
+func (s *PromotionRelocation) Relocations() []clusterconf.Relocation {
+	return s.clusters.Relocated
+}
+
+// Moves returns the workloads moved along with the relocated clusters.
+func (s *PromotionRelocation) Moves() (Results, error) {
+	fs, err := s.repo.WorkingTreeFS()
+	if err != nil {
+		return nil, err
+	}
+
+	results := Results{}
+	for _, r := range s.clusters.Relocated {
+		workloads, err := s.clusters.Layout.Workloads(fs, r.From.ManifestFolder())
+		if err != nil {
+			return nil, fmt.Errorf("list workload directories: %w", err)
+		}
+
+		results[r.Cluster.Name()] = map[string]detect.WorkloadChange{}
+		for _, workload := range workloads {
+			results[r.Cluster.Name()][workload] = detect.WorkloadChange{
+				W:  detect.Workload{Name: workload, SourceEnv: string(s.env)},
+				Op: detect.OperationCopy,
+			}
+		}
+	}
+	return results, nil
+}
+
+func (s *PromotionRelocation) AfterChanges(_ Results, clusters clusterconf.Clusters) error {
+	fs, err := s.repo.WorkingTreeFS()
+	if err != nil {
+		return err
+	}
+
+	return s.moveClusters(fs, clusters)
+}
+
+func (s *PromotionRelocation) moveClusters(fs billy.Filesystem, clusters clusterconf.Clusters) error {
+	for _, r := range s.clusters.Relocated {
+		if !clusters.Contains(r.Cluster) {
+			continue
+		}
+
+		moves := [][2]string{{r.From.ManifestFolder(), r.Cluster.ManifestFolder()}}
+		if r.From.ConfigFolder() != "" && r.Cluster.ConfigFolder() != "" {
+			moves = append(moves, [2]string{r.From.ConfigFolder(), r.Cluster.ConfigFolder()})
+		}
+
+		for _, move := range moves {
+			s.logger.WithFields(logrus.Fields{
+				"cluster": r.Cluster.Name(),
+				"from":    move[0],
+				"to":      move[1],
+			}).Info("Moving folder of relocated cluster")
+
+			if err := filesystem.Move(fs, move[0], move[1]); err != nil {
+				return fmt.Errorf("move %s to %s: %w", move[0], move[1], err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package promotion
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/form3tech/k8s-promoter/internal/clusterconf"
+	"github.com/form3tech/k8s-promoter/internal/detect"
+	"github.com/form3tech/k8s-promoter/internal/environment"
+	"github.com/form3tech/k8s-promoter/internal/github"
+	"github.com/sirupsen/logrus"
+)
+
+// PromotionResync implements Promotion interface. It encapsulates the logic of copying the source over
+// the workloads which drifted from it in the clusters of the target environment. There are no source
+// commits, the pull requests are assigned to the owners of the drifted workloads instead.
+type PromotionResync struct {
+	env           environment.Env
+	kind          Kind
+	sourceCommits []*github.Commit
+	assignees     []string
+	manual        bool
+	skipped       []Skipped
+
+	changes  []detect.WorkloadChange
+	clusters clusterconf.Clusters
+	registry clusterconf.WorkloadRegistry
+
+	logger *logrus.Entry
+}
+
+// NewPromotionResync re-syncs the drifted workloads of the changes in the drifted clusters. It applies the
+// promotion policies of the workloads from the registry, manual tells whether the resync was triggered manually.
+func NewPromotionResync(ctx context.Context, l *logrus.Entry, env environment.Env, r *github.ManifestRepository, changes []detect.WorkloadChange, clusters clusterconf.Clusters, registry clusterconf.WorkloadRegistry, manual bool) (*PromotionResync, error) {
+	var owners []string
+	for _, change := range changes {
+		workload, err := registry.Get(change.W.Name)
+		if err != nil {
+			return nil, fmt.Errorf("registry.Get: %w", err)
+		}
+		owners = append(owners, workload.Metadata.Owners.Users...)
+	}
+
+	assignees, err := r.Assignees(ctx, owners)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PromotionResync{
+		env:           env,
+		kind:          Resync,
+		assignees:     assignees,
+		manual:        manual,
+		sourceCommits: []*github.Commit{},
+		changes:       changes,
+		clusters:      clusters,
+		registry:      registry,
+		logger:        l,
+	}, nil
+}
+
+func (s *PromotionResync) Kind() Kind {
+	return s.kind
+}
+
+func (s *PromotionResync) Assignes() []string {
+	return s.assignees
+}
+
+// Skipped returns the drifted workloads whose promotion policy skips the target environment.
+func (s *PromotionResync) Skipped() []Skipped {
+	return s.skipped
+}
+
+func (s *PromotionResync) SourceCommits() []*github.Commit {
+	return s.sourceCommits
+}
+
+func (s *PromotionResync) Changes() ([]detect.WorkloadChange, clusterconf.Clusters, error) {
+	changes, skipped, err := changesAllowedByPolicy(s.logger, s.registry, s.changes, s.env, s.manual)
+	if err != nil {
+		return []detect.WorkloadChange{}, clusterconf.Clusters{}, err
+	}
+	s.skipped = skipped
+
+	return changes, s.clusters, nil
+}
+
+func (s *PromotionResync) AfterChanges(_ Results, _ clusterconf.Clusters) error {
+	return nil
+}
@@ -24,16 +24,19 @@ type GithubFake struct {
 	CreatedPullRequests     []github.PullRequest
 	CreateLabelRequests     []AddLabelRequest
 	CreateAssigneesRequests []AddAssigneesRequest
+	ReviewersRequests       []RequestReviewersRequest
 	t                       *testing.T
 	r                       *gin.Engine
 	orgName                 string
 	repoName                string
 	adminRepoName           string
 	repoAssignees           []string
+	repoTeams               []string
 	gitFake                 *FakeGit
 	baseCommit              *commitFake
 	commits                 []*commitFake
 	content                 map[string]string
+	configRefs              map[string]string
 }
 
 type commitFake struct {
@@ -52,6 +55,12 @@ type AddAssigneesRequest struct {
 	Assignees   []string
 }
 
+type RequestReviewersRequest struct {
+	PullNumber    int
+	Reviewers     []string
+	TeamReviewers []string
+}
+
 type GitHubFakeOption func(gh *GithubFake)
 
 func NewGithubFake(t *testing.T, opts ...GitHubFakeOption) *GithubFake {
@@ -70,6 +79,7 @@ func NewGithubFake(t *testing.T, opts ...GitHubFakeOption) *GithubFake {
 	}
 
 	f.content = make(map[string]string)
+	f.configRefs = make(map[string]string)
 
 	return f
 }
@@ -99,6 +109,12 @@ func WithRepoAssignees(assignees ...string) GitHubFakeOption {
 	}
 }
 
+func WithRepoTeams(teams ...string) GitHubFakeOption {
+	return func(gh *GithubFake) {
+		gh.repoTeams = teams
+	}
+}
+
 func (f *GithubFake) StartServer() *GithubFake {
 	f.server = httptest.NewServer(f.r)
 	return f
@@ -108,6 +124,11 @@ func (f *GithubFake) SetContent(path, content string) {
 	f.content[path] = content
 }
 
+// SetConfigRef sets the commit the ref of the config repository points at.
+func (f *GithubFake) SetConfigRef(ref, commit string) {
+	f.configRefs[ref] = commit
+}
+
 func (f *GithubFake) URL() string {
 	return f.server.URL
 }
@@ -136,9 +157,15 @@ func (f *GithubFake) SetupRoutes(r *gin.Engine) {
 	commits := fmt.Sprintf("/api/v3/repos/%s/%s/compare/:base_head", f.orgName, f.repoName)
 	r.GET(commits, f.handleCommitComparison)
 
-	contents := fmt.Sprintf("/api/v3/repos/%s/%s/contents/:path", f.orgName, f.adminRepoName)
+	contents := fmt.Sprintf("/api/v3/repos/%s/%s/contents/*path", f.orgName, f.adminRepoName)
 	r.GET(contents, f.handleContents)
 
+	tree := fmt.Sprintf("/api/v3/repos/%s/%s/git/trees/:sha", f.orgName, f.adminRepoName)
+	r.GET(tree, f.handleTree)
+
+	configCommit := fmt.Sprintf("/api/v3/repos/%s/%s/commits/:ref", f.orgName, f.adminRepoName)
+	r.GET(configCommit, f.handleConfigCommit)
+
 	isAssignee := fmt.Sprintf("/api/v3/repos/%s/%s/assignees/:assignee", f.orgName, f.repoName)
 	r.GET(isAssignee, f.handleIsAssignee)
 
@@ -150,6 +177,12 @@ func (f *GithubFake) SetupRoutes(r *gin.Engine) {
 
 	createAssignees := fmt.Sprintf("/api/v3/repos/%s/%s/issues/:number/assignees", f.orgName, f.repoName)
 	r.POST(createAssignees, f.handleAddAssignees)
+
+	requestReviewers := fmt.Sprintf("/api/v3/repos/%s/%s/pulls/:number/requested_reviewers", f.orgName, f.repoName)
+	r.POST(requestReviewers, f.handleRequestReviewers)
+
+	isTeamRepo := fmt.Sprintf("/api/v3/orgs/%s/teams/:team/repos/%s/%s", f.orgName, f.orgName, f.repoName)
+	r.GET(isTeamRepo, f.handleIsTeamRepo)
 }
 
 func (f *GithubFake) InitClient() *GithubFake {
@@ -210,6 +243,24 @@ func (f *GithubFake) handleIsAssignee(c *gin.Context) {
 	c.Writer.WriteHeader(http.StatusNotFound)
 }
 
+func (f *GithubFake) handleIsTeamRepo(c *gin.Context) {
+	team, ok := c.Params.Get("team")
+	require.True(f.t, ok, "missing team")
+
+	for _, repoTeam := range f.repoTeams {
+		if team == repoTeam {
+			res, err := json.Marshal(github.Repository{Name: &f.repoName})
+			require.NoError(f.t, err)
+
+			_, err = c.Writer.Write(res)
+			require.NoError(f.t, err)
+			return
+		}
+	}
+
+	c.Writer.WriteHeader(http.StatusNotFound)
+}
+
 func (f *GithubFake) handleCreatePullRequest(c *gin.Context) {
 	var newPR github.NewPullRequest
 
@@ -243,9 +294,13 @@ func (f *GithubFake) handleContents(c *gin.Context) {
 
 	path, ok := c.Params.Get("path")
 	require.True(f.t, ok, "missing path")
+	path = strings.TrimPrefix(path, "/")
 
 	content, ok := f.content[path]
-	require.True(f.t, ok, "missing path in content map")
+	if !ok {
+		f.handleDirectoryContents(c, path)
+		return
+	}
 
 	rType := "file"
 	enc := "base64"
@@ -266,6 +321,64 @@ func (f *GithubFake) handleContents(c *gin.Context) {
 	require.NoError(f.t, err)
 }
 
+// handleDirectoryContents lists the files and directories directly in the directory dir of the content.
+func (f *GithubFake) handleDirectoryContents(c *gin.Context, dir string) {
+	seen := map[string]bool{}
+	var entries []*github.RepositoryContent
+	for path := range f.content {
+		if !strings.HasPrefix(path, dir+"/") {
+			continue
+		}
+
+		name := strings.SplitN(strings.TrimPrefix(path, dir+"/"), "/", 2)
+		entryPath := dir + "/" + name[0]
+		if seen[entryPath] {
+			continue
+		}
+		seen[entryPath] = true
+
+		entryType := "file"
+		if len(name) > 1 {
+			entryType = "dir"
+		}
+		entries = append(entries, &github.RepositoryContent{Type: github.String(entryType), Name: github.String(name[0]), Path: github.String(entryPath)})
+	}
+	require.NotEmpty(f.t, entries, "missing path in content map")
+
+	res, err := json.Marshal(entries)
+	require.NoError(f.t, err)
+
+	_, err = c.Writer.Write(res)
+	require.NoError(f.t, err)
+}
+
+func (f *GithubFake) handleTree(c *gin.Context) {
+	var entries []*github.TreeEntry
+	for path := range f.content {
+		entries = append(entries, &github.TreeEntry{Type: github.String("blob"), Path: github.String(path)})
+	}
+
+	res, err := json.Marshal(github.Tree{Entries: entries})
+	require.NoError(f.t, err)
+
+	_, err = c.Writer.Write(res)
+	require.NoError(f.t, err)
+}
+
+func (f *GithubFake) handleConfigCommit(c *gin.Context) {
+	ref, ok := c.Params.Get("ref")
+	require.True(f.t, ok, "missing ref")
+
+	commit, ok := f.configRefs[ref]
+	if !ok {
+		c.Writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	_, err := c.Writer.WriteString(commit)
+	require.NoError(f.t, err)
+}
+
 func (f *GithubFake) handleAddIssueLabels(c *gin.Context) {
 	issueNumberParam, ok := c.Params.Get("number")
 	require.True(f.t, ok, "missing PR number while trying to create new PR label")
@@ -379,6 +492,41 @@ func (f *GithubFake) FindPRLabel(number int, label string) github.Label {
 	return github.Label{}
 }
 
+func (f *GithubFake) handleRequestReviewers(c *gin.Context) {
+	pullNumberParam, ok := c.Params.Get("number")
+	require.True(f.t, ok, "missing PR number while trying to request reviewers")
+
+	pullNumber, err := strconv.Atoi(pullNumberParam)
+	require.NoError(f.t, err)
+
+	var reqBody github.ReviewersRequest
+	err = json.NewDecoder(c.Request.Body).Decode(&reqBody)
+	require.NoError(f.t, err)
+
+	res, err := json.Marshal(github.PullRequest{Number: &pullNumber})
+	require.NoError(f.t, err)
+
+	c.Writer.WriteHeader(http.StatusCreated)
+	_, err = c.Writer.Write(res)
+	require.NoError(f.t, err)
+
+	f.ReviewersRequests = append(f.ReviewersRequests, RequestReviewersRequest{
+		PullNumber:    pullNumber,
+		Reviewers:     reqBody.Reviewers,
+		TeamReviewers: reqBody.TeamReviewers,
+	})
+}
+
+// FindPRReviewers returns the users and teams requested to review the PR, if any.
+func (f *GithubFake) FindPRReviewers(number int) ([]string, []string) {
+	for _, r := range f.ReviewersRequests {
+		if r.PullNumber == number {
+			return r.Reviewers, r.TeamReviewers
+		}
+	}
+	return nil, nil
+}
+
 func (f *GithubFake) FindPRAssignees(number int) []string {
 	var assignees []string
 